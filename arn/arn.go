@@ -0,0 +1,119 @@
+//
+// arn: This package parses and builds Amazon Resource Names (ARNs)
+//
+// See http://docs.aws.amazon.com/general/latest/gr/aws-arns-and-namespaces.html
+//
+
+package arn
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ARN is an Amazon Resource Name, of the form
+//
+//	arn:partition:service:region:account-id:resource
+//
+// Region and AccountID are empty for resources that don't belong to any,
+// such as S3 buckets or IAM users respectively.
+type ARN struct {
+	// Partition is "aws", or another partition such as "aws-cn".
+	Partition string
+
+	// Service is the namespace of the service, such as "ec2".
+	Service string
+
+	Region    string
+	AccountID string
+
+	// Resource identifies the resource within the service. It is
+	// split into a type and an id by ResourceType and ResourceID.
+	Resource string
+}
+
+// Parse parses an ARN.
+func Parse(s string) (ARN, error) {
+	if !strings.HasPrefix(s, "arn:") {
+		return ARN{}, fmt.Errorf("arn: invalid prefix in %q", s)
+	}
+	parts := strings.SplitN(s, ":", 6)
+	if len(parts) != 6 {
+		return ARN{}, fmt.Errorf("arn: not enough sections in %q", s)
+	}
+	a := ARN{
+		Partition: parts[1],
+		Service:   parts[2],
+		Region:    parts[3],
+		AccountID: parts[4],
+		Resource:  parts[5],
+	}
+	if a.Partition == "" || a.Service == "" || a.Resource == "" {
+		return ARN{}, fmt.Errorf("arn: missing partition, service or resource in %q", s)
+	}
+	return a, nil
+}
+
+// IsARN reports whether s parses as an ARN.
+func IsARN(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+// String returns the ARN in its textual form.
+func (a ARN) String() string {
+	return "arn:" + a.Partition + ":" + a.Service + ":" + a.Region + ":" + a.AccountID + ":" + a.Resource
+}
+
+// ResourceType returns the type of the resource, such as "instance" for
+// arn:aws:ec2:us-east-1:123456789012:instance/i-1234, or "" for the
+// resources of services that don't qualify them with a type. See
+// ResourceID.
+func (a ARN) ResourceType() string {
+	typ, _ := a.splitResource()
+	return typ
+}
+
+// ResourceID returns the resource without its type, such as "i-1234" for
+// arn:aws:ec2:us-east-1:123456789012:instance/i-1234.
+//
+// The type of most resources is separated from the id by the first slash
+// or colon, and the id may hold more of them, such as the path of IAM
+// users ("user", "division/Bob"), the cluster of ECS tasks ("task",
+// "default/0123") or the stream of DynamoDB tables ("table",
+// "Music/stream/2015-05-11T21:21:33.291"). Some services only qualify
+// some resources with a type:
+//
+//	s3   bucket or bucket/key, and accesspoint/name or outpost/id/...
+//	sqs  queue name
+//	sns  topic or topic:subscription, and app/platform/name or
+//	     endpoint/platform/app/id for mobile push
+func (a ARN) ResourceID() string {
+	_, id := a.splitResource()
+	return id
+}
+
+// typedResources lists the resource types of the services whose resources
+// aren't all typed. Other resources have no type.
+var typedResources = map[string][]string{
+	"s3":  {"accesspoint", "outpost"},
+	"sqs": {},
+	"sns": {"app", "endpoint"},
+}
+
+func (a ARN) splitResource() (typ, id string) {
+	i := strings.IndexAny(a.Resource, "/:")
+	if i < 0 {
+		return "", a.Resource
+	}
+	if types, ok := typedResources[a.Service]; ok {
+		typ := a.Resource[:i]
+		for _, t := range types {
+			if t == typ && a.Resource[i] == '/' {
+				return typ, a.Resource[i+1:]
+			}
+		}
+		return "", a.Resource
+	}
+	return a.Resource[:i], a.Resource[i+1:]
+}
@@ -0,0 +1,91 @@
+package arn_test
+
+import (
+	"testing"
+
+	"github.com/goamz/goamz/arn"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&S{})
+
+type S struct{}
+
+var parseTests = []struct {
+	s       string
+	arn     arn.ARN
+	typ, id string
+}{{
+	s:   "arn:aws:ec2:us-east-1:123456789012:instance/i-1234",
+	arn: arn.ARN{Partition: "aws", Service: "ec2", Region: "us-east-1", AccountID: "123456789012", Resource: "instance/i-1234"},
+	typ: "instance", id: "i-1234",
+}, {
+	s:   "arn:aws:iam::123456789012:user/division/Bob",
+	arn: arn.ARN{Partition: "aws", Service: "iam", AccountID: "123456789012", Resource: "user/division/Bob"},
+	typ: "user", id: "division/Bob",
+}, {
+	s:   "arn:aws:iam::123456789012:root",
+	arn: arn.ARN{Partition: "aws", Service: "iam", AccountID: "123456789012", Resource: "root"},
+	id:  "root",
+}, {
+	s:   "arn:aws:s3:::bucket/path/to:key",
+	arn: arn.ARN{Partition: "aws", Service: "s3", Resource: "bucket/path/to:key"},
+	id:  "bucket/path/to:key",
+}, {
+	s:   "arn:aws:s3:us-west-2:123456789012:accesspoint/reports",
+	arn: arn.ARN{Partition: "aws", Service: "s3", Region: "us-west-2", AccountID: "123456789012", Resource: "accesspoint/reports"},
+	typ: "accesspoint", id: "reports",
+}, {
+	s:   "arn:aws-cn:sns:cn-north-1:123456789012:topic:0f3f4c1e-8a1b-4f0c-9c9e-1a2b3c4d5e6f",
+	arn: arn.ARN{Partition: "aws-cn", Service: "sns", Region: "cn-north-1", AccountID: "123456789012", Resource: "topic:0f3f4c1e-8a1b-4f0c-9c9e-1a2b3c4d5e6f"},
+	id:  "topic:0f3f4c1e-8a1b-4f0c-9c9e-1a2b3c4d5e6f",
+}, {
+	s:   "arn:aws:sns:us-east-1:123456789012:endpoint/GCM/app/5f3c",
+	arn: arn.ARN{Partition: "aws", Service: "sns", Region: "us-east-1", AccountID: "123456789012", Resource: "endpoint/GCM/app/5f3c"},
+	typ: "endpoint", id: "GCM/app/5f3c",
+}, {
+	s:   "arn:aws:sqs:us-east-1:123456789012:queue",
+	arn: arn.ARN{Partition: "aws", Service: "sqs", Region: "us-east-1", AccountID: "123456789012", Resource: "queue"},
+	id:  "queue",
+}, {
+	s:   "arn:aws:ecs:us-east-1:123456789012:task/default/0123abcd",
+	arn: arn.ARN{Partition: "aws", Service: "ecs", Region: "us-east-1", AccountID: "123456789012", Resource: "task/default/0123abcd"},
+	typ: "task", id: "default/0123abcd",
+}, {
+	s:   "arn:aws:ecs:us-east-1:123456789012:task-definition/web:3",
+	arn: arn.ARN{Partition: "aws", Service: "ecs", Region: "us-east-1", AccountID: "123456789012", Resource: "task-definition/web:3"},
+	typ: "task-definition", id: "web:3",
+}, {
+	s:   "arn:aws:dynamodb:us-east-1:123456789012:table/Music/stream/2015-05-11T21:21:33.291",
+	arn: arn.ARN{Partition: "aws", Service: "dynamodb", Region: "us-east-1", AccountID: "123456789012", Resource: "table/Music/stream/2015-05-11T21:21:33.291"},
+	typ: "table", id: "Music/stream/2015-05-11T21:21:33.291",
+}}
+
+func (s *S) TestParse(c *C) {
+	for _, t := range parseTests {
+		a, err := arn.Parse(t.s)
+		c.Assert(err, IsNil, Commentf("%s", t.s))
+		c.Check(a, Equals, t.arn)
+		c.Check(a.String(), Equals, t.s)
+		c.Check(a.ResourceType(), Equals, t.typ, Commentf("%s", t.s))
+		c.Check(a.ResourceID(), Equals, t.id, Commentf("%s", t.s))
+	}
+}
+
+func (s *S) TestParseErrors(c *C) {
+	for _, bad := range []string{
+		"",
+		"i-1234",
+		"arn:aws:ec2:us-east-1:123456789012",
+		"arn::ec2:us-east-1:123456789012:instance/i-1234",
+		"arn:aws:ec2:us-east-1:123456789012:",
+	} {
+		_, err := arn.Parse(bad)
+		c.Check(err, ErrorMatches, "arn: .*", Commentf("%q", bad))
+		c.Check(arn.IsARN(bad), Equals, false)
+	}
+}
@@ -7,34 +7,72 @@
 package autoscaling
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/xml"
 	"fmt"
-	"log"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"sort"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/goamz/goamz/aws"
 )
 
-const debug = false
-
 var timeNow = time.Now
 
 // AutoScaling contains the details of the AWS region to perform operations against.
 type AutoScaling struct {
 	aws.Auth
 	aws.Region
+	httpClient *http.Client
+	ctx        context.Context
+	clock      *aws.Clock
+
+	// Logger, if set, is given the lines selected by LogLevel about
+	// requests, with credentials redacted.
+	Logger   aws.Logger
+	LogLevel aws.LogLevel
+
+	// Instrumentation, if set, is told about every attempt of the
+	// calls of autoscaling.
+	Instrumentation aws.Instrumentation
+
+	// Handlers are run on every request of the client, after the
+	// aws.GlobalHandlers.
+	Handlers aws.Handlers
 }
 
 // New creates a new AutoScaling Client.
 func New(auth aws.Auth, region aws.Region) *AutoScaling {
-	return &AutoScaling{auth, region}
+	return NewWithClient(auth, region, http.DefaultClient)
+}
+
+// NewWithClient creates a new AutoScaling Client sending its requests
+// with client.
+func NewWithClient(auth aws.Auth, region aws.Region, client *http.Client) *AutoScaling {
+	return &AutoScaling{Auth: auth, Region: region, httpClient: client, clock: &aws.Clock{}}
+}
+
+// WithContext returns a shallow copy of as whose requests are bound to
+// ctx, so that they are abandoned when ctx is cancelled or its deadline
+// expires. The provided ctx must be non-nil.
+func (as *AutoScaling) WithContext(ctx context.Context) *AutoScaling {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *as
+	c.ctx = ctx
+	return &c
+}
+
+// Context returns the context requests made by as are bound to.
+func (as *AutoScaling) Context() context.Context {
+	if as.ctx != nil {
+		return as.ctx
+	}
+	return context.Background()
 }
 
 // ----------------------------------------------------------------------------
@@ -63,52 +101,51 @@ func (err *Error) Error() string {
 	return fmt.Sprintf("%s (%s)", err.Message, err.Code)
 }
 
+// HTTPStatusCode, ErrorCode, ErrorMessage and RequestID implement
+// aws.APIError.
+func (err *Error) HTTPStatusCode() int  { return err.StatusCode }
+func (err *Error) ErrorCode() string    { return err.Code }
+func (err *Error) ErrorMessage() string { return err.Message }
+func (err *Error) RequestID() string    { return err.RequestId }
+
 type xmlErrors struct {
 	RequestId string  `xml:"RequestId"`
 	Errors    []Error `xml:"Error"`
 }
 
+// handlers are the handlers of AutoScaling requests, run before the
+// aws.GlobalHandlers and the Handlers of the client.
+var handlers = func() aws.Handlers {
+	h := aws.QueryHandlers()
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("autoscaling.UnmarshalError", buildError))
+	return h
+}()
+
 func (as *AutoScaling) query(params map[string]string, resp interface{}) error {
 	params["Version"] = "2011-01-01"
-	data := strings.NewReader(multimap(params).Encode())
-
-	hreq, err := http.NewRequest("POST", as.Region.AutoScalingEndpoint+"/", data)
-	if err != nil {
-		return err
-	}
-
-	hreq.Header.Set("Content-Type", "application/x-www-form-urlencoded; param=value")
-
-	token := as.Auth.Token()
-	if token != "" {
-		hreq.Header.Set("X-Amz-Security-Token", token)
-	}
-
-	signer := aws.NewV4Signer(as.Auth, "autoscaling", as.Region)
-	signer.Sign(hreq)
-
-	if debug {
-		log.Printf("%v -> {\n", hreq)
-	}
-	r, err := http.DefaultClient.Do(hreq)
-
+	ep, err := aws.ResolveEndpoint("autoscaling", as.Region)
 	if err != nil {
-		log.Printf("Error calling Amazon %v", err)
 		return err
 	}
-
-	defer r.Body.Close()
-
-	if debug {
-		dump, _ := httputil.DumpResponse(r, true)
-		log.Printf("response:\n")
-		log.Printf("%v\n}\n", string(dump))
-	}
-	if r.StatusCode != 200 {
-		return buildError(r)
-	}
-	err = xml.NewDecoder(r.Body).Decode(resp)
-	return err
+	r := &aws.Request{
+		Service:         ep.SigningName,
+		Operation:       params["Action"],
+		Region:          as.Region,
+		Auth:            as.Auth,
+		Context:         as.Context(),
+		Method:          "POST",
+		SigningRegion:   ep.SigningRegion,
+		Endpoint:        ep.URL,
+		Params:          params,
+		Result:          resp,
+		HTTPClient:      as.httpClient,
+		Clock:           as.clock,
+		Logger:          as.Logger,
+		LogLevel:        as.LogLevel,
+		Instrumentation: as.Instrumentation,
+		Handlers:        aws.ChainHandlers(handlers, aws.GlobalHandlers, as.Handlers),
+	}
+	return r.Send()
 }
 
 func buildError(r *http.Response) error {
@@ -776,6 +813,18 @@ func (as *AutoScaling) DescribeAutoScalingGroups(names []string, maxRecords int,
 	return resp, nil
 }
 
+// DescribeAutoScalingGroupsPages returns a Pager over the pages of
+// DescribeAutoScalingGroups results.
+func (as *AutoScaling) DescribeAutoScalingGroupsPages(names []string, maxRecords int) *aws.Pager[*DescribeAutoScalingGroupsResp] {
+	return aws.NewPager(func(token string) (*DescribeAutoScalingGroupsResp, string, error) {
+		resp, err := as.DescribeAutoScalingGroups(names, maxRecords, token)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // DescribeAutoScalingInstances response wrapper
 //
 // See http://goo.gl/ckzORt for more details.
@@ -812,6 +861,18 @@ func (as *AutoScaling) DescribeAutoScalingInstances(ids []string, maxRecords int
 	return resp, nil
 }
 
+// DescribeAutoScalingInstancesPages returns a Pager over the pages of
+// DescribeAutoScalingInstances results.
+func (as *AutoScaling) DescribeAutoScalingInstancesPages(ids []string, maxRecords int) *aws.Pager[*DescribeAutoScalingInstancesResp] {
+	return aws.NewPager(func(token string) (*DescribeAutoScalingInstancesResp, string, error) {
+		resp, err := as.DescribeAutoScalingInstances(ids, maxRecords, token)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // DescribeAutoScalingNotificationTypes response wrapper
 //
 // See http://goo.gl/pmLIoE for more details.
@@ -870,6 +931,18 @@ func (as *AutoScaling) DescribeLaunchConfigurations(names []string, maxRecords i
 	return resp, nil
 }
 
+// DescribeLaunchConfigurationsPages returns a Pager over the pages of
+// DescribeLaunchConfigurations results.
+func (as *AutoScaling) DescribeLaunchConfigurationsPages(names []string, maxRecords int) *aws.Pager[*DescribeLaunchConfigurationsResp] {
+	return aws.NewPager(func(token string) (*DescribeLaunchConfigurationsResp, string, error) {
+		resp, err := as.DescribeLaunchConfigurations(names, maxRecords, token)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // DescribeLifecycleHookTypesResult wraps a DescribeLifecycleHookTypes response
 //
 // See http://goo.gl/qiAH31 for more details.
@@ -1013,6 +1086,18 @@ func (as *AutoScaling) DescribeNotificationConfigurations(asgNames []string, max
 	return resp, nil
 }
 
+// DescribeNotificationConfigurationsPages returns a Pager over the pages of
+// DescribeNotificationConfigurations results.
+func (as *AutoScaling) DescribeNotificationConfigurationsPages(asgNames []string, maxRecords int) *aws.Pager[*DescribeNotificationConfigurationsResp] {
+	return aws.NewPager(func(token string) (*DescribeNotificationConfigurationsResp, string, error) {
+		resp, err := as.DescribeNotificationConfigurations(asgNames, maxRecords, token)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // Alarm encapsulates the Alarm data type.
 //
 // See http://goo.gl/Q0uPAB for more details
@@ -1072,6 +1157,18 @@ func (as *AutoScaling) DescribePolicies(asgName string, policyNames []string, ma
 	return resp, nil
 }
 
+// DescribePoliciesPages returns a Pager over the pages of DescribePolicies
+// results.
+func (as *AutoScaling) DescribePoliciesPages(asgName string, policyNames []string, maxRecords int) *aws.Pager[*DescribePoliciesResp] {
+	return aws.NewPager(func(token string) (*DescribePoliciesResp, string, error) {
+		resp, err := as.DescribePolicies(asgName, policyNames, maxRecords, token)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // Activity encapsulates the Activity data type
 //
 // See http://goo.gl/fRaVi1 for more details
@@ -1125,6 +1222,18 @@ func (as *AutoScaling) DescribeScalingActivities(asgName string, activityIds []s
 	return resp, nil
 }
 
+// DescribeScalingActivitiesPages returns a Pager over the pages of
+// DescribeScalingActivities results.
+func (as *AutoScaling) DescribeScalingActivitiesPages(asgName string, activityIds []string, maxRecords int) *aws.Pager[*DescribeScalingActivitiesResp] {
+	return aws.NewPager(func(token string) (*DescribeScalingActivitiesResp, string, error) {
+		resp, err := as.DescribeScalingActivities(asgName, activityIds, maxRecords, token)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // ProcessType encapsulates the Auto Scaling process data type
 //
 // See http://goo.gl/9BvNik for more details.
@@ -1224,6 +1333,25 @@ func (as *AutoScaling) DescribeScheduledActions(options *DescribeScheduledAction
 	return resp, nil
 }
 
+// DescribeScheduledActionsPages returns a Pager over the pages of
+// DescribeScheduledActions results, starting from options.NextToken.
+func (as *AutoScaling) DescribeScheduledActionsPages(options *DescribeScheduledActionsParams) *aws.Pager[*DescribeScheduledActionsResult] {
+	return aws.NewPager(func(token string) (*DescribeScheduledActionsResult, string, error) {
+		var page DescribeScheduledActionsParams
+		if options != nil {
+			page = *options
+		}
+		if token != "" {
+			page.NextToken = token
+		}
+		resp, err := as.DescribeScheduledActions(&page)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // DescribeTags response wrapper
 //
 // See http://goo.gl/ZTEU3G for more details.
@@ -1257,6 +1385,17 @@ func (as *AutoScaling) DescribeTags(filter *Filter, maxRecords int, nextToken st
 	return resp, nil
 }
 
+// DescribeTagsPages returns a Pager over the pages of DescribeTags results.
+func (as *AutoScaling) DescribeTagsPages(filter *Filter, maxRecords int) *aws.Pager[*DescribeTagsResp] {
+	return aws.NewPager(func(token string) (*DescribeTagsResp, string, error) {
+		resp, err := as.DescribeTags(filter, maxRecords, token)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // DescribeTerminationPolicyTypes response wrapper
 //
 // See http://goo.gl/ZTEU3G for more details.
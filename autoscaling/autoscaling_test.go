@@ -1,6 +1,7 @@
 package autoscaling
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -726,6 +727,27 @@ func (s *S) TestDescribePolicies(c *C) {
 	c.Assert(resp, DeepEquals, expected)
 }
 
+func (s *S) TestDescribePoliciesPages(c *C) {
+	lastPage := strings.Replace(DescribePoliciesResponse, "<NextToken>3ef417fe-9202-12-8ddd-d13e1313413</NextToken>", "", 1)
+	testServer.Response(200, nil, DescribePoliciesResponse)
+	testServer.Response(200, nil, lastPage)
+
+	var pages int
+	err := s.as.DescribePoliciesPages("my-test-asg", nil, 2).EachPage(func(page *DescribePoliciesResp, last bool) bool {
+		pages++
+		c.Assert(page.ScalingPolicies, HasLen, 2)
+		c.Assert(last, Equals, pages == 2)
+		return true
+	})
+	c.Assert(err, IsNil)
+	c.Assert(pages, Equals, 2)
+
+	reqs := testServer.WaitRequests(2)
+	c.Assert(reqs[0].PostForm.Get("NextToken"), Equals, "")
+	c.Assert(reqs[1].PostForm.Get("NextToken"), Equals, "3ef417fe-9202-12-8ddd-d13e1313413")
+	c.Assert(reqs[1].PostForm.Get("MaxRecords"), Equals, "2")
+}
+
 func (s *S) TestDescribeScalingActivities(c *C) {
 	testServer.Response(200, nil, DescribeScalingActivitiesResponse)
 	resp, err := s.as.DescribeScalingActivities("my-test-asg", []string{}, 1, "")
@@ -1,6 +1,7 @@
 package aws
 
 import (
+	"context"
 	"time"
 )
 
@@ -15,6 +16,7 @@ type AttemptStrategy struct {
 
 type Attempt struct {
 	strategy AttemptStrategy
+	ctx      context.Context
 	last     time.Time
 	end      time.Time
 	force    bool
@@ -23,9 +25,19 @@ type Attempt struct {
 
 // Start begins a new sequence of attempts for the given strategy.
 func (s AttemptStrategy) Start() *Attempt {
+	return s.StartWithContext(context.Background())
+}
+
+// StartWithContext begins a new sequence of attempts for the given
+// strategy that stops as soon as ctx is cancelled or its deadline
+// expires, even if the strategy would otherwise allow more attempts.
+// The first attempt is always made so that callers observe the
+// context error from the operation itself.
+func (s AttemptStrategy) StartWithContext(ctx context.Context) *Attempt {
 	now := time.Now()
 	return &Attempt{
 		strategy: s,
+		ctx:      ctx,
 		last:     now,
 		end:      now.Add(s.Total),
 		force:    true,
@@ -35,6 +47,9 @@ func (s AttemptStrategy) Start() *Attempt {
 // Next waits until it is time to perform the next attempt or returns
 // false if it is time to stop trying.
 func (a *Attempt) Next() bool {
+	if !a.force && a.ctx.Err() != nil {
+		return false
+	}
 	now := time.Now()
 	sleep := a.nextSleep(now)
 	if !a.force && !now.Add(sleep).Before(a.end) && a.strategy.Min <= a.count {
@@ -42,7 +57,13 @@ func (a *Attempt) Next() bool {
 	}
 	a.force = false
 	if sleep > 0 && a.count > 0 {
-		time.Sleep(sleep)
+		t := time.NewTimer(sleep)
+		select {
+		case <-t.C:
+		case <-a.ctx.Done():
+			t.Stop()
+			return false
+		}
 		now = time.Now()
 	}
 	a.count++
@@ -50,6 +71,18 @@ func (a *Attempt) Next() bool {
 	return true
 }
 
+// Count returns the number of attempts made so far, the current one
+// included.
+func (a *Attempt) Count() int {
+	return a.count
+}
+
+// Err returns the error of the context the attempt was started with,
+// which is non-nil once the attempts were stopped by cancellation.
+func (a *Attempt) Err() error {
+	return a.ctx.Err()
+}
+
 func (a *Attempt) nextSleep(now time.Time) time.Duration {
 	sleep := a.strategy.Delay - now.Sub(a.last)
 	if sleep < 0 {
@@ -62,6 +95,9 @@ func (a *Attempt) nextSleep(now time.Time) time.Duration {
 // one fails. If it returns true, the following call to Next is
 // guaranteed to return true.
 func (a *Attempt) HasNext() bool {
+	if !a.force && a.ctx.Err() != nil {
+		return false
+	}
 	if a.force || a.strategy.Min > a.count {
 		return true
 	}
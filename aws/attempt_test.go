@@ -1,6 +1,7 @@
 package aws_test
 
 import (
+	"context"
 	"time"
 
 	"github.com/goamz/goamz/aws"
@@ -56,3 +57,22 @@ func (S) TestAttemptNextHasNext(c *C) {
 	c.Assert(a.HasNext(), Equals, false)
 	c.Assert(a.Next(), Equals, false)
 }
+
+func (S) TestAttemptWithContext(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a := aws.AttemptStrategy{Min: 5}.StartWithContext(ctx)
+	c.Assert(a.Next(), Equals, true)
+	c.Assert(a.HasNext(), Equals, false)
+	c.Assert(a.Next(), Equals, false)
+	c.Assert(a.Err(), Equals, context.Canceled)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 0.05e9)
+	defer cancel()
+	a = aws.AttemptStrategy{Total: 5e9, Delay: 1e9}.StartWithContext(ctx)
+	t0 := time.Now()
+	c.Assert(a.Next(), Equals, true)
+	c.Assert(a.Next(), Equals, false)
+	c.Assert(time.Since(t0) < 0.5e9, Equals, true)
+	c.Assert(a.Err(), Equals, context.DeadlineExceeded)
+}
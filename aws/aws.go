@@ -10,17 +10,19 @@
 package aws
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
-
-	"github.com/vaughan0/go-ini"
 )
 
 // Defines the valid signers
@@ -37,7 +39,9 @@ type ServiceInfo struct {
 	Signer   uint
 }
 
-// Region defines the URLs where AWS services may be accessed.
+// Region defines the URLs where AWS services may be accessed. Clients
+// resolve their endpoint with ResolveEndpoint rather than reading these
+// fields, so that it may be overridden.
 //
 // See http://goo.gl/d8BP1 for more details.
 type Region struct {
@@ -61,24 +65,16 @@ type Region struct {
 	CloudFormationEndpoint  string
 	ECSEndpoint             string
 	DynamoDBStreamsEndpoint string
-}
 
-var Regions = map[string]Region{
-	APNortheast.Name:  APNortheast,
-	APNortheast2.Name: APNortheast2,
-	APSoutheast.Name:  APSoutheast,
-	APSoutheast2.Name: APSoutheast2,
-	EUCentral.Name:    EUCentral,
-	EUWest.Name:       EUWest,
-	USEast.Name:       USEast,
-	USEast2.Name:      USEast2,
-	USWest.Name:       USWest,
-	USWest2.Name:      USWest2,
-	USGovWest.Name:    USGovWest,
-	SAEast.Name:       SAEast,
-	CNNorth.Name:      CNNorth,
+	// EndpointResolver, if set, resolves the endpoints of the region in
+	// place of DefaultEndpointResolver.
+	EndpointResolver EndpointResolver
 }
 
+// Regions holds the known regions by name. It is derived from the
+// endpoints document embedded in goamz, and extended by LoadEndpoints.
+var Regions = loadDefaultEndpoints()
+
 // Designates a signer interface suitable for signing AWS requests, params
 // should be appropriately encoded for the request before signing.
 //
@@ -99,11 +95,42 @@ type AWSService interface {
 	BuildError(r *http.Response) error
 }
 
+// AWSServiceWithContext is implemented by AWS services that can bind a
+// context to each query, so that it may be cancelled or given a deadline.
+type AWSServiceWithContext interface {
+	AWSService
+	QueryWithContext(ctx context.Context, method, path string, params map[string]string) (*http.Response, error)
+}
+
 // Implements a Server Query/Post API to easily query AWS services and build
 // errors when desired
 type Service struct {
 	service ServiceInfo
-	signer  Signer
+	auth    Auth
+	clock   *Clock
+
+	// HTTPClient sends the queries. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// SigningName and SigningRegion are the name of the service, such as
+	// "monitoring", and the region of its endpoint, that queries report to
+	// Instrumentation. NewService derives them from the host of AWS
+	// endpoints.
+	SigningName   string
+	SigningRegion string
+
+	// Logger, if set, is given the lines selected by LogLevel about
+	// queries, with credentials redacted.
+	Logger   Logger
+	LogLevel LogLevel
+
+	// Instrumentation, if set, is told about every attempt of the
+	// queries of the service.
+	Instrumentation Instrumentation
+
+	// Handlers are run on every query of the service, after the
+	// GlobalHandlers.
+	Handlers Handlers
 }
 
 // Create a base set of params for an action
@@ -115,10 +142,9 @@ func MakeParams(action string) map[string]string {
 
 // Create a new AWS server to handle making requests
 func NewService(auth Auth, service ServiceInfo) (s *Service, err error) {
-	var signer Signer
 	switch service.Signer {
 	case V2Signature:
-		signer, err = NewV2Signer(auth, service)
+		_, err = NewV2Signer(auth, service)
 	// case V4Signature:
 	// 	signer, err = NewV4Signer(auth, service, Regions["eu-west-1"])
 	default:
@@ -127,11 +153,43 @@ func NewService(auth Auth, service ServiceInfo) (s *Service, err error) {
 	if err != nil {
 		return
 	}
-	s = &Service{service: service, signer: signer}
+	name, region := serviceFromEndpoint(service.Endpoint)
+	s = &Service{service: service, auth: auth, clock: &Clock{}, SigningName: name, SigningRegion: region}
 	return
 }
 
+// serviceFromEndpoint returns the service and region of an AWS endpoint
+// such as https://monitoring.us-east-1.amazonaws.com, or empty strings if
+// endpoint is not one.
+func serviceFromEndpoint(endpoint string) (name, region string) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", ""
+	}
+	host := u.Hostname()
+	if !strings.HasSuffix(host, ".amazonaws.com") && !strings.HasSuffix(host, ".amazonaws.com.cn") {
+		return "", ""
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) > 3 && strings.Contains(labels[1], "-") {
+		region = labels[1]
+	}
+	return labels[0], region
+}
+
 func (s *Service) Query(method, path string, params map[string]string) (resp *http.Response, err error) {
+	return s.QueryWithContext(context.Background(), method, path, params)
+}
+
+// QueryWithContext is like Query but the request is bound to ctx.
+//
+// The body of the returned response is fully read and may be consumed after
+// the connection has been released. Responses with an error status are
+// returned along with a nil error, to be handled by BuildError.
+func (s *Service) QueryWithContext(ctx context.Context, method, path string, params map[string]string) (resp *http.Response, err error) {
+	if method != "GET" && method != "POST" {
+		return nil, fmt.Errorf("Unsupported method %q for service", method)
+	}
 	params["Timestamp"] = time.Now().UTC().Format(time.RFC3339)
 	u, err := url.Parse(s.service.Endpoint)
 	if err != nil {
@@ -139,17 +197,66 @@ func (s *Service) Query(method, path string, params map[string]string) (resp *ht
 	}
 	u.Path = path
 
-	s.signer.Sign(method, path, params)
-	if method == "GET" {
-		u.RawQuery = multimap(params).Encode()
-		resp, err = http.Get(u.String())
-	} else if method == "POST" {
-		resp, err = http.PostForm(u.String(), multimap(params))
+	var h Handlers
+	h.Build.PushBack(BuildHandler)
+	h.Sign.PushBack(V2SignHandler("core.V2Sign", func(auth Auth, method, path string, params map[string]string, host string) {
+		signer := &V2Signer{auth: auth, service: s.service, host: host}
+		signer.Sign(method, path, params)
+	}))
+	h.Send.PushBack(SendHandler)
+	h.Unmarshal.PushBack(bufferBodyHandler)
+	h.UnmarshalError.PushBack(bufferBodyHandler)
+	h.UnmarshalError.PushBack(NamedHandler{Name: "core.UnmarshalError", Fn: func(r *Request) {
+		if r.Error != nil {
+			return
+		}
+		r.Error = s.BuildError(r.HTTPResponse)
+		r.HTTPResponse.Body.(*bufferedBody).Seek(0, io.SeekStart)
+	}})
+	h.Retry.PushBack(RetryHandler)
+	r := &Request{
+		Service:         s.SigningName,
+		Operation:       params["Action"],
+		Region:          Region{Name: s.SigningRegion},
+		Auth:            s.auth,
+		Context:         ctx,
+		Method:          method,
+		Endpoint:        u.String(),
+		Params:          params,
+		HTTPClient:      s.HTTPClient,
+		Clock:           s.clock,
+		Logger:          s.Logger,
+		LogLevel:        s.LogLevel,
+		Instrumentation: s.Instrumentation,
+		Handlers:        ChainHandlers(h, GlobalHandlers, s.Handlers),
+	}
+	if err := r.Send(); err != nil {
+		if _, ok := err.(*Error); !ok || r.HTTPResponse == nil {
+			return nil, err
+		}
+		// Error responses are left to BuildError.
 	}
+	return r.HTTPResponse, nil
+}
 
-	return
+// bufferedBody holds the body of a response read into memory, so that it
+// outlives the request.
+type bufferedBody struct {
+	*bytes.Reader
 }
 
+func (b *bufferedBody) Close() error { return nil }
+
+var bufferBodyHandler = NamedHandler{Name: "core.BufferBody", Fn: func(r *Request) {
+	body, err := ioutil.ReadAll(r.HTTPResponse.Body)
+	if err != nil {
+		r.Error = err
+		return
+	}
+	r.HTTPResponse.Body = &bufferedBody{bytes.NewReader(body)}
+	r.Error = nil
+}}
+
 func (s *Service) BuildError(r *http.Response) error {
 	errors := ErrorResponse{}
 	xml.NewDecoder(r.Body).Decode(&errors)
@@ -186,6 +293,7 @@ type Auth struct {
 	AccessKey, SecretKey string
 	token                string
 	expiration           time.Time
+	creds                *Credentials
 }
 
 func (a *Auth) Token() string {
@@ -193,7 +301,14 @@ func (a *Auth) Token() string {
 		return ""
 	}
 	if time.Since(a.expiration) >= -30*time.Second { //in an ideal world this should be zero assuming the instance is synching it's clock
-		*a, _ = GetAuth("", "", "", time.Time{})
+		if a.creds != nil {
+			a.creds.Expire()
+			if auth, err := a.creds.Get(); err == nil {
+				*a = auth
+			}
+		} else {
+			*a, _ = GetAuth("", "", "", time.Time{})
+		}
 	}
 	return a.token
 }
@@ -250,41 +365,25 @@ type credentials struct {
 	Expiration      string
 }
 
-// GetMetaData retrieves instance metadata about the current machine.
+// GetMetaData retrieves instance metadata about the current machine from
+// DefaultMetadataClient.
 //
 // See http://docs.aws.amazon.com/AWSEC2/latest/UserGuide/AESDG-chapter-instancedata.html for more details.
 func GetMetaData(path string) (contents []byte, err error) {
-	url := "http://169.254.169.254/latest/meta-data/" + path
-
-	resp, err := RetryingClient.Get(url)
-	if err != nil {
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		err = fmt.Errorf("Code %d returned for url %s", resp.StatusCode, url)
-		return
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return
-	}
-	return []byte(body), err
+	return DefaultMetadataClient.GetMetaData(path)
 }
 
-func getInstanceCredentials() (cred credentials, err error) {
+func getInstanceCredentials(client *MetadataClient) (cred credentials, err error) {
 	credentialPath := "iam/security-credentials/"
 
 	// Get the instance role
-	role, err := GetMetaData(credentialPath)
+	role, err := client.GetMetaData(credentialPath)
 	if err != nil {
 		return
 	}
 
 	// Get the instance role credentials
-	credentialJSON, err := GetMetaData(credentialPath + string(role))
+	credentialJSON, err := client.GetMetaData(credentialPath + string(role))
 	if err != nil {
 		return
 	}
@@ -294,11 +393,11 @@ func getInstanceCredentials() (cred credentials, err error) {
 }
 
 // GetAuth creates an Auth based on either passed in credentials,
-// environment information or instance based role credentials.
+// environment information or container or instance based role credentials.
 func GetAuth(accessKey string, secretKey, token string, expiration time.Time) (auth Auth, err error) {
 	// First try passed in credentials
 	if accessKey != "" && secretKey != "" {
-		return Auth{accessKey, secretKey, token, expiration}, nil
+		return Auth{accessKey, secretKey, token, expiration, nil}, nil
 	}
 
 	// Next try to get auth from the shared credentials file
@@ -315,19 +414,13 @@ func GetAuth(accessKey string, secretKey, token string, expiration time.Time) (a
 		return
 	}
 
-	// Next try getting auth from the instance role
-	cred, err := getInstanceCredentials()
+	// Next try getting auth from the role of the container or instance.
+	// The returned auth is refreshed as it nears expiration.
+	creds := NewCredentials(roleProvider())
+	auth, err = creds.Get()
 	if err == nil {
 		// Found auth, return
-		auth.AccessKey = cred.AccessKeyId
-		auth.SecretKey = cred.SecretAccessKey
-		auth.token = cred.Token
-		exptdate, err := time.Parse("2006-01-02T15:04:05Z", cred.Expiration)
-		if err != nil {
-			err = fmt.Errorf("Error Parseing expiration date: cred.Expiration :%s , error: %s \n", cred.Expiration, err)
-		}
-		auth.expiration = exptdate
-		return auth, err
+		return
 	}
 	err = errors.New("No valid AWS authentication found: " + err.Error())
 	return auth, err
@@ -362,45 +455,7 @@ func EnvAuth() (auth Auth, err error) {
 // $HOME/.aws/credentials. The AWS_PROFILE environment variables is used to
 // select the profile.
 func SharedAuth() (auth Auth, err error) {
-	var profileName = os.Getenv("AWS_PROFILE")
-
-	if profileName == "" {
-		profileName = "default"
-	}
-
-	var credentialsFile = os.Getenv("AWS_CREDENTIAL_FILE")
-	if credentialsFile == "" {
-		var homeDir = os.Getenv("HOME")
-		if homeDir == "" {
-			err = errors.New("Could not get HOME")
-			return
-		}
-		credentialsFile = homeDir + "/.aws/credentials"
-	}
-
-	file, err := ini.LoadFile(credentialsFile)
-	if err != nil {
-		err = errors.New("Couldn't parse AWS credentials file")
-		return
-	}
-
-	var profile = file[profileName]
-	if profile == nil {
-		err = errors.New("Couldn't find profile in AWS credentials file")
-		return
-	}
-
-	auth.AccessKey = profile["aws_access_key_id"]
-	auth.SecretKey = profile["aws_secret_access_key"]
-	auth.token = profile["aws_session_token"]
-
-	if auth.AccessKey == "" {
-		err = errors.New("AWS_ACCESS_KEY_ID not found in environment in credentials file")
-	}
-	if auth.SecretKey == "" {
-		err = errors.New("AWS_SECRET_ACCESS_KEY not found in credentials file")
-	}
-	return
+	return (&SharedCredentialsProvider{}).Retrieve()
 }
 
 // Encode takes a string and URI-encodes it in a way suitable
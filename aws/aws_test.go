@@ -209,3 +209,17 @@ func (s *S) TestRegionsAreNamed(c *C) {
 		c.Assert(n, Equals, r.Name)
 	}
 }
+
+func (s *S) TestServiceFromEndpoint(c *C) {
+	tests := []struct{ endpoint, name, region string }{
+		{"https://monitoring.us-east-1.amazonaws.com", "monitoring", "us-east-1"},
+		{"https://rds.cn-north-1.amazonaws.com.cn/", "rds", "cn-north-1"},
+		{"https://iam.amazonaws.com", "iam", ""},
+		{"http://localhost:4444", "", ""},
+	}
+	for _, t := range tests {
+		name, region := aws.ServiceFromEndpoint(t.endpoint)
+		c.Check(name, Equals, t.name, Commentf("%s", t.endpoint))
+		c.Check(region, Equals, t.region, Commentf("%s", t.endpoint))
+	}
+}
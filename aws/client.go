@@ -1,9 +1,11 @@
 package aws
 
 import (
+	"crypto/tls"
 	"math"
 	"net"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -71,10 +73,63 @@ func NewClient(rt *ResilientTransport) *http.Client {
 	}
 }
 
+// HTTPConfig configures the http.Client built by NewHTTPClient, which may
+// be shared by the clients of every service through their NewWithClient
+// constructors, or the optional argument of s3.New.
+type HTTPConfig struct {
+	// Proxy returns the proxy of a request, as for http.Transport. If
+	// nil, http.ProxyFromEnvironment is used.
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// TLSClientConfig configures TLS connections. If nil, the defaults
+	// of crypto/tls are used.
+	TLSClientConfig *tls.Config
+
+	// ConnectTimeout bounds the time spent connecting, and then in the
+	// TLS handshake. ResponseHeaderTimeout bounds the time spent waiting
+	// for the headers of a response once the request is written. Timeout
+	// bounds whole requests, including the reading of response bodies,
+	// such as those returned by s3.Bucket.GetReader. Zero means no
+	// timeout.
+	ConnectTimeout        time.Duration
+	ResponseHeaderTimeout time.Duration
+	Timeout               time.Duration
+}
+
+// NewHTTPClient returns an http.Client configured by config.
+func NewHTTPClient(config HTTPConfig) *http.Client {
+	proxy := config.Proxy
+	if proxy == nil {
+		proxy = http.ProxyFromEnvironment
+	}
+	dialer := &net.Dialer{Timeout: config.ConnectTimeout, KeepAlive: 30 * time.Second}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 proxy,
+			DialContext:           dialer.DialContext,
+			TLSClientConfig:       config.TLSClientConfig,
+			TLSHandshakeTimeout:   config.ConnectTimeout,
+			ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+			IdleConnTimeout:       90 * time.Second,
+			ForceAttemptHTTP2:     true,
+		},
+		Timeout: config.Timeout,
+	}
+}
+
+// DefaultHTTPClient is the client of ec2.New and iam.New. It bounds the
+// time spent connecting and waiting for responses, so that stalled
+// attempts fail and are retried according to DefaultRetryPolicy.
+var DefaultHTTPClient = NewHTTPClient(HTTPConfig{
+	ConnectTimeout:        10 * time.Second,
+	ResponseHeaderTimeout: 30 * time.Second,
+})
+
 // internal default resilient transport
 var retryingTransport = NewResilientTransport()
 
-// Exported default client
+// Exported default client. The service clients retry failed requests
+// according to DefaultRetryPolicy and do not need it.
 var RetryingClient = NewClient(retryingTransport)
 
 func (t *ResilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
@@ -85,11 +140,13 @@ func (t *ResilientTransport) RoundTrip(req *http.Request) (*http.Response, error
 // We'll only retry if the proper criteria are met.
 // If a wait function is specified, wait that amount of time
 // In between requests.
+// No further attempts are made once the request's context is done.
 func (t *ResilientTransport) tries(req *http.Request) (res *http.Response, err error) {
+	ctx := req.Context()
 	for try := 0; try < t.MaxTries; try += 1 {
 		res, err = t.transport.RoundTrip(req)
 
-		if !t.ShouldRetry(req, res, err) {
+		if ctx.Err() != nil || !t.ShouldRetry(req, res, err) {
 			break
 		}
 		if res != nil {
@@ -98,6 +155,9 @@ func (t *ResilientTransport) tries(req *http.Request) (res *http.Response, err e
 		if t.Wait != nil {
 			t.Wait(try)
 		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 	}
 
 	return
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -119,3 +120,36 @@ func TestClient_fails(t *testing.T) {
 		t.Fatal("Didn't retry enough")
 	}
 }
+
+func TestNewHTTPClient_proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		fmt.Fprintln(w, "proxied")
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	client := aws.NewHTTPClient(aws.HTTPConfig{Proxy: http.ProxyURL(proxyURL), Timeout: 5 * time.Second})
+	if client.Timeout != 5*time.Second {
+		t.Fatalf("Timeout not set: %v", client.Timeout)
+	}
+	resp, err := client.Get("http://ec2.us-east-1.amazonaws.com/?Action=DescribeInstances")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if proxied != "http://ec2.us-east-1.amazonaws.com/?Action=DescribeInstances" {
+		t.Fatalf("Request not sent through the proxy: %q", proxied)
+	}
+}
+
+func TestDefaultHTTPClient_timeouts(t *testing.T) {
+	tr, ok := aws.DefaultHTTPClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Unexpected transport: %T", aws.DefaultHTTPClient.Transport)
+	}
+	if tr.TLSHandshakeTimeout <= 0 || tr.ResponseHeaderTimeout <= 0 {
+		t.Fatalf("Timeouts not set: %v, %v", tr.TLSHandshakeTimeout, tr.ResponseHeaderTimeout)
+	}
+}
@@ -0,0 +1,66 @@
+package aws
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// MinClockSkew is the smallest difference with the time of AWS that a
+// Clock corrects. Smaller ones are left to the tolerance of AWS, which
+// accepts requests signed up to five minutes away from its time.
+const MinClockSkew = time.Second
+
+// Clock gives the time requests are signed at: the local time, corrected
+// by the offset to the time of AWS learnt from the Date header of
+// responses rejecting requests signed with a skewed clock. Service
+// clients keep one each, shared by their copies, so that a correction
+// applies to all later requests of the client.
+//
+// A nil *Clock gives the local time. The methods of a Clock may be called
+// concurrently.
+type Clock struct {
+	offset int64
+}
+
+// Now returns the corrected current time, in UTC.
+func (c *Clock) Now() time.Time {
+	return time.Now().Add(c.Offset()).UTC()
+}
+
+// Offset returns the duration added to the local time.
+func (c *Clock) Offset() time.Duration {
+	if c == nil {
+		return 0
+	}
+	return time.Duration(atomic.LoadInt64(&c.offset))
+}
+
+// SetOffset sets the duration added to the local time.
+func (c *Clock) SetOffset(d time.Duration) {
+	atomic.StoreInt64(&c.offset, int64(d))
+}
+
+// Correct sets the offset of c from the Date header of resp, reporting
+// whether it changed by MinClockSkew or more. It does nothing if resp has
+// no valid Date header, or c is nil.
+func (c *Clock) Correct(resp *http.Response) bool {
+	if c == nil || resp == nil {
+		return false
+	}
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return false
+	}
+	// The Date header has a precision of a second.
+	offset := date.Sub(time.Now()).Truncate(time.Second)
+	change := offset - c.Offset()
+	if change < 0 {
+		change = -change
+	}
+	if change < MinClockSkew {
+		return false
+	}
+	c.SetOffset(offset)
+	return true
+}
@@ -0,0 +1,239 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vaughan0/go-ini"
+)
+
+// Profile holds the settings of a named profile, merged from the shared
+// config file (~/.aws/config) and the shared credentials file
+// (~/.aws/credentials). Settings in the credentials file take precedence.
+type Profile struct {
+	Name string
+
+	// Static credentials.
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+
+	// Region is the name of the profile's region, such as "us-west-2".
+	Region string
+
+	// Role assumption settings. The credentials to assume the role
+	// with come from SourceProfile or from CredentialSource, or the role
+	// is assumed with the web identity token in WebIdentityTokenFile.
+	RoleArn              string
+	SourceProfile        string
+	CredentialSource     string
+	WebIdentityTokenFile string
+	ExternalId           string
+	RoleSessionName      string
+	MFASerial            string
+	DurationSeconds      int
+
+	// CredentialProcess is a command whose output provides the
+	// credentials, as described for ProcessProvider.
+	CredentialProcess string
+}
+
+// LoadProfile reads the profile name from the shared config and credentials
+// files.
+//
+// An empty configFile defaults to the AWS_CONFIG_FILE environment variable
+// or $HOME/.aws/config, and an empty credentialsFile to AWS_CREDENTIAL_FILE
+// or $HOME/.aws/credentials. Either file may be missing, but the profile
+// must be found in at least one of them. An empty name defaults to the
+// AWS_PROFILE environment variable or "default".
+func LoadProfile(configFile, credentialsFile, name string) (*Profile, error) {
+	if name == "" {
+		name = os.Getenv("AWS_PROFILE")
+	}
+	if name == "" {
+		name = "default"
+	}
+	if configFile == "" {
+		configFile = defaultSharedFile("AWS_CONFIG_FILE", "config")
+	}
+	if credentialsFile == "" {
+		credentialsFile = defaultSharedFile("AWS_CREDENTIAL_FILE", "credentials")
+	}
+
+	settings := make(ini.Section)
+	found := false
+
+	// Profiles in the config file, except the default one, are prefixed.
+	section := name
+	if name != "default" {
+		section = "profile " + name
+	}
+	for _, f := range []struct{ filename, section string }{
+		{configFile, section},
+		{credentialsFile, name},
+	} {
+		if f.filename == "" {
+			continue
+		}
+		file, err := ini.LoadFile(f.filename)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't parse %s: %v", f.filename, err)
+		}
+		if s, ok := file[f.section]; ok {
+			found = true
+			for k, v := range s {
+				settings[k] = v
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("Couldn't find profile %q in AWS config files", name)
+	}
+
+	p := &Profile{
+		Name:                 name,
+		AccessKey:            settings["aws_access_key_id"],
+		SecretKey:            settings["aws_secret_access_key"],
+		SessionToken:         settings["aws_session_token"],
+		Region:               settings["region"],
+		RoleArn:              settings["role_arn"],
+		SourceProfile:        settings["source_profile"],
+		CredentialSource:     settings["credential_source"],
+		WebIdentityTokenFile: settings["web_identity_token_file"],
+		ExternalId:           settings["external_id"],
+		RoleSessionName:      settings["role_session_name"],
+		MFASerial:            settings["mfa_serial"],
+		CredentialProcess:    settings["credential_process"],
+	}
+	if s := settings["duration_seconds"]; s != "" {
+		d, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid duration_seconds in profile %q: %v", name, err)
+		}
+		p.DurationSeconds = d
+	}
+	return p, nil
+}
+
+func defaultSharedFile(env, name string) string {
+	if filename := os.Getenv(env); filename != "" {
+		return filename
+	}
+	if home := os.Getenv("HOME"); home != "" {
+		return home + "/.aws/" + name
+	}
+	return ""
+}
+
+// GetRegion returns the profile's region. If the profile sets none, the
+// AWS_REGION or AWS_DEFAULT_REGION environment variables are used; if
+// those are unset too, the zero Region is returned.
+func (p *Profile) GetRegion() (Region, error) {
+	name := p.Region
+	if name == "" {
+		name = os.Getenv("AWS_REGION")
+	}
+	if name == "" {
+		name = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if name == "" {
+		return Region{}, nil
+	}
+	region, ok := Regions[name]
+	if !ok {
+		return Region{}, fmt.Errorf("Unknown region %q in profile %q", name, p.Name)
+	}
+	return region, nil
+}
+
+// Provider returns a CredentialsProvider for the profile's own credentials:
+// its credential_process, its static keys, or its credential_source, in
+// that order. Profiles that assume a role through role_arn are resolved by
+// the sts package, which calls Provider for the source credentials.
+func (p *Profile) Provider() (CredentialsProvider, error) {
+	switch {
+	case p.CredentialProcess != "":
+		return &ProcessProvider{Command: p.CredentialProcess}, nil
+	case p.AccessKey != "" || p.SecretKey != "":
+		return &StaticProvider{Auth: Auth{AccessKey: p.AccessKey, SecretKey: p.SecretKey, token: p.SessionToken}}, nil
+	case p.CredentialSource == "Environment":
+		return &EnvProvider{}, nil
+	case p.CredentialSource == "Ec2InstanceMetadata":
+		return &EC2RoleProvider{}, nil
+	case p.CredentialSource == "EcsContainer":
+		return &ContainerProvider{}, nil
+	case p.CredentialSource != "":
+		return nil, fmt.Errorf("Unsupported credential_source %q in profile %q", p.CredentialSource, p.Name)
+	}
+	return nil, fmt.Errorf("No credentials found in profile %q", p.Name)
+}
+
+// ProcessProvider is a CredentialsProvider that runs an external command
+// and reads the credentials from its standard output, which must be a JSON
+// document of the form:
+//
+//	{
+//		"Version": 1,
+//		"AccessKeyId": "...",
+//		"SecretAccessKey": "...",
+//		"SessionToken": "...",
+//		"Expiration": "2006-01-02T15:04:05Z"
+//	}
+//
+// SessionToken and Expiration are optional.
+type ProcessProvider struct {
+	// Command is run through the shell.
+	Command string
+}
+
+type processCredentials struct {
+	Version         int
+	AccessKeyId     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      string
+}
+
+func (p *ProcessProvider) Retrieve() (auth Auth, err error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd.exe", "/C", p.Command)
+	} else {
+		cmd = exec.Command("sh", "-c", p.Command)
+	}
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return auth, fmt.Errorf("credential_process %q failed: %v", p.Command, err)
+	}
+
+	var cred processCredentials
+	if err = json.Unmarshal(out, &cred); err != nil {
+		return auth, fmt.Errorf("credential_process %q returned invalid JSON: %v", p.Command, err)
+	}
+	if cred.Version != 1 {
+		return auth, fmt.Errorf("credential_process %q returned unsupported version %d", p.Command, cred.Version)
+	}
+	if cred.AccessKeyId == "" || cred.SecretAccessKey == "" {
+		return auth, fmt.Errorf("credential_process %q returned no credentials", p.Command)
+	}
+	auth.AccessKey = cred.AccessKeyId
+	auth.SecretKey = cred.SecretAccessKey
+	auth.token = cred.SessionToken
+	if cred.Expiration != "" {
+		auth.expiration, err = time.Parse(time.RFC3339, strings.TrimSpace(cred.Expiration))
+		if err != nil {
+			return Auth{}, fmt.Errorf("credential_process %q returned invalid expiration: %v", p.Command, err)
+		}
+	}
+	return auth, nil
+}
@@ -0,0 +1,145 @@
+package aws_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/goamz/goamz/aws"
+	. "gopkg.in/check.v1"
+)
+
+var sharedConfig = `
+[default]
+region = eu-west-1
+
+[profile dev]
+region = us-west-2
+role_arn = arn:aws:iam::123456789012:role/dev
+source_profile = default
+external_id = ext
+mfa_serial = arn:aws:iam::123456789012:mfa/bob
+duration_seconds = 900
+
+[profile process]
+credential_process = echo '{"Version": 1, "AccessKeyId": "pk", "SecretAccessKey": "ps", "SessionToken": "pt", "Expiration": "2099-01-02T15:04:05Z"}'
+`
+
+var sharedCredentials = `
+[default]
+aws_access_key_id = access
+aws_secret_access_key = secret
+
+[dev]
+region = ap-southeast-2
+`
+
+func writeSharedFiles(c *C) (config, credentials string) {
+	d := c.MkDir()
+	config = filepath.Join(d, "config")
+	credentials = filepath.Join(d, "credentials")
+	c.Assert(ioutil.WriteFile(config, []byte(sharedConfig), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(credentials, []byte(sharedCredentials), 0644), IsNil)
+	return
+}
+
+func (s *S) TestLoadProfileDefault(c *C) {
+	os.Clearenv()
+	config, credentials := writeSharedFiles(c)
+
+	p, err := aws.LoadProfile(config, credentials, "")
+	c.Assert(err, IsNil)
+	c.Assert(p.Name, Equals, "default")
+	c.Assert(p.AccessKey, Equals, "access")
+	c.Assert(p.SecretKey, Equals, "secret")
+	region, err := p.GetRegion()
+	c.Assert(err, IsNil)
+	c.Assert(region, DeepEquals, aws.EUWest)
+
+	provider, err := p.Provider()
+	c.Assert(err, IsNil)
+	auth, err := provider.Retrieve()
+	c.Assert(err, IsNil)
+	c.Assert(auth, Equals, aws.Auth{AccessKey: "access", SecretKey: "secret"})
+}
+
+func (s *S) TestLoadProfileMerged(c *C) {
+	os.Clearenv()
+	os.Setenv("AWS_PROFILE", "dev")
+	config, credentials := writeSharedFiles(c)
+
+	p, err := aws.LoadProfile(config, credentials, "")
+	c.Assert(err, IsNil)
+	c.Assert(p, DeepEquals, &aws.Profile{
+		Name:            "dev",
+		Region:          "ap-southeast-2",
+		RoleArn:         "arn:aws:iam::123456789012:role/dev",
+		SourceProfile:   "default",
+		ExternalId:      "ext",
+		MFASerial:       "arn:aws:iam::123456789012:mfa/bob",
+		DurationSeconds: 900,
+	})
+	_, err = p.Provider()
+	c.Assert(err, ErrorMatches, `No credentials found in profile "dev"`)
+}
+
+func (s *S) TestLoadProfileNotFound(c *C) {
+	os.Clearenv()
+	config, credentials := writeSharedFiles(c)
+
+	_, err := aws.LoadProfile(config, credentials, "missing")
+	c.Assert(err, ErrorMatches, `Couldn't find profile "missing" in AWS config files`)
+
+	// Missing files are skipped.
+	_, err = aws.LoadProfile(config+".missing", credentials, "default")
+	c.Assert(err, IsNil)
+}
+
+func (s *S) TestProfileRegionFromEnv(c *C) {
+	os.Clearenv()
+	p := &aws.Profile{Name: "x"}
+	region, err := p.GetRegion()
+	c.Assert(err, IsNil)
+	c.Assert(region.Name, Equals, "")
+
+	os.Setenv("AWS_DEFAULT_REGION", "us-west-1")
+	region, err = p.GetRegion()
+	c.Assert(err, IsNil)
+	c.Assert(region.Name, Equals, "us-west-1")
+
+	p.Region = "nowhere-1"
+	_, err = p.GetRegion()
+	c.Assert(err, ErrorMatches, `Unknown region "nowhere-1" in profile "x"`)
+}
+
+func (s *S) TestProcessProvider(c *C) {
+	os.Clearenv()
+	os.Setenv("PATH", "/bin:/usr/bin")
+	config, credentials := writeSharedFiles(c)
+
+	p, err := aws.LoadProfile(config, credentials, "process")
+	c.Assert(err, IsNil)
+	provider, err := p.Provider()
+	c.Assert(err, IsNil)
+	auth, err := provider.Retrieve()
+	c.Assert(err, IsNil)
+	c.Assert(auth.AccessKey, Equals, "pk")
+	c.Assert(auth.SecretKey, Equals, "ps")
+	c.Assert(auth.Token(), Equals, "pt")
+	c.Assert(auth.Expiration(), Equals, time.Date(2099, 1, 2, 15, 4, 5, 0, time.UTC))
+}
+
+func (s *S) TestProcessProviderErrors(c *C) {
+	os.Clearenv()
+	os.Setenv("PATH", "/bin:/usr/bin")
+
+	_, err := (&aws.ProcessProvider{Command: "exit 1"}).Retrieve()
+	c.Assert(err, ErrorMatches, `credential_process "exit 1" failed: .*`)
+
+	_, err = (&aws.ProcessProvider{Command: "echo nope"}).Retrieve()
+	c.Assert(err, ErrorMatches, `credential_process "echo nope" returned invalid JSON: .*`)
+
+	_, err = (&aws.ProcessProvider{Command: `echo '{"Version": 2}'`}).Retrieve()
+	c.Assert(err, ErrorMatches, `credential_process .* returned unsupported version 2`)
+}
@@ -0,0 +1,187 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultContainerEndpoint is the address of the credentials endpoint of
+// the ECS agent, which the relative URI of a task role is resolved
+// against.
+const DefaultContainerEndpoint = "http://169.254.170.2"
+
+// ContainerProvider is a CredentialsProvider that retrieves the temporary
+// credentials of the IAM role of an ECS task, or of an EKS pod, from the
+// HTTP endpoint the container agent exposes.
+//
+// The endpoint is named by the AWS_CONTAINER_CREDENTIALS_RELATIVE_URI
+// environment variable, set by ECS, or by AWS_CONTAINER_CREDENTIALS_FULL_URI.
+// Requests to the latter carry the AWS_CONTAINER_AUTHORIZATION_TOKEN, or
+// the content of the AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE, in their
+// Authorization header.
+type ContainerProvider struct {
+	// URI is the full URL the credentials are retrieved from. If empty,
+	// it is the relative URI of the environment resolved against
+	// Endpoint, or else the full URI of the environment.
+	URI string
+
+	// Endpoint is the base URL of relative URIs. If empty,
+	// DefaultContainerEndpoint is used.
+	Endpoint string
+
+	// AuthorizationToken is sent in the Authorization header of requests.
+	// If empty, it is taken from the environment.
+	AuthorizationToken string
+
+	// HTTPClient sends the requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// Timeout bounds every retrieval. If zero, retrievals time out after
+	// five seconds.
+	Timeout time.Duration
+}
+
+// containerCredentials is the document served by container credentials
+// endpoints, or the error they return.
+type containerCredentials struct {
+	AccessKeyId     string
+	SecretAccessKey string
+	Token           string
+	Expiration      string
+
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// InContainer reports whether the environment names a container
+// credentials endpoint, which ContainerProvider then retrieves the
+// credentials from.
+func InContainer() bool {
+	return os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "" || os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != ""
+}
+
+func (p *ContainerProvider) Retrieve() (auth Auth, err error) {
+	uri, err := p.uri()
+	if err != nil {
+		return
+	}
+	token, err := p.token()
+	if err != nil {
+		return
+	}
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	client := p.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return auth, fmt.Errorf("Error getting container credentials: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return auth, fmt.Errorf("Error getting container credentials: %v", err)
+	}
+	var cred containerCredentials
+	jsonErr := json.Unmarshal(body, &cred)
+	if resp.StatusCode != 200 {
+		if jsonErr == nil && cred.Message != "" {
+			return auth, fmt.Errorf("Code %d returned for url %s: %s: %s", resp.StatusCode, uri, cred.Code, cred.Message)
+		}
+		return auth, fmt.Errorf("Code %d returned for url %s", resp.StatusCode, uri)
+	}
+	if jsonErr != nil {
+		return auth, fmt.Errorf("Error decoding container credentials: %v", jsonErr)
+	}
+	if cred.AccessKeyId == "" || cred.SecretAccessKey == "" {
+		return auth, errors.New("Container credentials lack an access key")
+	}
+	auth.AccessKey = cred.AccessKeyId
+	auth.SecretKey = cred.SecretAccessKey
+	auth.token = cred.Token
+	if cred.Expiration != "" {
+		auth.expiration, err = time.Parse(time.RFC3339, cred.Expiration)
+		if err != nil {
+			err = fmt.Errorf("Error parsing expiration date %q of container credentials: %v", cred.Expiration, err)
+		}
+	}
+	return
+}
+
+// uri returns the URL the credentials are retrieved from.
+func (p *ContainerProvider) uri() (string, error) {
+	if p.URI != "" {
+		return p.URI, nil
+	}
+	if rel := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); rel != "" {
+		endpoint := p.Endpoint
+		if endpoint == "" {
+			endpoint = DefaultContainerEndpoint
+		}
+		return strings.TrimSuffix(endpoint, "/") + "/" + strings.TrimPrefix(rel, "/"), nil
+	}
+	full := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if full == "" {
+		return "", errors.New("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or AWS_CONTAINER_CREDENTIALS_FULL_URI not found in environment")
+	}
+	// Credentials are only handed to local or secure endpoints.
+	u, err := url.Parse(full)
+	if err != nil {
+		return "", fmt.Errorf("Invalid AWS_CONTAINER_CREDENTIALS_FULL_URI: %v", err)
+	}
+	if u.Scheme != "https" && !containerHost(u.Hostname()) {
+		return "", fmt.Errorf("AWS_CONTAINER_CREDENTIALS_FULL_URI %q is neither HTTPS nor a local address", full)
+	}
+	return full, nil
+}
+
+// containerHost reports whether host is a loopback address, or one of the
+// addresses of the ECS and EKS container agents.
+func containerHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	return ip.IsLoopback() || ip.Equal(net.ParseIP("169.254.170.2")) || ip.Equal(net.ParseIP("169.254.170.23")) || ip.Equal(net.ParseIP("fd00:ec2::23"))
+}
+
+// token returns the authorization token requests carry, if any.
+func (p *ContainerProvider) token() (string, error) {
+	if p.AuthorizationToken != "" {
+		return p.AuthorizationToken, nil
+	}
+	if file := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("Error reading container authorization token: %v", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"), nil
+}
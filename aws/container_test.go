@@ -0,0 +1,123 @@
+package aws_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/goamz/goamz/aws"
+	. "gopkg.in/check.v1"
+)
+
+// fakeContainerAgent is a stand-in of the credentials endpoint of the
+// container agent, serving credentials at /v2/credentials/task to requests
+// carrying the token, if set.
+type fakeContainerAgent struct {
+	*httptest.Server
+	token    string
+	expiry   time.Time
+	requests int
+}
+
+func newFakeContainerAgent(token string) *fakeContainerAgent {
+	agent := &fakeContainerAgent{token: token, expiry: time.Now().Add(time.Hour)}
+	agent.Server = httptest.NewServer(http.HandlerFunc(agent.serve))
+	return agent
+}
+
+func (agent *fakeContainerAgent) serve(w http.ResponseWriter, req *http.Request) {
+	agent.requests++
+	if req.Header.Get("Authorization") != agent.token {
+		w.WriteHeader(401)
+		fmt.Fprint(w, `{"code": "AccessDenied", "message": "bad token"}`)
+		return
+	}
+	if req.URL.Path != "/v2/credentials/task" {
+		w.WriteHeader(404)
+		return
+	}
+	fmt.Fprintf(w, `{
+	"RoleArn": "arn:aws:iam::123456789012:role/task",
+	"AccessKeyId": "access%d",
+	"SecretAccessKey": "secret",
+	"Token": "token",
+	"Expiration": %q
+}`, agent.requests, agent.expiry.UTC().Format(time.RFC3339))
+}
+
+func (s *S) TestContainerProviderRelativeURI(c *C) {
+	agent := newFakeContainerAgent("")
+	defer agent.Close()
+	os.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "/v2/credentials/task")
+	c.Assert(aws.InContainer(), Equals, true)
+
+	p := &aws.ContainerProvider{Endpoint: agent.URL}
+	auth, err := p.Retrieve()
+	c.Assert(err, IsNil)
+	c.Assert(auth.AccessKey, Equals, "access1")
+	c.Assert(auth.SecretKey, Equals, "secret")
+	c.Assert(auth.Token(), Equals, "token")
+	c.Assert(auth.Expiration().Sub(agent.expiry) < time.Second, Equals, true)
+}
+
+func (s *S) TestContainerProviderFullURI(c *C) {
+	agent := newFakeContainerAgent("Bearer secret-token")
+	defer agent.Close()
+	os.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", agent.URL+"/v2/credentials/task")
+
+	_, err := (&aws.ContainerProvider{}).Retrieve()
+	c.Assert(err, ErrorMatches, "Code 401 returned for url .*: AccessDenied: bad token")
+
+	os.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "Bearer secret-token")
+	auth, err := (&aws.ContainerProvider{}).Retrieve()
+	c.Assert(err, IsNil)
+	c.Assert(auth.AccessKey, Equals, "access2")
+
+	// The token file takes precedence.
+	file := filepath.Join(c.MkDir(), "token")
+	c.Assert(ioutil.WriteFile(file, []byte("Bearer other-token\n"), 0600), IsNil)
+	os.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE", file)
+	_, err = (&aws.ContainerProvider{}).Retrieve()
+	c.Assert(err, ErrorMatches, "Code 401 .*")
+}
+
+func (s *S) TestContainerProviderRejectsRemoteHTTP(c *C) {
+	os.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", "http://example.com/credentials")
+	_, err := (&aws.ContainerProvider{}).Retrieve()
+	c.Assert(err, ErrorMatches, ".* is neither HTTPS nor a local address")
+}
+
+func (s *S) TestContainerProviderNotInContainer(c *C) {
+	os.Unsetenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI")
+	os.Unsetenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	c.Assert(aws.InContainer(), Equals, false)
+	_, err := (&aws.ContainerProvider{}).Retrieve()
+	c.Assert(err, ErrorMatches, ".* not found in environment")
+}
+
+func (s *S) TestContainerProviderRefresh(c *C) {
+	agent := newFakeContainerAgent("")
+	defer agent.Close()
+	agent.expiry = time.Now().Add(time.Minute)
+
+	creds := aws.NewCredentials(&aws.ContainerProvider{URI: agent.URL + "/v2/credentials/task"})
+	auth, err := creds.Auth().Resolve()
+	c.Assert(err, IsNil)
+	c.Assert(auth.AccessKey, Equals, "access1")
+
+	// Credentials expiring within the expiry window are retrieved again.
+	auth, err = creds.Auth().Resolve()
+	c.Assert(err, IsNil)
+	c.Assert(auth.AccessKey, Equals, "access2")
+
+	agent.expiry = time.Now().Add(time.Hour)
+	creds.Get()
+	auth, err = creds.Auth().Resolve()
+	c.Assert(err, IsNil)
+	c.Assert(auth.AccessKey, Equals, "access3")
+	c.Assert(agent.requests, Equals, 3)
+}
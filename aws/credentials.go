@@ -0,0 +1,265 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vaughan0/go-ini"
+)
+
+// A CredentialsProvider supplies the credentials used to sign requests.
+//
+// Retrieve is called whenever fresh credentials are needed. The expiration
+// of the returned Auth, if any, tells when they must be retrieved again; a
+// zero expiration means the credentials never expire.
+type CredentialsProvider interface {
+	Retrieve() (Auth, error)
+}
+
+// DefaultExpiryWindow is how long before their expiration credentials
+// cached by a Credentials value are refreshed.
+const DefaultExpiryWindow = 5 * time.Minute
+
+// Credentials caches the credentials returned by a CredentialsProvider and
+// refreshes them ahead of their expiration. It is safe for concurrent use,
+// so a single Credentials value may be shared by any number of clients.
+type Credentials struct {
+	// ExpiryWindow is how long before their expiration the cached
+	// credentials are refreshed. DefaultExpiryWindow is used if zero.
+	ExpiryWindow time.Duration
+
+	provider CredentialsProvider
+	mu       sync.Mutex
+	auth     Auth
+	valid    bool
+}
+
+// NewCredentials returns a Credentials value that obtains its credentials
+// from provider.
+func NewCredentials(provider CredentialsProvider) *Credentials {
+	return &Credentials{provider: provider}
+}
+
+// DefaultCredentials returns a Credentials value backed by the default
+// provider chain: the environment, the shared credentials file and the
+// role of the ECS task or EC2 instance, in that order.
+func DefaultCredentials() *Credentials {
+	return NewCredentials(&ChainProvider{Providers: []CredentialsProvider{
+		&EnvProvider{},
+		&SharedCredentialsProvider{},
+		roleProvider(),
+	}})
+}
+
+// roleProvider returns the provider of the credentials of the role the
+// code runs with: that of the container if InContainer, which must not
+// fall back to the role of the instance it runs on, or else that of the
+// EC2 instance.
+func roleProvider() CredentialsProvider {
+	if InContainer() {
+		return &ContainerProvider{}
+	}
+	return &EC2RoleProvider{}
+}
+
+// Get returns the cached credentials, retrieving them from the provider
+// if they were never retrieved or are about to expire. If the refresh
+// fails while the cached credentials are still valid, those are returned.
+func (c *Credentials) Get() (Auth, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.valid && !c.expiresWithin(c.expiryWindow()) {
+		return c.auth, nil
+	}
+	auth, err := c.provider.Retrieve()
+	if err != nil {
+		if c.valid && !c.expiresWithin(0) {
+			return c.auth, nil
+		}
+		return Auth{}, err
+	}
+	auth.creds = c
+	c.auth = auth
+	c.valid = true
+	return auth, nil
+}
+
+// Expire forces the credentials to be retrieved again on the next call
+// to Get, for example after a request failed with an expired token.
+func (c *Credentials) Expire() {
+	c.mu.Lock()
+	c.valid = false
+	c.mu.Unlock()
+}
+
+// Auth returns an Auth that reads its keys from c each time a request is
+// signed. Its AccessKey and SecretKey fields are empty; use Auth.Resolve
+// to obtain the current keys.
+func (c *Credentials) Auth() Auth {
+	return Auth{creds: c}
+}
+
+func (c *Credentials) expiryWindow() time.Duration {
+	if c.ExpiryWindow > 0 {
+		return c.ExpiryWindow
+	}
+	return DefaultExpiryWindow
+}
+
+func (c *Credentials) expiresWithin(d time.Duration) bool {
+	if c.auth.expiration.IsZero() {
+		return false
+	}
+	return !time.Now().Add(d).Before(c.auth.expiration)
+}
+
+// NewProviderAuth is a shorthand for NewCredentials(provider).Auth().
+func NewProviderAuth(provider CredentialsProvider) Auth {
+	return NewCredentials(provider).Auth()
+}
+
+// Resolve returns the credentials to sign a request with. For an Auth
+// backed by a CredentialsProvider these are the current, refreshed
+// credentials; otherwise a is returned unchanged.
+func (a Auth) Resolve() (Auth, error) {
+	if a.creds == nil {
+		return a, nil
+	}
+	return a.creds.Get()
+}
+
+// StaticProvider is a CredentialsProvider that always returns the same,
+// fixed credentials.
+type StaticProvider struct {
+	Auth
+}
+
+func (p *StaticProvider) Retrieve() (Auth, error) {
+	if p.AccessKey == "" || p.SecretKey == "" {
+		return Auth{}, errors.New("static credentials are empty")
+	}
+	return Auth{
+		AccessKey:  p.AccessKey,
+		SecretKey:  p.SecretKey,
+		token:      p.token,
+		expiration: p.expiration,
+	}, nil
+}
+
+// EnvProvider is a CredentialsProvider that reads the credentials from the
+// environment as described in EnvAuth.
+type EnvProvider struct{}
+
+func (p *EnvProvider) Retrieve() (Auth, error) {
+	return EnvAuth()
+}
+
+// SharedCredentialsProvider is a CredentialsProvider that reads the
+// credentials from a profile of the shared credentials file.
+type SharedCredentialsProvider struct {
+	// Filename is the path of the credentials file. If empty, the
+	// AWS_CREDENTIAL_FILE environment variable is used, falling back
+	// to $HOME/.aws/credentials.
+	Filename string
+
+	// Profile is the name of the profile to use. If empty, the
+	// AWS_PROFILE environment variable is used, falling back to
+	// "default".
+	Profile string
+}
+
+func (p *SharedCredentialsProvider) Retrieve() (auth Auth, err error) {
+	var profileName = p.Profile
+	if profileName == "" {
+		profileName = os.Getenv("AWS_PROFILE")
+	}
+	if profileName == "" {
+		profileName = "default"
+	}
+
+	var credentialsFile = p.Filename
+	if credentialsFile == "" {
+		credentialsFile = os.Getenv("AWS_CREDENTIAL_FILE")
+	}
+	if credentialsFile == "" {
+		var homeDir = os.Getenv("HOME")
+		if homeDir == "" {
+			err = errors.New("Could not get HOME")
+			return
+		}
+		credentialsFile = homeDir + "/.aws/credentials"
+	}
+
+	file, err := ini.LoadFile(credentialsFile)
+	if err != nil {
+		err = errors.New("Couldn't parse AWS credentials file")
+		return
+	}
+
+	var profile = file[profileName]
+	if profile == nil {
+		err = errors.New("Couldn't find profile in AWS credentials file")
+		return
+	}
+
+	auth.AccessKey = profile["aws_access_key_id"]
+	auth.SecretKey = profile["aws_secret_access_key"]
+	auth.token = profile["aws_session_token"]
+
+	if auth.AccessKey == "" {
+		err = errors.New("AWS_ACCESS_KEY_ID not found in environment in credentials file")
+	}
+	if auth.SecretKey == "" {
+		err = errors.New("AWS_SECRET_ACCESS_KEY not found in credentials file")
+	}
+	return
+}
+
+// EC2RoleProvider is a CredentialsProvider that retrieves the temporary
+// credentials of the IAM role the EC2 instance was launched with.
+type EC2RoleProvider struct {
+	// Client retrieves the credentials from the instance metadata. If
+	// nil, DefaultMetadataClient is used.
+	Client *MetadataClient
+}
+
+func (p *EC2RoleProvider) Retrieve() (auth Auth, err error) {
+	client := p.Client
+	if client == nil {
+		client = DefaultMetadataClient
+	}
+	cred, err := getInstanceCredentials(client)
+	if err != nil {
+		return
+	}
+	auth.AccessKey = cred.AccessKeyId
+	auth.SecretKey = cred.SecretAccessKey
+	auth.token = cred.Token
+	auth.expiration, err = time.Parse("2006-01-02T15:04:05Z", cred.Expiration)
+	if err != nil {
+		err = fmt.Errorf("Error Parseing expiration date: cred.Expiration :%s , error: %s \n", cred.Expiration, err)
+	}
+	return
+}
+
+// ChainProvider is a CredentialsProvider that returns the credentials of
+// the first of its providers that succeeds.
+type ChainProvider struct {
+	Providers []CredentialsProvider
+}
+
+func (p *ChainProvider) Retrieve() (Auth, error) {
+	var msgs []string
+	for _, provider := range p.Providers {
+		auth, err := provider.Retrieve()
+		if err == nil {
+			return auth, nil
+		}
+		msgs = append(msgs, err.Error())
+	}
+	return Auth{}, errors.New("No valid AWS authentication found: " + strings.Join(msgs, "; "))
+}
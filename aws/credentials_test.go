@@ -0,0 +1,168 @@
+package aws_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/goamz/goamz/aws"
+	. "gopkg.in/check.v1"
+)
+
+// countingProvider hands out a new access key on every retrieval so tests
+// can tell cached credentials from refreshed ones.
+type countingProvider struct {
+	calls  int
+	expiry time.Duration
+	err    error
+}
+
+func (p *countingProvider) Retrieve() (aws.Auth, error) {
+	p.calls++
+	if p.err != nil {
+		return aws.Auth{}, p.err
+	}
+	var exp time.Time
+	if p.expiry != 0 {
+		exp = time.Now().Add(p.expiry)
+	}
+	key := string(rune('a' + p.calls - 1))
+	return *aws.NewAuth(key, "secret", "token", exp), nil
+}
+
+func (s *S) TestCredentialsCachesUntilExpiry(c *C) {
+	p := &countingProvider{expiry: time.Hour}
+	creds := aws.NewCredentials(p)
+
+	auth, err := creds.Get()
+	c.Assert(err, IsNil)
+	c.Assert(auth.AccessKey, Equals, "a")
+	auth, err = creds.Get()
+	c.Assert(err, IsNil)
+	c.Assert(auth.AccessKey, Equals, "a")
+	c.Assert(p.calls, Equals, 1)
+
+	creds.Expire()
+	auth, err = creds.Get()
+	c.Assert(err, IsNil)
+	c.Assert(auth.AccessKey, Equals, "b")
+}
+
+func (s *S) TestCredentialsRefreshWithinExpiryWindow(c *C) {
+	p := &countingProvider{expiry: time.Minute}
+	creds := aws.NewCredentials(p)
+
+	// A minute is within the default five minute window.
+	creds.Get()
+	auth, err := creds.Get()
+	c.Assert(err, IsNil)
+	c.Assert(auth.AccessKey, Equals, "b")
+
+	creds.ExpiryWindow = time.Second
+	auth, err = creds.Get()
+	c.Assert(err, IsNil)
+	c.Assert(auth.AccessKey, Equals, "b")
+	c.Assert(p.calls, Equals, 2)
+}
+
+func (s *S) TestCredentialsKeepValidOnRefreshError(c *C) {
+	p := &countingProvider{expiry: time.Minute}
+	creds := aws.NewCredentials(p)
+	creds.Get()
+
+	p.err = errors.New("metadata unavailable")
+	auth, err := creds.Get()
+	c.Assert(err, IsNil)
+	c.Assert(auth.AccessKey, Equals, "a")
+
+	creds.Expire()
+	_, err = creds.Get()
+	c.Assert(err, ErrorMatches, "metadata unavailable")
+}
+
+func (s *S) TestAuthResolve(c *C) {
+	static := aws.Auth{AccessKey: "access", SecretKey: "secret"}
+	auth, err := static.Resolve()
+	c.Assert(err, IsNil)
+	c.Assert(auth, Equals, static)
+
+	p := &countingProvider{}
+	backed := aws.NewProviderAuth(p)
+	c.Assert(backed.AccessKey, Equals, "")
+	auth, err = backed.Resolve()
+	c.Assert(err, IsNil)
+	c.Assert(auth.AccessKey, Equals, "a")
+	c.Assert(auth.Token(), Equals, "token")
+
+	p.err = errors.New("boom")
+	_, err = aws.NewProviderAuth(p).Resolve()
+	c.Assert(err, ErrorMatches, "boom")
+}
+
+func (s *S) TestAuthTokenRefreshesFromProvider(c *C) {
+	p := &countingProvider{expiry: 10 * time.Second}
+	creds := aws.NewCredentials(p)
+	creds.ExpiryWindow = time.Second
+	auth, err := creds.Get()
+	c.Assert(err, IsNil)
+
+	// The token expires in less than 30 seconds, so it is refreshed
+	// from the provider rather than through GetAuth.
+	c.Assert(auth.Token(), Equals, "token")
+	c.Assert(auth.AccessKey, Equals, "b")
+}
+
+func (s *S) TestStaticProvider(c *C) {
+	auth, err := (&aws.StaticProvider{Auth: aws.Auth{AccessKey: "access", SecretKey: "secret"}}).Retrieve()
+	c.Assert(err, IsNil)
+	c.Assert(auth, Equals, aws.Auth{AccessKey: "access", SecretKey: "secret"})
+
+	_, err = (&aws.StaticProvider{}).Retrieve()
+	c.Assert(err, ErrorMatches, "static credentials are empty")
+}
+
+func (s *S) TestSharedCredentialsProvider(c *C) {
+	os.Clearenv()
+
+	d, err := ioutil.TempDir("", "")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(d)
+
+	file := filepath.Join(d, "credentials")
+	ioutil.WriteFile(file, []byte("[bar]\naws_access_key_id = access\naws_secret_access_key = secret\n"), 0644)
+
+	auth, err := (&aws.SharedCredentialsProvider{Filename: file, Profile: "bar"}).Retrieve()
+	c.Assert(err, IsNil)
+	c.Assert(auth, Equals, aws.Auth{AccessKey: "access", SecretKey: "secret"})
+
+	_, err = (&aws.SharedCredentialsProvider{Filename: file}).Retrieve()
+	c.Assert(err, ErrorMatches, "Couldn't find profile in AWS credentials file")
+}
+
+func (s *S) TestChainProvider(c *C) {
+	os.Clearenv()
+	os.Setenv("AWS_ACCESS_KEY_ID", "access")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	chain := &aws.ChainProvider{Providers: []aws.CredentialsProvider{
+		&aws.StaticProvider{},
+		&aws.EnvProvider{},
+		&countingProvider{},
+	}}
+	auth, err := chain.Retrieve()
+	c.Assert(err, IsNil)
+	c.Assert(auth, Equals, aws.Auth{AccessKey: "access", SecretKey: "secret"})
+
+	os.Clearenv()
+	auth, err = chain.Retrieve()
+	c.Assert(err, IsNil)
+	c.Assert(auth.AccessKey, Equals, "a")
+
+	chain.Providers = chain.Providers[:2]
+	_, err = chain.Retrieve()
+	c.Assert(err, ErrorMatches, "No valid AWS authentication found: static credentials are empty; .* not found in environment")
+}
@@ -0,0 +1,187 @@
+package aws
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Endpoint is where the requests of a service in a region are sent, and
+// how they are signed.
+type Endpoint struct {
+	// URL is the base URL of the service, such as
+	// "https://ec2.us-east-1.amazonaws.com".
+	URL string
+
+	// SigningName is the service name in Signature Version 4 signatures,
+	// such as "ses" for the "email" service.
+	SigningName string
+
+	// SigningRegion is the region name in Signature Version 4
+	// signatures. Global services are signed in the main region of their
+	// partition, such as "us-east-1" for IAM.
+	SigningRegion string
+}
+
+// EndpointResolver maps a service in a region to its endpoint. Services are
+// identified by the host prefix of their endpoints, such as "ec2",
+// "elasticloadbalancing", "monitoring" or "streams.dynamodb".
+type EndpointResolver interface {
+	ResolveEndpoint(service string, region Region) (Endpoint, error)
+}
+
+// EndpointResolverFunc is a function implementing EndpointResolver.
+type EndpointResolverFunc func(service string, region Region) (Endpoint, error)
+
+// ResolveEndpoint calls f(service, region).
+func (f EndpointResolverFunc) ResolveEndpoint(service string, region Region) (Endpoint, error) {
+	return f(service, region)
+}
+
+// DefaultEndpointResolver resolves the endpoints of regions without an
+// EndpointResolver of their own. It is a StandardEndpointResolver
+// configured from the environment at every call:
+//
+//	AWS_ENDPOINT_URL            URL
+//	AWS_ENDPOINT_URL_<SERVICE>  ServiceURLs, such as AWS_ENDPOINT_URL_EC2
+//	                            or AWS_ENDPOINT_URL_STREAMS_DYNAMODB
+//	AWS_USE_FIPS_ENDPOINT       UseFIPS, if "true"
+//	AWS_USE_DUALSTACK_ENDPOINT  UseDualStack, if "true"
+var DefaultEndpointResolver EndpointResolver = EndpointResolverFunc(func(service string, region Region) (Endpoint, error) {
+	return EnvEndpointResolver().ResolveEndpoint(service, region)
+})
+
+// ResolveEndpoint returns the endpoint of service in region, as resolved
+// by region.EndpointResolver or, if nil, by DefaultEndpointResolver. Every
+// client resolves its endpoint with it.
+func ResolveEndpoint(service string, region Region) (Endpoint, error) {
+	if region.EndpointResolver != nil {
+		return region.EndpointResolver.ResolveEndpoint(service, region)
+	}
+	return DefaultEndpointResolver.ResolveEndpoint(service, region)
+}
+
+// StandardEndpointResolver resolves endpoints from the fields of Region,
+// falling back to the endpoints document loaded by LoadEndpoints, and to
+// the usual endpoint pattern of AWS for services and regions it knows
+// nothing about.
+type StandardEndpointResolver struct {
+	// URL, if set, replaces the endpoint of every service, for example
+	// to send all requests to a local stand-in of AWS such as
+	// "http://localhost:4566".
+	URL string
+
+	// ServiceURLs replaces the endpoints of single services, and takes
+	// precedence over URL. It is keyed by service, such as "ec2", or by
+	// the suffix of its environment variable, such as "STREAMS_DYNAMODB".
+	ServiceURLs map[string]string
+
+	// UseFIPS selects the FIPS 140-2 validated endpoints, such as
+	// "https://ec2-fips.us-east-1.amazonaws.com".
+	UseFIPS bool
+
+	// UseDualStack selects the endpoints reachable over both IPv4 and
+	// IPv6, such as "https://ec2.us-east-1.api.aws".
+	UseDualStack bool
+}
+
+// EnvEndpointResolver returns a StandardEndpointResolver configured from
+// the environment, as described for DefaultEndpointResolver.
+func EnvEndpointResolver() *StandardEndpointResolver {
+	r := &StandardEndpointResolver{URL: os.Getenv("AWS_ENDPOINT_URL")}
+	const prefix = "AWS_ENDPOINT_URL_"
+	for _, kv := range os.Environ() {
+		i := strings.Index(kv, "=")
+		if i < 0 || !strings.HasPrefix(kv[:i], prefix) || kv[i+1:] == "" {
+			continue
+		}
+		if r.ServiceURLs == nil {
+			r.ServiceURLs = make(map[string]string)
+		}
+		r.ServiceURLs[kv[len(prefix):i]] = kv[i+1:]
+	}
+	r.UseFIPS, _ = strconv.ParseBool(os.Getenv("AWS_USE_FIPS_ENDPOINT"))
+	r.UseDualStack, _ = strconv.ParseBool(os.Getenv("AWS_USE_DUALSTACK_ENDPOINT"))
+	return r
+}
+
+// ResolveEndpoint implements EndpointResolver.
+func (r *StandardEndpointResolver) ResolveEndpoint(service string, region Region) (Endpoint, error) {
+	p := partitionOf(region.Name)
+	doc, known := p.endpoint(service, region.Name)
+	ep := Endpoint{SigningName: service, SigningRegion: region.Name}
+	if known {
+		ep.SigningName = doc.signingName
+	}
+
+	override := r.ServiceURLs[envServiceName(service)]
+	if override == "" {
+		override = r.ServiceURLs[service]
+	}
+	if override == "" {
+		override = r.URL
+	}
+	if override != "" {
+		ep.URL = strings.TrimSuffix(override, "/")
+		return ep, nil
+	}
+
+	if field, ok := regionFields[service]; ok && !r.UseFIPS && !r.UseDualStack {
+		ep.URL = *field(&region)
+	}
+	switch {
+	case ep.URL != "":
+		// Endpoints set in the region are signed as the document says
+		// if they are the ones it describes, such as the partition-wide
+		// endpoint of IAM set in every region.
+		if u, err := url.Parse(ep.URL); err == nil && known && u.Host == doc.host {
+			ep.SigningRegion = doc.signingRegion
+		}
+	case region.Name == "":
+		return Endpoint{}, fmt.Errorf("aws: no region to resolve the endpoint of %s in", service)
+	case r.UseFIPS || r.UseDualStack:
+		host, err := r.variantHost(service, doc.global, region.Name, p)
+		if err != nil {
+			return Endpoint{}, err
+		}
+		ep.URL = "https://" + host
+		if doc.global {
+			ep.SigningRegion = doc.signingRegion
+		}
+	case known:
+		ep.URL = "https://" + doc.host
+		ep.SigningRegion = doc.signingRegion
+	default:
+		ep.URL = "https://" + service + "." + region.Name + "." + p.DNSSuffix
+	}
+	return ep, nil
+}
+
+// variantHost returns the host of the FIPS or dual-stack variant of the
+// endpoint of service in region.
+func (r *StandardEndpointResolver) variantHost(service string, global bool, region string, p *partitionDoc) (string, error) {
+	name := service
+	if r.UseFIPS {
+		name += "-fips"
+	}
+	switch {
+	case global && r.UseDualStack:
+		return "", fmt.Errorf("aws: %s has no dual-stack endpoint", service)
+	case global:
+		return name + "." + p.DNSSuffix, nil
+	case service == "s3" && r.UseDualStack:
+		// S3 predates the api.aws domain.
+		return name + ".dualstack." + region + "." + p.DNSSuffix, nil
+	case r.UseDualStack:
+		return name + "." + region + "." + p.dualStackDNSSuffix(), nil
+	}
+	return name + "." + region + "." + p.DNSSuffix, nil
+}
+
+// envServiceName returns the suffix of the AWS_ENDPOINT_URL_<SERVICE>
+// variable of service.
+func envServiceName(service string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(service))
+}
@@ -0,0 +1,85 @@
+package aws_test
+
+import (
+	"os"
+
+	"github.com/goamz/goamz/aws"
+	. "gopkg.in/check.v1"
+)
+
+func (s *S) TestResolveEndpoint(c *C) {
+	euWest2 := aws.Region{Name: "eu-west-2"}
+	tests := []struct {
+		resolver aws.StandardEndpointResolver
+		service  string
+		region   aws.Region
+		endpoint aws.Endpoint
+	}{
+		// Endpoints set in the region.
+		{service: "ec2", region: aws.USEast, endpoint: aws.Endpoint{URL: "https://ec2.us-east-1.amazonaws.com", SigningName: "ec2", SigningRegion: "us-east-1"}},
+		{service: "email", region: aws.EUWest, endpoint: aws.Endpoint{URL: "https://email.eu-west-1.amazonaws.com", SigningName: "ses", SigningRegion: "eu-west-1"}},
+		{service: "streams.dynamodb", region: aws.EUWest, endpoint: aws.Endpoint{URL: "https://streams.dynamodb.eu-west-1.amazonaws.com", SigningName: "dynamodb", SigningRegion: "eu-west-1"}},
+		// Partition-wide endpoints are signed in the main region.
+		{service: "iam", region: aws.EUWest, endpoint: aws.Endpoint{URL: "https://iam.amazonaws.com", SigningName: "iam", SigningRegion: "us-east-1"}},
+		{service: "sts", region: aws.APSoutheast, endpoint: aws.Endpoint{URL: "https://sts.amazonaws.com", SigningName: "sts", SigningRegion: "us-east-1"}},
+		{service: "iam", region: aws.USGovWest, endpoint: aws.Endpoint{URL: "https://iam.us-gov.amazonaws.com", SigningName: "iam", SigningRegion: "us-gov-west-1"}},
+		// Services with no field in Region are described by the endpoints
+		// document, others follow the usual pattern.
+		{service: "ec2", region: euWest2, endpoint: aws.Endpoint{URL: "https://ec2.eu-west-2.amazonaws.com", SigningName: "ec2", SigningRegion: "eu-west-2"}},
+		{service: "kms", region: aws.CNNorth, endpoint: aws.Endpoint{URL: "https://kms.cn-north-1.amazonaws.com.cn", SigningName: "kms", SigningRegion: "cn-north-1"}},
+		{service: "route53", region: aws.USEast, endpoint: aws.Endpoint{URL: "https://route53.amazonaws.com", SigningName: "route53", SigningRegion: "us-east-1"}},
+		{service: "iam", region: aws.Region{Name: "cn-northwest-1"}, endpoint: aws.Endpoint{URL: "https://iam.cn-north-1.amazonaws.com.cn", SigningName: "iam", SigningRegion: "cn-north-1"}},
+		// Regions without a name, as used by tests.
+		{service: "sqs", region: aws.Region{SQSEndpoint: "http://localhost:4444"}, endpoint: aws.Endpoint{URL: "http://localhost:4444", SigningName: "sqs"}},
+		// FIPS and dual-stack variants.
+		{resolver: aws.StandardEndpointResolver{UseFIPS: true}, service: "ec2", region: aws.USEast, endpoint: aws.Endpoint{URL: "https://ec2-fips.us-east-1.amazonaws.com", SigningName: "ec2", SigningRegion: "us-east-1"}},
+		{resolver: aws.StandardEndpointResolver{UseDualStack: true}, service: "ec2", region: aws.USWest2, endpoint: aws.Endpoint{URL: "https://ec2.us-west-2.api.aws", SigningName: "ec2", SigningRegion: "us-west-2"}},
+		{resolver: aws.StandardEndpointResolver{UseFIPS: true, UseDualStack: true}, service: "sqs", region: aws.USEast2, endpoint: aws.Endpoint{URL: "https://sqs-fips.us-east-2.api.aws", SigningName: "sqs", SigningRegion: "us-east-2"}},
+		{resolver: aws.StandardEndpointResolver{UseDualStack: true}, service: "s3", region: aws.EUWest, endpoint: aws.Endpoint{URL: "https://s3.dualstack.eu-west-1.amazonaws.com", SigningName: "s3", SigningRegion: "eu-west-1"}},
+		{resolver: aws.StandardEndpointResolver{UseFIPS: true}, service: "iam", region: aws.USEast, endpoint: aws.Endpoint{URL: "https://iam-fips.amazonaws.com", SigningName: "iam", SigningRegion: "us-east-1"}},
+		// Overrides.
+		{resolver: aws.StandardEndpointResolver{URL: "http://localhost:4566/"}, service: "sqs", region: aws.EUWest, endpoint: aws.Endpoint{URL: "http://localhost:4566", SigningName: "sqs", SigningRegion: "eu-west-1"}},
+		{resolver: aws.StandardEndpointResolver{URL: "http://localhost:4566", ServiceURLs: map[string]string{"s3": "http://localhost:9000"}}, service: "s3", region: aws.EUWest, endpoint: aws.Endpoint{URL: "http://localhost:9000", SigningName: "s3", SigningRegion: "eu-west-1"}},
+		{resolver: aws.StandardEndpointResolver{UseFIPS: true, ServiceURLs: map[string]string{"STREAMS_DYNAMODB": "http://localhost:8000"}}, service: "streams.dynamodb", region: aws.EUWest, endpoint: aws.Endpoint{URL: "http://localhost:8000", SigningName: "dynamodb", SigningRegion: "eu-west-1"}},
+	}
+	for _, t := range tests {
+		endpoint, err := t.resolver.ResolveEndpoint(t.service, t.region)
+		c.Assert(err, IsNil)
+		c.Check(endpoint, Equals, t.endpoint, Commentf("%s in %s", t.service, t.region.Name))
+	}
+
+	r := aws.StandardEndpointResolver{UseDualStack: true}
+	_, err := r.ResolveEndpoint("iam", aws.USEast)
+	c.Assert(err, ErrorMatches, "aws: iam has no dual-stack endpoint")
+	_, err = r.ResolveEndpoint("ec2", aws.Region{})
+	c.Assert(err, ErrorMatches, "aws: no region to resolve the endpoint of ec2 in")
+}
+
+func (s *S) TestResolveEndpointOverrides(c *C) {
+	for _, name := range []string{"AWS_ENDPOINT_URL", "AWS_ENDPOINT_URL_ELASTICLOADBALANCING", "AWS_USE_FIPS_ENDPOINT"} {
+		defer os.Setenv(name, os.Getenv(name))
+	}
+	os.Setenv("AWS_ENDPOINT_URL", "http://localhost:4566")
+	os.Setenv("AWS_ENDPOINT_URL_ELASTICLOADBALANCING", "http://localhost:4567")
+	os.Setenv("AWS_USE_FIPS_ENDPOINT", "true")
+
+	endpoint, err := aws.ResolveEndpoint("ec2", aws.USEast)
+	c.Assert(err, IsNil)
+	c.Assert(endpoint.URL, Equals, "http://localhost:4566")
+	endpoint, err = aws.ResolveEndpoint("elasticloadbalancing", aws.USEast)
+	c.Assert(err, IsNil)
+	c.Assert(endpoint.URL, Equals, "http://localhost:4567")
+
+	os.Setenv("AWS_ENDPOINT_URL", "")
+	os.Setenv("AWS_ENDPOINT_URL_ELASTICLOADBALANCING", "")
+	endpoint, err = aws.ResolveEndpoint("ec2", aws.USEast)
+	c.Assert(err, IsNil)
+	c.Assert(endpoint.URL, Equals, "https://ec2-fips.us-east-1.amazonaws.com")
+
+	// The resolver of the region takes precedence.
+	region := aws.USEast
+	region.EndpointResolver = &aws.StandardEndpointResolver{URL: "http://localhost:4444"}
+	endpoint, err = aws.ResolveEndpoint("ec2", region)
+	c.Assert(err, IsNil)
+	c.Assert(endpoint.URL, Equals, "http://localhost:4444")
+}
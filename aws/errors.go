@@ -0,0 +1,172 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+)
+
+// APIError is implemented by the errors returned by AWS services, in every
+// package of goamz, whatever the concrete error type of the package is.
+// Use errors.As to find one in the chain of an error:
+//
+//	var apiErr aws.APIError
+//	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidGroup.Duplicate" {
+//		...
+//	}
+type APIError interface {
+	error
+
+	// HTTPStatusCode returns the HTTP status code of the response, or
+	// zero if it is unknown.
+	HTTPStatusCode() int
+
+	// ErrorCode returns the AWS error code, such as "Throttling".
+	ErrorCode() string
+
+	// ErrorMessage returns the human-oriented message of the error.
+	ErrorMessage() string
+
+	// RequestID returns the ID AWS assigned to the failed request, if
+	// known.
+	RequestID() string
+}
+
+// HTTPStatusCode, ErrorCode, ErrorMessage and RequestID implement
+// APIError.
+func (err *Error) HTTPStatusCode() int  { return err.StatusCode }
+func (err *Error) ErrorCode() string    { return err.Code }
+func (err *Error) ErrorMessage() string { return err.Message }
+func (err *Error) RequestID() string    { return err.RequestId }
+
+// Error codes of throttled requests, across services.
+var throttleCodes = map[string]bool{
+	"Throttling":                             true,
+	"ThrottlingException":                    true,
+	"ThrottledException":                     true,
+	"RequestThrottled":                       true,
+	"RequestThrottledException":              true,
+	"RequestLimitExceeded":                   true,
+	"TooManyRequestsException":               true,
+	"ProvisionedThroughputExceededException": true,
+	"TransactionInProgressException":         true,
+	"BandwidthLimitExceeded":                 true,
+	"LimitExceededException":                 true,
+	"PriorRequestNotComplete":                true,
+	"EC2ThrottledException":                  true,
+	"SlowDown":                               true,
+}
+
+// Error codes of transient failures, other than throttling.
+var transientCodes = map[string]bool{
+	"RequestTimeout":          true,
+	"RequestTimeoutException": true,
+	"InternalError":           true,
+	"InternalFailure":         true,
+	"InternalServerError":     true,
+	"ServiceUnavailable":      true,
+	"Unavailable":             true,
+	"IDPCommunicationError":   true,
+}
+
+// Error codes of requests rejected because the clock of the client is
+// off, across services.
+var clockSkewCodes = map[string]bool{
+	"RequestTimeTooSkewed": true,
+	"RequestExpired":       true,
+	"RequestInTheFuture":   true,
+}
+
+// Error codes of denied requests, across services.
+var accessDeniedCodes = map[string]bool{
+	"AccessDenied":          true,
+	"AccessDeniedException": true,
+	"AllAccessDisabled":     true,
+	"AuthorizationError":    true,
+	"UnauthorizedOperation": true,
+	"UnauthorizedAccess":    true,
+}
+
+func apiError(err error) (APIError, bool) {
+	var apiErr APIError
+	ok := errors.As(err, &apiErr)
+	return apiErr, ok
+}
+
+// IsThrottle reports whether err is an AWS error telling that the request
+// was throttled, such as EC2's RequestLimitExceeded, DynamoDB's
+// ProvisionedThroughputExceededException or S3's SlowDown.
+func IsThrottle(err error) bool {
+	apiErr, ok := apiError(err)
+	if !ok {
+		return false
+	}
+	return throttleCodes[apiErr.ErrorCode()] || apiErr.HTTPStatusCode() == 429
+}
+
+// IsClockSkew reports whether err is an AWS error telling that the request
+// was rejected because the clock of the client is too far off the clock
+// of AWS, such as S3's RequestTimeTooSkewed.
+func IsClockSkew(err error) bool {
+	apiErr, ok := apiError(err)
+	if !ok {
+		return false
+	}
+	switch code := apiErr.ErrorCode(); code {
+	case "InvalidSignatureException", "SignatureDoesNotMatch", "AuthFailure":
+		msg := apiErr.ErrorMessage()
+		return strings.Contains(msg, "Signature expired") || strings.Contains(msg, "Signature not yet current")
+	default:
+		return clockSkewCodes[code]
+	}
+}
+
+// IsRetryable reports whether the request that failed with err may succeed
+// if sent again unchanged: throttled requests, server errors and network
+// failures.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	apiErr, ok := apiError(err)
+	if !ok {
+		var netErr net.Error
+		return errors.As(err, &netErr)
+	}
+	if IsThrottle(err) || transientCodes[apiErr.ErrorCode()] {
+		return true
+	}
+	status := apiErr.HTTPStatusCode()
+	return status >= 500 && status != 501
+}
+
+// IsNotFound reports whether err is an AWS error telling that the resource
+// acted upon does not exist, such as EC2's InvalidInstanceID.NotFound, S3's
+// NoSuchKey or DynamoDB's ResourceNotFoundException.
+func IsNotFound(err error) bool {
+	apiErr, ok := apiError(err)
+	if !ok {
+		return false
+	}
+	code := apiErr.ErrorCode()
+	switch {
+	case strings.HasSuffix(code, "NotFound"),
+		strings.HasSuffix(code, "NotFoundException"),
+		strings.HasSuffix(code, "NonExistentQueue"),
+		strings.HasPrefix(code, "NoSuch"):
+		return true
+	}
+	return code == "" && apiErr.HTTPStatusCode() == 404
+}
+
+// IsAccessDenied reports whether err is an AWS error telling that the
+// credentials used are not allowed to make the request.
+func IsAccessDenied(err error) bool {
+	apiErr, ok := apiError(err)
+	if !ok {
+		return false
+	}
+	code := apiErr.ErrorCode()
+	return accessDeniedCodes[code] || code == "" && apiErr.HTTPStatusCode() == 403
+}
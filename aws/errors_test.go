@@ -0,0 +1,62 @@
+package aws_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/goamz/goamz/aws"
+	. "gopkg.in/check.v1"
+)
+
+func (s *S) TestAPIErrorAs(c *C) {
+	err := fmt.Errorf("describing: %w", &aws.Error{StatusCode: 400, Code: "Bad", Message: "bad request", RequestId: "id"})
+
+	var apiErr aws.APIError
+	c.Assert(errors.As(err, &apiErr), Equals, true)
+	c.Assert(apiErr.HTTPStatusCode(), Equals, 400)
+	c.Assert(apiErr.ErrorCode(), Equals, "Bad")
+	c.Assert(apiErr.ErrorMessage(), Equals, "bad request")
+	c.Assert(apiErr.RequestID(), Equals, "id")
+}
+
+func (s *S) TestErrorClassification(c *C) {
+	netErr := &url.Error{Op: "Get", URL: "http://localhost", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+	tests := []struct {
+		err                                       error
+		throttle, retryable, notFound, accessDeny bool
+	}{
+		{&aws.Error{StatusCode: 400, Code: "Throttling"}, true, true, false, false},
+		{&aws.Error{StatusCode: 503, Code: "RequestLimitExceeded"}, true, true, false, false},
+		{&aws.Error{StatusCode: 400, Code: "ProvisionedThroughputExceededException"}, true, true, false, false},
+		{&aws.Error{StatusCode: 503, Code: "SlowDown"}, true, true, false, false},
+		{&aws.Error{StatusCode: 429}, true, true, false, false},
+		{&aws.Error{StatusCode: 500, Code: "InternalError"}, false, true, false, false},
+		{&aws.Error{StatusCode: 502}, false, true, false, false},
+		{&aws.Error{StatusCode: 501, Code: "NotImplemented"}, false, false, false, false},
+		{&aws.Error{StatusCode: 400, Code: "RequestTimeout"}, false, true, false, false},
+		{&aws.Error{StatusCode: 400, Code: "InvalidInstanceID.NotFound"}, false, false, true, false},
+		{&aws.Error{StatusCode: 404, Code: "NoSuchKey"}, false, false, true, false},
+		{&aws.Error{StatusCode: 400, Code: "ResourceNotFoundException"}, false, false, true, false},
+		{&aws.Error{StatusCode: 400, Code: "AWS.SimpleQueueService.NonExistentQueue"}, false, false, true, false},
+		{&aws.Error{StatusCode: 404}, false, false, true, false},
+		{&aws.Error{StatusCode: 403, Code: "AccessDenied"}, false, false, false, true},
+		{&aws.Error{StatusCode: 400, Code: "UnauthorizedOperation"}, false, false, false, true},
+		{&aws.Error{StatusCode: 403}, false, false, false, true},
+		{&aws.Error{StatusCode: 403, Code: "SignatureDoesNotMatch"}, false, false, false, false},
+		{&aws.Error{StatusCode: 400, Code: "ValidationError"}, false, false, false, false},
+		{fmt.Errorf("wrapped: %w", &aws.Error{StatusCode: 400, Code: "Throttling"}), true, true, false, false},
+		{netErr, false, true, false, false},
+		{&url.Error{Op: "Get", URL: "http://localhost", Err: context.Canceled}, false, false, false, false},
+		{errors.New("other"), false, false, false, false},
+		{nil, false, false, false, false},
+	}
+	for _, t := range tests {
+		c.Check(aws.IsThrottle(t.err), Equals, t.throttle, Commentf("%v", t.err))
+		c.Check(aws.IsRetryable(t.err), Equals, t.retryable, Commentf("%v", t.err))
+		c.Check(aws.IsNotFound(t.err), Equals, t.notFound, Commentf("%v", t.err))
+		c.Check(aws.IsAccessDenied(t.err), Equals, t.accessDeny, Commentf("%v", t.err))
+	}
+}
@@ -27,3 +27,17 @@ func (s *V4Signer) Signature(t time.Time, sts string) string {
 func (s *V4Signer) Authorization(header http.Header, t time.Time, signature string) string {
 	return s.authorization(header, t, signature)
 }
+
+// RateLimiter:
+// Exporting the clock for testing
+
+func (l *RateLimiter) SetClock(now func() time.Time) {
+	l.now = now
+}
+
+// Endpoints:
+// Exporting the embedded document for testing
+
+var EndpointsJSON = string(endpointsJSON)
+
+var ServiceFromEndpoint = serviceFromEndpoint
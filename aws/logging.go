@@ -0,0 +1,291 @@
+package aws
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogLevel selects what is logged about requests. Its values are flags,
+// combined such as LogRequests|LogRetries.
+type LogLevel uint
+
+const (
+	// LogRequests logs a line for every attempt, with the method and URL
+	// of the request, and the status of its response and how long it
+	// took, or the error that prevented it.
+	LogRequests LogLevel = 1 << iota
+
+	// LogHeaders logs the headers of requests and responses, along with
+	// their LogRequests lines.
+	LogHeaders
+
+	// LogBodies logs the bodies of requests and responses, along with
+	// their LogRequests lines. Streamed request bodies are left out, and
+	// bodies are cut after their first 64 KiB, which is all that is read
+	// into memory of streamed response bodies.
+	LogBodies
+
+	// LogRetries logs the attempts that are retried, and why.
+	LogRetries
+
+	// LogSigning logs the strings signed by Signature Version 2 and 4.
+	LogSigning
+
+	LogOff LogLevel = 0
+	LogAll          = LogRequests | LogHeaders | LogBodies | LogRetries | LogSigning
+)
+
+// Logger receives the lines logged about requests. Credentials are
+// redacted from them: the Authorization, X-Amz-Security-Token and similar
+// headers, the signature and token parameters of URLs, and the secret
+// keys, tokens and passwords of bodies.
+type Logger interface {
+	Log(args ...interface{})
+}
+
+// LoggerFunc is a function that implements Logger.
+type LoggerFunc func(args ...interface{})
+
+func (f LoggerFunc) Log(args ...interface{}) {
+	f(args...)
+}
+
+// NewDefaultLogger returns a Logger writing to standard error with the
+// log package.
+func NewDefaultLogger() Logger {
+	l := log.New(os.Stderr, "", log.LstdFlags)
+	return LoggerFunc(func(args ...interface{}) {
+		l.Println(args...)
+	})
+}
+
+// LogHandler returns a Build handler logging requests at level to
+// logger. It may be added to the Handlers of a client, or to
+// GlobalHandlers to log the requests of every client:
+//
+//	ec2.Handlers.Build.PushBack(aws.LogHandler(aws.NewDefaultLogger(), aws.LogRequests|aws.LogRetries))
+//
+// Every client also has Logger and LogLevel fields doing the same for its
+// own requests. Other http.Clients log with a LoggingTransport.
+func LogHandler(logger Logger, level LogLevel) NamedHandler {
+	return NamedHandler{Name: "core.Log", Fn: func(r *Request) {
+		r.Logger, r.LogLevel = logger, level
+	}}
+}
+
+// logf logs a line to r.Logger if its level has any of flags.
+func (r *Request) logf(flags LogLevel, format string, args ...interface{}) {
+	if r.Logger != nil && r.LogLevel&flags != 0 {
+		r.Logger.Log(fmt.Sprintf(format, args...))
+	}
+}
+
+// logPrefix returns the prefix of the lines logged about r.
+func (r *Request) logPrefix() string {
+	name := r.Operation
+	if name == "" {
+		name = r.Service
+	}
+	if name == "" {
+		return "aws:"
+	}
+	return "aws: " + name + ":"
+}
+
+// LoggingTransport is an http.RoundTripper logging the requests it sends
+// through Transport, with credentials redacted as for Request. Clients
+// taking an http.Client log their requests with one:
+//
+//	client := &http.Client{Transport: &aws.LoggingTransport{Logger: logger, Level: aws.LogRequests}}
+type LoggingTransport struct {
+	// Transport sends the requests. If nil, http.DefaultTransport is
+	// used.
+	Transport http.RoundTripper
+
+	Logger Logger
+	Level  LogLevel
+}
+
+func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if t.Logger == nil {
+		return transport.RoundTrip(req)
+	}
+	logRequest(t.Logger, t.Level, "aws:", req)
+	start := time.Now()
+	resp, err := transport.RoundTrip(req)
+	logResponse(t.Logger, t.Level, "aws:", resp, err, time.Since(start))
+	return resp, err
+}
+
+// maxLoggedBody is the number of bytes of a body that are logged.
+const maxLoggedBody = 64 << 10
+
+// loggedBodySlack is read past maxLoggedBody so that the credentials
+// straddling the cut are redacted before it is made.
+const loggedBodySlack = 4 << 10
+
+// bodyLine returns the line logged for data, read with a limit of
+// maxLoggedBody+loggedBodySlack bytes.
+func bodyLine(data []byte) string {
+	data = RedactBody(data)
+	if len(data) > maxLoggedBody {
+		return string(data[:maxLoggedBody]) + fmt.Sprintf("\n... (cut after %d bytes)", maxLoggedBody)
+	}
+	return string(data)
+}
+
+// logRequest logs req, as selected by level.
+func logRequest(logger Logger, level LogLevel, prefix string, req *http.Request) {
+	if level&LogRequests == 0 {
+		return
+	}
+	lines := []string{fmt.Sprintf("%s %s %s", prefix, req.Method, RedactURL(req.URL))}
+	if level&LogHeaders != 0 {
+		lines = append(lines, headerLines(req.Header)...)
+	}
+	if level&LogBodies != 0 && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := ioutil.ReadAll(io.LimitReader(body, maxLoggedBody+loggedBodySlack))
+			body.Close()
+			lines = append(lines, bodyLine(data))
+		}
+	}
+	logger.Log(strings.Join(lines, "\n"))
+}
+
+// logResponse logs resp, or err, as selected by level. The logged part of
+// the body is read into memory, and put back in front of the rest of it.
+func logResponse(logger Logger, level LogLevel, prefix string, resp *http.Response, err error, d time.Duration) {
+	if level&LogRequests == 0 {
+		return
+	}
+	if err != nil {
+		logger.Log(fmt.Sprintf("%s failed after %v: %v", prefix, d.Round(time.Millisecond), err))
+		return
+	}
+	lines := []string{fmt.Sprintf("%s %s in %v", prefix, resp.Status, d.Round(time.Millisecond))}
+	if level&LogHeaders != 0 {
+		lines = append(lines, headerLines(resp.Header)...)
+	}
+	if level&LogBodies != 0 {
+		data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxLoggedBody+loggedBodySlack))
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("error reading body: %v", err))
+		}
+		lines = append(lines, bodyLine(data))
+	}
+	logger.Log(strings.Join(lines, "\n"))
+}
+
+func headerLines(h http.Header) []string {
+	h = RedactHeader(h)
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var lines []string
+	for _, k := range keys {
+		for _, v := range h[k] {
+			lines = append(lines, "  "+k+": "+v)
+		}
+	}
+	return lines
+}
+
+const redacted = "REDACTED"
+
+// Headers carrying credentials.
+var redactedHeaders = []string{
+	"Authorization",
+	"X-Amz-Security-Token",
+	"X-Amzn-Authorization",
+	"X-Aws-Ec2-Metadata-Token",
+	"X-Amz-Server-Side-Encryption-Customer-Key",
+	"X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key",
+}
+
+// Query and form parameters carrying credentials.
+var redactedParams = []string{
+	"Signature",
+	"SecurityToken",
+	"X-Amz-Signature",
+	"X-Amz-Security-Token",
+	"WebIdentityToken",
+	"Password",
+	"OldPassword",
+	"NewPassword",
+}
+
+var (
+	redactedForm = regexp.MustCompile(`(^|&)(` + strings.Join(redactedParams, "|") + `)=[^&]*`)
+	redactedXML  = regexp.MustCompile(`<(SecretAccessKey|SessionToken|Password|OldPassword|NewPassword)>[^<]*<`)
+	redactedJSON = regexp.MustCompile(`"(SecretAccessKey|SessionToken|Token|secretAccessKey|sessionToken)"(\s*):(\s*)"[^"]*"`)
+)
+
+// RedactHeader returns a copy of h whose headers carrying credentials are
+// redacted.
+func RedactHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, v := range h {
+		c[k] = v
+	}
+	for _, k := range redactedHeaders {
+		if len(c.Values(k)) > 0 {
+			c.Set(k, redacted)
+		}
+	}
+	return c
+}
+
+// RedactURL returns u as a string, with the parameters of its query
+// carrying credentials redacted.
+func RedactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	c := *u
+	c.RawQuery = redactedForm.ReplaceAllString(u.RawQuery, "$1$2="+redacted)
+	return c.String()
+}
+
+// RedactBody returns body with the credentials found in form, XML and
+// JSON documents redacted.
+func RedactBody(body []byte) []byte {
+	body = redactedForm.ReplaceAll(body, []byte("$1$2="+redacted))
+	body = redactedXML.ReplaceAll(body, []byte("<$1>"+redacted+"<"))
+	return redactedJSON.ReplaceAll(body, []byte(`"$1"$2:$3"`+redacted+`"`))
+}
+
+// RedactSigned returns a canonical request or string to sign with the
+// credentials of its query and headers redacted.
+func RedactSigned(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = redactedForm.ReplaceAllString(line, "$1$2="+redacted)
+		for _, h := range redactedHeaders {
+			if strings.HasPrefix(line, strings.ToLower(h)+":") {
+				lines[i] = line[:len(h)+1] + redacted
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,161 @@
+package aws_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goamz/goamz/aws"
+	. "gopkg.in/check.v1"
+)
+
+// testLogger records the lines logged to it.
+type testLogger struct {
+	lines []string
+}
+
+func (l *testLogger) Log(args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprint(args...))
+}
+
+func (l *testLogger) String() string {
+	return strings.Join(l.lines, "\n")
+}
+
+func (s *S) TestLogRedactsCredentials(c *C) {
+	var requests []*http.Request
+	ts := newQueryServer(c, 200, `<Response><Value>ok</Value><SecretAccessKey>new-secret</SecretAccessKey><SessionToken>new-token</SessionToken></Response>`, &requests)
+	defer ts.Close()
+
+	logger := &testLogger{}
+	h := aws.QueryHandlers()
+	h.Build.PushBack(aws.LogHandler(logger, aws.LogAll))
+	var result queryResult
+	r := &aws.Request{
+		Service:   "sts",
+		Operation: "Test",
+		Region:    aws.USEast,
+		Auth:      *aws.NewAuth("access", "secret-key", "session-token", time.Now().Add(time.Hour)),
+		Method:    "POST",
+		Endpoint:  ts.URL,
+		Params:    map[string]string{"Action": "Test", "Password": "hunter2"},
+		Result:    &result,
+		Handlers:  h,
+	}
+	c.Assert(r.Send(), IsNil)
+	// The logged body is still decoded.
+	c.Assert(result.Value, Equals, "ok")
+
+	log := logger.String()
+	c.Assert(log, Matches, `(?s).*aws: Test: POST http://127\.0\.0\.1:[0-9]+/\n.*`)
+	c.Assert(log, Matches, `(?s).*\n  Authorization: REDACTED\n.*`)
+	c.Assert(log, Matches, `(?s).*\n  X-Amz-Security-Token: REDACTED\n.*`)
+	c.Assert(log, Matches, `(?s).*Action=Test&Password=REDACTED.*`)
+	c.Assert(log, Matches, `(?s).*\naws: Test: 200 OK in [0-9.]+m?s\n.*`)
+	c.Assert(log, Matches, `(?s).*<SecretAccessKey>REDACTED</SecretAccessKey><SessionToken>REDACTED</SessionToken>.*`)
+	c.Assert(log, Matches, `(?s).*aws: canonical request:\n.*\nx-amz-security-token:REDACTED\n.*aws: string to sign:\nAWS4-HMAC-SHA256\n.*`)
+	for _, secret := range []string{"secret-key", "session-token", "hunter2", "new-secret", "new-token", "Signature="} {
+		c.Assert(strings.Contains(log, secret), Equals, false, Commentf("%s logged", secret))
+	}
+	c.Assert(requests[0].Header.Get("X-Amz-Security-Token"), Equals, "session-token")
+}
+
+func (s *S) TestLogLevels(c *C) {
+	var requests []*http.Request
+	ts := newQueryServer(c, 500, "", &requests)
+	defer ts.Close()
+
+	logger := &testLogger{}
+	var h aws.Handlers
+	h.Build.PushBack(aws.BuildHandler)
+	h.Build.PushBack(aws.LogHandler(logger, aws.LogRetries|aws.LogSigning))
+	h.Sign.PushBack(aws.V2SignHandler("test.Sign", func(auth aws.Auth, method, path string, params map[string]string, host string) {
+		params["SecurityToken"] = "session-token"
+		params["Signature"] = "signature"
+	}))
+	h.Send.PushBack(aws.SendHandler)
+	h.Retry.PushBack(aws.NamedHandler{Name: "test.Retry", Fn: func(r *aws.Request) {
+		r.Retryable = r.RetryCount < 1
+	}})
+	r := &aws.Request{Operation: "Test", Method: "GET", Endpoint: ts.URL, Params: map[string]string{"Action": "Test"}, Handlers: h}
+	c.Assert(r.Send(), ErrorMatches, ".*500 Internal Server Error")
+	c.Assert(requests, HasLen, 2)
+
+	c.Assert(logger.lines, HasLen, 3)
+	c.Assert(logger.lines[0], Matches, "aws: Test: string to sign:\nGET\n127.0.0.1:[0-9]+\n/\nAction=Test&SecurityToken=REDACTED")
+	c.Assert(logger.lines[1], Matches, "aws: Test: retrying attempt 1 after 0s: .*500 Internal Server Error")
+	c.Assert(logger.lines[2], Equals, logger.lines[0])
+}
+
+func (s *S) TestLoggingTransport(c *C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"AccessKeyId": "access", "SecretAccessKey" : "secret", "Token": "token"}`)
+	}))
+	defer ts.Close()
+
+	logger := &testLogger{}
+	client := &http.Client{Transport: &aws.LoggingTransport{Logger: logger, Level: aws.LogRequests | aws.LogBodies}}
+	req, err := http.NewRequest("GET", ts.URL+"/?X-Amz-Security-Token=token&X-Amz-Signature=signature&prefix=a", nil)
+	c.Assert(err, IsNil)
+	req.Header.Set("Authorization", "secret")
+	resp, err := client.Do(req)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+
+	c.Assert(logger.lines, HasLen, 2)
+	c.Assert(logger.lines[0], Matches, `aws: GET http://127\.0\.0\.1:[0-9]+/\?X-Amz-Security-Token=REDACTED&X-Amz-Signature=REDACTED&prefix=a`)
+	c.Assert(logger.lines[1], Matches, `aws: 200 OK in [0-9.]+m?s\n{"AccessKeyId": "access", "SecretAccessKey" : "REDACTED", "Token": "REDACTED"}`)
+}
+
+func (s *S) TestLogBodiesCut(c *C) {
+	body := strings.Repeat("x", 100<<10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, body)
+	}))
+	defer ts.Close()
+
+	logger := &testLogger{}
+	client := &http.Client{Transport: &aws.LoggingTransport{Logger: logger, Level: aws.LogRequests | aws.LogBodies}}
+	resp, err := client.Get(ts.URL)
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, body)
+
+	c.Assert(logger.lines, HasLen, 2)
+	c.Assert(len(logger.lines[1]) < 65<<10, Equals, true)
+	c.Assert(logger.lines[1], Matches, `(?s).*\n\.\.\. \(cut after 65536 bytes\)`)
+
+	logger.lines = nil
+	var h aws.Handlers
+	h.Build.PushBack(aws.BuildHandler)
+	h.Build.PushBack(aws.LogHandler(logger, aws.LogRequests|aws.LogBodies))
+	h.Send.PushBack(aws.SendHandler)
+	r := &aws.Request{Method: "GET", Endpoint: ts.URL, Stream: true, Handlers: h}
+	c.Assert(r.Send(), IsNil)
+	data, err = ioutil.ReadAll(r.HTTPResponse.Body)
+	r.HTTPResponse.Body.Close()
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, body)
+	c.Assert(logger.lines, HasLen, 2)
+	c.Assert(logger.lines[1], Matches, `(?s)aws: 200 OK in .*\n\.\.\. \(cut after 65536 bytes\)`)
+}
+
+func (s *S) TestRedactPresignedURL(c *C) {
+	req, err := http.NewRequest("GET", "https://bucket.s3.amazonaws.com/key", nil)
+	c.Assert(err, IsNil)
+	signer := aws.NewV4Signer(*aws.NewAuth("access", "secret", "session-token", time.Now().Add(time.Hour)), "s3", aws.USEast)
+	signer.Presign(req, time.Hour)
+	redacted, err := url.Parse(aws.RedactURL(req.URL))
+	c.Assert(err, IsNil)
+	query := redacted.Query()
+	c.Assert(query.Get("X-Amz-Signature"), Equals, "REDACTED")
+	c.Assert(query.Get("X-Amz-Security-Token"), Equals, "REDACTED")
+	c.Assert(query.Get("X-Amz-Credential"), Equals, req.URL.Query().Get("X-Amz-Credential"))
+}
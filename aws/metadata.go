@@ -0,0 +1,303 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMetadataEndpoint is the address of the EC2 instance metadata
+// service.
+const DefaultMetadataEndpoint = "http://169.254.169.254"
+
+// MetadataClient retrieves data from the EC2 instance metadata service.
+//
+// Requests are authenticated with a session token, as instances enforcing
+// IMDSv2 require. The token is requested with a PUT to /latest/api/token
+// and reused until it nears expiry. Should the service not hand out tokens,
+// such as older stand-ins of it or instances whose responses to PUT
+// requests do not make it back through a container, requests fall back to
+// IMDSv1 unless DisableV1Fallback is set.
+type MetadataClient struct {
+	// Endpoint is the base URL of the metadata service. If empty, it is
+	// taken from the AWS_EC2_METADATA_SERVICE_ENDPOINT environment
+	// variable, defaulting to DefaultMetadataEndpoint. It may point to a
+	// local stand-in of the service, such as "http://localhost:1338".
+	Endpoint string
+
+	// HTTPClient sends the requests. If nil, RetryingClient is used.
+	HTTPClient *http.Client
+
+	// Timeout bounds every call, retries included. If zero, calls time
+	// out after five seconds, which keeps machines outside EC2 from
+	// waiting long for a service that is not there.
+	Timeout time.Duration
+
+	// TokenTimeout bounds the request for a session token, within
+	// Timeout, so that a service not answering it leaves time for an
+	// IMDSv1 request. If zero, it is one second.
+	TokenTimeout time.Duration
+
+	// TokenTTL is the lifetime of the session tokens requested, between
+	// one second and six hours. If zero, tokens last six hours.
+	TokenTTL time.Duration
+
+	// DisableV1Fallback makes requests fail rather than go without a
+	// session token when none can be obtained. It defaults to the value
+	// of the AWS_EC2_METADATA_V1_DISABLED environment variable.
+	DisableV1Fallback bool
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// DefaultMetadataClient is the MetadataClient used by GetMetaData and
+// EC2RoleProvider.
+var DefaultMetadataClient = &MetadataClient{}
+
+const (
+	defaultMetadataTimeout = 5 * time.Second
+	defaultTokenTimeout    = time.Second
+	defaultTokenTTL        = 6 * time.Hour
+
+	// v1FallbackPeriod is how long requests go without a session token
+	// after failing to get one, before a new one is requested.
+	v1FallbackPeriod = time.Minute
+)
+
+// InstanceIdentityDocument describes an EC2 instance, as returned by the
+// instance metadata service.
+//
+// See http://docs.aws.amazon.com/AWSEC2/latest/UserGuide/instance-identity-documents.html for more details.
+type InstanceIdentityDocument struct {
+	AccountId               string    `json:"accountId"`
+	Architecture            string    `json:"architecture"`
+	AvailabilityZone        string    `json:"availabilityZone"`
+	BillingProducts         []string  `json:"billingProducts"`
+	DevpayProductCodes      []string  `json:"devpayProductCodes"`
+	MarketplaceProductCodes []string  `json:"marketplaceProductCodes"`
+	ImageId                 string    `json:"imageId"`
+	InstanceId              string    `json:"instanceId"`
+	InstanceType            string    `json:"instanceType"`
+	KernelId                string    `json:"kernelId"`
+	RamdiskId               string    `json:"ramdiskId"`
+	PendingTime             time.Time `json:"pendingTime"`
+	PrivateIp               string    `json:"privateIp"`
+	Region                  string    `json:"region"`
+	Version                 string    `json:"version"`
+}
+
+// GetMetaData retrieves the instance metadata at path, relative to
+// /latest/meta-data/, such as "instance-id" or "placement/region".
+//
+// See http://docs.aws.amazon.com/AWSEC2/latest/UserGuide/ec2-instance-metadata.html for more details.
+func (c *MetadataClient) GetMetaData(path string) ([]byte, error) {
+	return c.get("/latest/meta-data/" + path)
+}
+
+// GetDynamicData retrieves the dynamic data at path, relative to
+// /latest/dynamic/, such as "instance-identity/document".
+func (c *MetadataClient) GetDynamicData(path string) ([]byte, error) {
+	return c.get("/latest/dynamic/" + path)
+}
+
+// GetUserData retrieves the user data the instance was launched with.
+func (c *MetadataClient) GetUserData() ([]byte, error) {
+	return c.get("/latest/user-data")
+}
+
+// InstanceIdentityDocument retrieves the identity document of the
+// instance.
+func (c *MetadataClient) InstanceIdentityDocument() (doc InstanceIdentityDocument, err error) {
+	data, err := c.GetDynamicData("instance-identity/document")
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(data, &doc); err != nil {
+		err = fmt.Errorf("Error parsing instance identity document: %v", err)
+	}
+	return
+}
+
+// Region returns the region the instance runs in. Regions unknown to
+// Regions are returned with the endpoints of the partition they belong to.
+func (c *MetadataClient) Region() (Region, error) {
+	name, err := c.GetMetaData("placement/region")
+	if err != nil {
+		return Region{}, err
+	}
+	if region, ok := Regions[string(name)]; ok {
+		return region, nil
+	}
+	return partitionOf(string(name)).region(string(name)), nil
+}
+
+// AvailabilityZone returns the availability zone the instance runs in,
+// such as "us-east-1a".
+func (c *MetadataClient) AvailabilityZone() (string, error) {
+	zone, err := c.GetMetaData("placement/availability-zone")
+	return string(zone), err
+}
+
+// Tags returns the tags of the instance. They are only available on
+// instances launched with access to tags in instance metadata enabled.
+func (c *MetadataClient) Tags() (map[string]string, error) {
+	keys, err := c.GetMetaData("tags/instance")
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string)
+	for _, key := range strings.Split(string(keys), "\n") {
+		if key == "" {
+			continue
+		}
+		value, err := c.GetMetaData("tags/instance/" + url.PathEscape(key))
+		if err != nil {
+			return nil, err
+		}
+		tags[key] = string(value)
+	}
+	return tags, nil
+}
+
+func (c *MetadataClient) endpoint() string {
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT")
+	}
+	if endpoint == "" {
+		endpoint = DefaultMetadataEndpoint
+	}
+	return strings.TrimSuffix(endpoint, "/")
+}
+
+func (c *MetadataClient) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return RetryingClient
+}
+
+func (c *MetadataClient) v1Disabled() bool {
+	if c.DisableV1Fallback {
+		return true
+	}
+	disabled, _ := strconv.ParseBool(os.Getenv("AWS_EC2_METADATA_V1_DISABLED"))
+	return disabled
+}
+
+func (c *MetadataClient) get(path string) ([]byte, error) {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = defaultMetadataTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	token, err := c.sessionToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	body, status, err := c.do(ctx, "GET", path, "X-aws-ec2-metadata-token", token)
+	if err == nil && status == http.StatusUnauthorized && token != "" {
+		// The token expired early, such as when the instance was
+		// stopped and started again.
+		c.expireToken(token)
+		if token, err = c.sessionToken(ctx); err != nil {
+			return nil, err
+		}
+		body, status, err = c.do(ctx, "GET", path, "X-aws-ec2-metadata-token", token)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("Code %d returned for url %s", status, c.endpoint()+path)
+	}
+	return body, nil
+}
+
+// sessionToken returns the session token to authenticate requests with,
+// or the empty string for IMDSv1 requests.
+func (c *MetadataClient) sessionToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	ttl := c.TokenTTL
+	if ttl == 0 {
+		ttl = defaultTokenTTL
+	}
+	seconds := strconv.Itoa(int(ttl / time.Second))
+	timeout := c.TokenTimeout
+	if timeout == 0 {
+		timeout = defaultTokenTimeout
+	}
+	tokenCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	body, status, err := c.do(tokenCtx, "PUT", "/latest/api/token", "X-aws-ec2-metadata-token-ttl-seconds", seconds)
+	if err == nil && status == http.StatusOK {
+		c.token = string(body)
+		// Renew tokens a minute, or half their lifetime, before they
+		// expire.
+		margin := time.Minute
+		if ttl/2 < margin {
+			margin = ttl / 2
+		}
+		c.tokenExpiry = start.Add(ttl - margin)
+		return c.token, nil
+	}
+
+	if err == nil {
+		err = fmt.Errorf("Code %d returned for url %s", status, c.endpoint()+"/latest/api/token")
+	}
+	if c.v1Disabled() {
+		return "", fmt.Errorf("Error getting instance metadata session token: %v", err)
+	}
+	c.token = ""
+	c.tokenExpiry = time.Now().Add(v1FallbackPeriod)
+	if ctx.Err() != nil {
+		// No time is left for the request itself.
+		return "", ctx.Err()
+	}
+	return "", nil
+}
+
+func (c *MetadataClient) expireToken(token string) {
+	c.mu.Lock()
+	if c.token == token {
+		c.tokenExpiry = time.Time{}
+	}
+	c.mu.Unlock()
+}
+
+// do sends a request to the metadata service with the header key set to
+// value, if not empty.
+func (c *MetadataClient) do(ctx context.Context, method, path, key, value string) (body []byte, status int, err error) {
+	req, err := http.NewRequest(method, c.endpoint()+path, nil)
+	if err != nil {
+		return
+	}
+	if value != "" {
+		req.Header.Set(key, value)
+	}
+	resp, err := c.httpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	body, err = ioutil.ReadAll(resp.Body)
+	return body, resp.StatusCode, err
+}
@@ -0,0 +1,216 @@
+package aws_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/goamz/goamz/aws"
+	. "gopkg.in/check.v1"
+)
+
+// fakeIMDS is a stand-in of the instance metadata service. It hands out
+// session tokens unless tokenStatus is set, and requires them if v2only is
+// set.
+type fakeIMDS struct {
+	mu          sync.Mutex
+	tokenStatus int
+	v2only      bool
+	tokens      int
+	ttls        []string
+	unauthed    int
+	data        map[string]string
+}
+
+func (f *fakeIMDS) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if req.URL.Path == "/latest/api/token" {
+		if req.Method != "PUT" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if f.tokenStatus != 0 {
+			w.WriteHeader(f.tokenStatus)
+			return
+		}
+		f.tokens++
+		f.ttls = append(f.ttls, req.Header.Get("X-aws-ec2-metadata-token-ttl-seconds"))
+		w.Write([]byte("token" + string(rune('0'+f.tokens))))
+		return
+	}
+	token := req.Header.Get("X-aws-ec2-metadata-token")
+	if token == "" && f.v2only || token != "" && token != "token"+string(rune('0'+f.tokens)) {
+		f.unauthed++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	data, ok := f.data[req.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Write([]byte(data))
+}
+
+var identityDocument = `{
+  "accountId" : "123456789012",
+  "architecture" : "x86_64",
+  "availabilityZone" : "eu-west-3b",
+  "billingProducts" : null,
+  "devpayProductCodes" : null,
+  "marketplaceProductCodes" : null,
+  "imageId" : "ami-5fb8c835",
+  "instanceId" : "i-1234567890abcdef0",
+  "instanceType" : "t3.micro",
+  "kernelId" : null,
+  "pendingTime" : "2016-11-19T16:32:11Z",
+  "privateIp" : "172.31.38.89",
+  "ramdiskId" : null,
+  "region" : "eu-west-3",
+  "version" : "2017-09-30"
+}`
+
+func newFakeIMDS() (*fakeIMDS, *httptest.Server) {
+	f := &fakeIMDS{data: map[string]string{
+		"/latest/meta-data/instance-id":                 "i-1234567890abcdef0",
+		"/latest/meta-data/placement/region":            "eu-west-3",
+		"/latest/meta-data/placement/availability-zone": "eu-west-3b",
+		"/latest/meta-data/tags/instance":               "Name\nteam",
+		"/latest/meta-data/tags/instance/Name":          "web",
+		"/latest/meta-data/tags/instance/team":          "infra",
+		"/latest/dynamic/instance-identity/document":    identityDocument,
+		"/latest/user-data":                             "#!/bin/sh\necho hello\n",
+	}}
+	return f, httptest.NewServer(f)
+}
+
+func (s *S) TestMetadataSessionToken(c *C) {
+	f, ts := newFakeIMDS()
+	defer ts.Close()
+	f.v2only = true
+	client := &aws.MetadataClient{Endpoint: ts.URL, TokenTTL: time.Minute}
+
+	id, err := client.GetMetaData("instance-id")
+	c.Assert(err, IsNil)
+	c.Assert(string(id), Equals, "i-1234567890abcdef0")
+	zone, err := client.AvailabilityZone()
+	c.Assert(err, IsNil)
+	c.Assert(zone, Equals, "eu-west-3b")
+
+	// The token is reused.
+	c.Assert(f.tokens, Equals, 1)
+	c.Assert(f.ttls, DeepEquals, []string{"60"})
+}
+
+func (s *S) TestMetadataExpiredToken(c *C) {
+	f, ts := newFakeIMDS()
+	defer ts.Close()
+	f.v2only = true
+	client := &aws.MetadataClient{Endpoint: ts.URL}
+
+	_, err := client.GetMetaData("instance-id")
+	c.Assert(err, IsNil)
+
+	// The service forgets the token, which is renewed.
+	f.tokens++
+	id, err := client.GetMetaData("instance-id")
+	c.Assert(err, IsNil)
+	c.Assert(string(id), Equals, "i-1234567890abcdef0")
+	c.Assert(f.unauthed, Equals, 1)
+	c.Assert(f.tokens, Equals, 3)
+}
+
+func (s *S) TestMetadataV1Fallback(c *C) {
+	f, ts := newFakeIMDS()
+	defer ts.Close()
+	f.tokenStatus = http.StatusNotFound
+	client := &aws.MetadataClient{Endpoint: ts.URL}
+
+	id, err := client.GetMetaData("instance-id")
+	c.Assert(err, IsNil)
+	c.Assert(string(id), Equals, "i-1234567890abcdef0")
+
+	client = &aws.MetadataClient{Endpoint: ts.URL, DisableV1Fallback: true}
+	_, err = client.GetMetaData("instance-id")
+	c.Assert(err, ErrorMatches, "Error getting instance metadata session token: Code 404 returned for url .*/latest/api/token")
+}
+
+func (s *S) TestMetadataTokenTimeout(c *C) {
+	f, _ := newFakeIMDS()
+	block := make(chan bool)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/latest/api/token" {
+			<-block
+			return
+		}
+		f.ServeHTTP(w, req)
+	}))
+	defer ts.Close()
+	defer close(block)
+	client := &aws.MetadataClient{Endpoint: ts.URL, HTTPClient: http.DefaultClient, TokenTimeout: 50 * time.Millisecond}
+
+	// The token request times out, leaving time for an IMDSv1 request.
+	start := time.Now()
+	id, err := client.GetMetaData("instance-id")
+	c.Assert(err, IsNil)
+	c.Assert(string(id), Equals, "i-1234567890abcdef0")
+	c.Assert(time.Since(start) < time.Second, Equals, true)
+}
+
+func (s *S) TestMetadataTimeout(c *C) {
+	block := make(chan bool)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-block
+	}))
+	defer ts.Close()
+	defer close(block)
+	client := &aws.MetadataClient{Endpoint: ts.URL, Timeout: 50 * time.Millisecond, HTTPClient: http.DefaultClient}
+
+	start := time.Now()
+	_, err := client.GetMetaData("instance-id")
+	c.Assert(err, NotNil)
+	c.Assert(time.Since(start) < time.Second, Equals, true)
+}
+
+func (s *S) TestMetadataEndpointFromEnv(c *C) {
+	_, ts := newFakeIMDS()
+	defer ts.Close()
+	os.Setenv("AWS_EC2_METADATA_SERVICE_ENDPOINT", ts.URL+"/")
+
+	id, err := (&aws.MetadataClient{}).GetMetaData("instance-id")
+	c.Assert(err, IsNil)
+	c.Assert(string(id), Equals, "i-1234567890abcdef0")
+}
+
+func (s *S) TestMetadataAccessors(c *C) {
+	_, ts := newFakeIMDS()
+	defer ts.Close()
+	client := &aws.MetadataClient{Endpoint: ts.URL}
+
+	doc, err := client.InstanceIdentityDocument()
+	c.Assert(err, IsNil)
+	c.Assert(doc.AccountId, Equals, "123456789012")
+	c.Assert(doc.InstanceId, Equals, "i-1234567890abcdef0")
+	c.Assert(doc.InstanceType, Equals, "t3.micro")
+	c.Assert(doc.Region, Equals, "eu-west-3")
+	c.Assert(doc.PrivateIp, Equals, "172.31.38.89")
+	c.Assert(doc.PendingTime.Equal(time.Date(2016, 11, 19, 16, 32, 11, 0, time.UTC)), Equals, true)
+
+	region, err := client.Region()
+	c.Assert(err, IsNil)
+	c.Assert(region, DeepEquals, aws.EUWest3)
+
+	userData, err := client.GetUserData()
+	c.Assert(err, IsNil)
+	c.Assert(string(userData), Equals, "#!/bin/sh\necho hello\n")
+
+	tags, err := client.Tags()
+	c.Assert(err, IsNil)
+	c.Assert(tags, DeepEquals, map[string]string{"Name": "web", "team": "infra"})
+
+	_, err = client.GetMetaData("public-ipv4")
+	c.Assert(err, ErrorMatches, "Code 404 returned for url .*/latest/meta-data/public-ipv4")
+}
@@ -0,0 +1,198 @@
+package aws
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Instrumentation is told about every attempt of the API calls of the
+// clients it is given to, to export metrics or traces of them. It is
+// called concurrently by the requests of all those clients.
+//
+// Clients are instrumented through their Instrumentation fields, or
+// through InstrumentHandler on their Handlers or GlobalHandlers.
+type Instrumentation interface {
+	AttemptDone(a *CallAttempt)
+}
+
+// InstrumentationFunc is a function that implements Instrumentation.
+type InstrumentationFunc func(a *CallAttempt)
+
+func (f InstrumentationFunc) AttemptDone(a *CallAttempt) {
+	f(a)
+}
+
+// CallAttempt describes an attempt of an API call.
+type CallAttempt struct {
+	Service   string
+	Operation string
+	Region    string
+
+	// Attempt is the number of the attempt, from 1. Retries have
+	// higher numbers.
+	Attempt int
+
+	Start    time.Time
+	Duration time.Duration
+
+	// StatusCode is the HTTP status of the response, or zero if none
+	// was received.
+	StatusCode int
+
+	// Err is the error the attempt failed with, if any. ErrorCode is its
+	// AWS error code, if it is an APIError, and Throttled tells whether
+	// it reports throttling, as IsThrottle.
+	Err       error
+	ErrorCode string
+	Throttled bool
+
+	// BytesSent is the length of the request body. BytesReceived is the
+	// length of the response body, as read by the client, or as given by
+	// its Content-Length when the body is handed to the caller. Either is
+	// -1 if unknown.
+	BytesSent     int64
+	BytesReceived int64
+}
+
+// StartAttempt returns the CallAttempt of an attempt starting now, for
+// clients that don't send their requests through Request. Its Done method
+// completes it once the response is received.
+func StartAttempt(service, operation string, region Region, attempt int) *CallAttempt {
+	return &CallAttempt{
+		Service:   service,
+		Operation: operation,
+		Region:    region.Name,
+		Attempt:   attempt,
+		Start:     time.Now(),
+	}
+}
+
+// Done completes a with req, the response to it and the error of the
+// attempt, any of which may be nil, and gives it to inst if not nil.
+func (a *CallAttempt) Done(inst Instrumentation, req *http.Request, resp *http.Response, err error) {
+	if inst != nil {
+		a.finish(req, resp, err)
+		inst.AttemptDone(a)
+	}
+}
+
+func (a *CallAttempt) finish(req *http.Request, resp *http.Response, err error) {
+	a.Duration = time.Since(a.Start)
+	a.BytesSent, a.BytesReceived = -1, -1
+	if req != nil {
+		a.BytesSent = req.ContentLength
+		if req.Body == nil || req.Body == http.NoBody {
+			a.BytesSent = 0
+		}
+	}
+	if resp != nil {
+		a.StatusCode = resp.StatusCode
+		a.BytesReceived = resp.ContentLength
+	}
+	a.Err = err
+	if apiErr, ok := apiError(err); ok {
+		a.ErrorCode = apiErr.ErrorCode()
+		if a.StatusCode == 0 {
+			a.StatusCode = apiErr.HTTPStatusCode()
+		}
+	}
+	a.Throttled = IsThrottle(err)
+}
+
+// InstrumentHandler returns a Build handler giving the attempts of requests
+// to inst. It may be added to the Handlers of a client, or to
+// GlobalHandlers to instrument every client.
+func InstrumentHandler(inst Instrumentation) NamedHandler {
+	return NamedHandler{Name: "core.Instrument", Fn: func(r *Request) {
+		r.Instrumentation = inst
+	}}
+}
+
+// countingReader counts the bytes read from a response body.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// MemoryInstrumentation is an Instrumentation keeping the attempts it is
+// told about in memory, for tests and debugging.
+type MemoryInstrumentation struct {
+	mu       sync.Mutex
+	attempts []CallAttempt
+}
+
+// OperationStats sums up the attempts of the calls of an operation.
+type OperationStats struct {
+	// Calls is the number of first attempts, and Retries of the others.
+	Calls   int
+	Retries int
+
+	// Errors is the number of failed attempts, and Throttles of those
+	// that were throttled.
+	Errors    int
+	Throttles int
+
+	Duration      time.Duration
+	BytesSent     int64
+	BytesReceived int64
+}
+
+func (m *MemoryInstrumentation) AttemptDone(a *CallAttempt) {
+	m.mu.Lock()
+	m.attempts = append(m.attempts, *a)
+	m.mu.Unlock()
+}
+
+// Attempts returns the attempts told about so far.
+func (m *MemoryInstrumentation) Attempts() []CallAttempt {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]CallAttempt(nil), m.attempts...)
+}
+
+// Stats returns the stats of the operation of service. Unknown byte
+// counts are left out of them.
+func (m *MemoryInstrumentation) Stats(service, operation string) OperationStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var stats OperationStats
+	for _, a := range m.attempts {
+		if a.Service != service || a.Operation != operation {
+			continue
+		}
+		if a.Attempt <= 1 {
+			stats.Calls++
+		} else {
+			stats.Retries++
+		}
+		if a.Err != nil {
+			stats.Errors++
+		}
+		if a.Throttled {
+			stats.Throttles++
+		}
+		stats.Duration += a.Duration
+		if a.BytesSent > 0 {
+			stats.BytesSent += a.BytesSent
+		}
+		if a.BytesReceived > 0 {
+			stats.BytesReceived += a.BytesReceived
+		}
+	}
+	return stats
+}
+
+// Reset forgets the attempts told about so far.
+func (m *MemoryInstrumentation) Reset() {
+	m.mu.Lock()
+	m.attempts = nil
+	m.mu.Unlock()
+}
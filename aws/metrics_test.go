@@ -0,0 +1,99 @@
+package aws_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/goamz/goamz/aws"
+	. "gopkg.in/check.v1"
+)
+
+func (s *S) TestInstrumentation(c *C) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(400)
+			fmt.Fprint(w, "throttled")
+			return
+		}
+		fmt.Fprint(w, "<Response><Value>ok</Value></Response>")
+	}))
+	defer ts.Close()
+
+	inst := &aws.MemoryInstrumentation{}
+	h := aws.QueryHandlers()
+	h.Build.PushBack(aws.InstrumentHandler(inst))
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("test.UnmarshalError", func(resp *http.Response) error {
+		return &aws.Error{StatusCode: resp.StatusCode, Code: "Throttling"}
+	}))
+	h.Retry.Remove(aws.RetryHandler.Name)
+	h.Retry.PushBack(aws.NamedHandler{Name: "test.Retry", Fn: func(r *aws.Request) {
+		r.Retryable = r.RetryCount < 1
+	}})
+	var result queryResult
+	r := &aws.Request{
+		Service:   "ec2",
+		Operation: "DescribeInstances",
+		Region:    aws.USWest2,
+		Auth:      aws.Auth{AccessKey: "access", SecretKey: "secret"},
+		Method:    "POST",
+		Endpoint:  ts.URL,
+		Params:    map[string]string{"Action": "DescribeInstances"},
+		Result:    &result,
+		Handlers:  h,
+	}
+	c.Assert(r.Send(), IsNil)
+	c.Assert(result.Value, Equals, "ok")
+
+	attempts := inst.Attempts()
+	c.Assert(attempts, HasLen, 2)
+	for i, a := range attempts {
+		c.Assert(a.Service, Equals, "ec2")
+		c.Assert(a.Operation, Equals, "DescribeInstances")
+		c.Assert(a.Region, Equals, "us-west-2")
+		c.Assert(a.Attempt, Equals, i+1)
+		c.Assert(a.BytesSent, Equals, int64(len("Action=DescribeInstances")))
+		c.Assert(a.Start.IsZero(), Equals, false)
+	}
+	c.Assert(attempts[0].StatusCode, Equals, 400)
+	c.Assert(attempts[0].ErrorCode, Equals, "Throttling")
+	c.Assert(attempts[0].Throttled, Equals, true)
+	c.Assert(attempts[0].BytesReceived, Equals, int64(len("throttled")))
+	c.Assert(attempts[1].StatusCode, Equals, 200)
+	c.Assert(attempts[1].Err, IsNil)
+	c.Assert(attempts[1].ErrorCode, Equals, "")
+	c.Assert(attempts[1].BytesReceived, Equals, int64(len("<Response><Value>ok</Value></Response>")))
+
+	stats := inst.Stats("ec2", "DescribeInstances")
+	c.Assert(stats.Calls, Equals, 1)
+	c.Assert(stats.Retries, Equals, 1)
+	c.Assert(stats.Errors, Equals, 1)
+	c.Assert(stats.Throttles, Equals, 1)
+	c.Assert(stats.BytesSent, Equals, int64(2*len("Action=DescribeInstances")))
+	c.Assert(stats.Duration, Equals, attempts[0].Duration+attempts[1].Duration)
+	c.Assert(inst.Stats("ec2", "RunInstances"), Equals, aws.OperationStats{})
+
+	inst.Reset()
+	c.Assert(inst.Attempts(), HasLen, 0)
+}
+
+func (s *S) TestInstrumentationNetworkError(c *C) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	var attempts []*aws.CallAttempt
+	h := aws.QueryHandlers()
+	h.Build.PushBack(aws.InstrumentHandler(aws.InstrumentationFunc(func(a *aws.CallAttempt) {
+		attempts = append(attempts, a)
+	})))
+	h.Retry.Remove(aws.RetryHandler.Name)
+	r := &aws.Request{Service: "sqs", Operation: "SendMessage", Method: "GET", Endpoint: ts.URL, Handlers: h}
+	c.Assert(r.Send(), NotNil)
+	c.Assert(attempts, HasLen, 1)
+	c.Assert(attempts[0].Operation, Equals, "SendMessage")
+	c.Assert(attempts[0].StatusCode, Equals, 0)
+	c.Assert(attempts[0].Err, NotNil)
+	c.Assert(attempts[0].BytesReceived, Equals, int64(-1))
+}
@@ -0,0 +1,76 @@
+package aws
+
+// Pager iterates over the pages of results of an operation returning them
+// in several pages, such as those taking a marker or a NextToken. Clients
+// return one from a Pages method next to every such operation:
+//
+//	p := as.DescribeAutoScalingGroupsPages(nil, 0)
+//	for p.Next() {
+//		for _, group := range p.Page().AutoScalingGroups {
+//			...
+//		}
+//	}
+//	if err := p.Err(); err != nil {
+//		...
+//	}
+type Pager[T any] struct {
+	fetch func(token string) (T, string, error)
+	token string
+	page  T
+	err   error
+	done  bool
+}
+
+// NewPager returns a Pager fetching pages with fetch, which is given the
+// token of the page to fetch, empty for the first one, and returns the
+// page along with the token of the next one, empty after the last page.
+func NewPager[T any](fetch func(token string) (page T, next string, err error)) *Pager[T] {
+	return &Pager[T]{fetch: fetch}
+}
+
+// Next fetches the next page, reporting whether there was one. It returns
+// false after the last page, or once fetching a page failed.
+func (p *Pager[T]) Next() bool {
+	if p.done {
+		return false
+	}
+	page, next, err := p.fetch(p.token)
+	if err != nil {
+		var zero T
+		p.page, p.err, p.done = zero, err, true
+		return false
+	}
+	// Stop rather than loop forever on a service handing out the token
+	// it was given.
+	p.done = next == "" || next == p.token
+	p.page, p.token = page, next
+	return true
+}
+
+// Page returns the page fetched by the last call to Next.
+func (p *Pager[T]) Page() T {
+	return p.page
+}
+
+// LastPage reports whether the page fetched by the last call to Next is
+// the last one.
+func (p *Pager[T]) LastPage() bool {
+	return p.done
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (p *Pager[T]) Err() error {
+	return p.err
+}
+
+// EachPage calls fn with every page left, and whether it is the last one,
+// until fn returns false. It returns the error of the first page that
+// could not be fetched.
+func (p *Pager[T]) EachPage(fn func(page T, lastPage bool) bool) error {
+	for p.Next() {
+		if !fn(p.page, p.done) {
+			break
+		}
+	}
+	return p.err
+}
@@ -0,0 +1,87 @@
+package aws_test
+
+import (
+	"errors"
+
+	"github.com/goamz/goamz/aws"
+	. "gopkg.in/check.v1"
+)
+
+// pagesOf returns a Pager over pages, whose tokens are the index of the
+// page, and the tokens it was asked for.
+func pagesOf(pages []string, err error) (*aws.Pager[string], *[]string) {
+	var tokens []string
+	return aws.NewPager(func(token string) (string, string, error) {
+		tokens = append(tokens, token)
+		i := 0
+		if token != "" {
+			i = int(token[0] - '0')
+		}
+		if i == len(pages) {
+			return "", "", err
+		}
+		next := ""
+		if i+1 < len(pages) || err != nil {
+			next = string(rune('0' + i + 1))
+		}
+		return pages[i], next, nil
+	}), &tokens
+}
+
+func (s *S) TestPager(c *C) {
+	p, tokens := pagesOf([]string{"a", "b", "c"}, nil)
+	var pages []string
+	for p.Next() {
+		pages = append(pages, p.Page())
+		c.Assert(p.LastPage(), Equals, len(pages) == 3)
+	}
+	c.Assert(p.Err(), IsNil)
+	c.Assert(pages, DeepEquals, []string{"a", "b", "c"})
+	c.Assert(*tokens, DeepEquals, []string{"", "1", "2"})
+	c.Assert(p.Next(), Equals, false)
+	c.Assert(*tokens, HasLen, 3)
+}
+
+func (s *S) TestPagerError(c *C) {
+	p, _ := pagesOf([]string{"a"}, errors.New("boom"))
+	c.Assert(p.Next(), Equals, true)
+	c.Assert(p.Page(), Equals, "a")
+	c.Assert(p.Next(), Equals, false)
+	c.Assert(p.Page(), Equals, "")
+	c.Assert(p.Err(), ErrorMatches, "boom")
+	c.Assert(p.Next(), Equals, false)
+}
+
+func (s *S) TestPagerRepeatedToken(c *C) {
+	var calls int
+	p := aws.NewPager(func(token string) (int, string, error) {
+		calls++
+		return calls, "same", nil
+	})
+	var pages []int
+	err := p.EachPage(func(page int, lastPage bool) bool {
+		pages = append(pages, page)
+		return true
+	})
+	c.Assert(err, IsNil)
+	c.Assert(pages, DeepEquals, []int{1, 2})
+}
+
+func (s *S) TestPagerEachPage(c *C) {
+	p, tokens := pagesOf([]string{"a", "b", "c"}, nil)
+	var pages []string
+	err := p.EachPage(func(page string, lastPage bool) bool {
+		pages = append(pages, page)
+		return page != "b"
+	})
+	c.Assert(err, IsNil)
+	c.Assert(pages, DeepEquals, []string{"a", "b"})
+	c.Assert(*tokens, DeepEquals, []string{"", "1"})
+
+	p, _ = pagesOf(nil, errors.New("boom"))
+	err = p.EachPage(func(page string, lastPage bool) bool {
+		c.Fatalf("unexpected page %q", page)
+		return true
+	})
+	c.Assert(err, ErrorMatches, "boom")
+}
@@ -0,0 +1,281 @@
+package aws
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// endpointsJSON describes the regions of AWS, grouped in partitions, and
+// the endpoints of the services in each of them. It follows the format of
+// the endpoints.json document shipped with the AWS SDKs.
+//
+//go:embed endpoints.json
+var endpointsJSON []byte
+
+// endpointsDocument is the subset of the endpoints.json format goamz
+// understands.
+type endpointsDocument struct {
+	Partitions []*partitionDoc `json:"partitions"`
+}
+
+type partitionDoc struct {
+	Partition   string `json:"partition"`
+	DNSSuffix   string `json:"dnsSuffix"`
+	RegionRegex string `json:"regionRegex"`
+	Defaults    struct {
+		endpointDoc
+		Variants []struct {
+			DNSSuffix string   `json:"dnsSuffix"`
+			Hostname  string   `json:"hostname"`
+			Tags      []string `json:"tags"`
+		} `json:"variants"`
+	} `json:"defaults"`
+	Regions  map[string]struct{} `json:"regions"`
+	Services map[string]struct {
+		PartitionEndpoint string                 `json:"partitionEndpoint"`
+		IsRegionalized    *bool                  `json:"isRegionalized"`
+		Defaults          endpointDoc            `json:"defaults"`
+		Endpoints         map[string]endpointDoc `json:"endpoints"`
+	} `json:"services"`
+
+	regionRegex *regexp.Regexp
+}
+
+type endpointDoc struct {
+	Hostname          string   `json:"hostname"`
+	SignatureVersions []string `json:"signatureVersions"`
+	CredentialScope   struct {
+		Region  string `json:"region"`
+		Service string `json:"service"`
+	} `json:"credentialScope"`
+}
+
+// merge returns e with the settings it lacks taken from defaults.
+func (e endpointDoc) merge(defaults endpointDoc) endpointDoc {
+	if e.Hostname == "" {
+		e.Hostname = defaults.Hostname
+	}
+	if e.SignatureVersions == nil {
+		e.SignatureVersions = defaults.SignatureVersions
+	}
+	if e.CredentialScope.Region == "" {
+		e.CredentialScope.Region = defaults.CredentialScope.Region
+	}
+	if e.CredentialScope.Service == "" {
+		e.CredentialScope.Service = defaults.CredentialScope.Service
+	}
+	return e
+}
+
+// docEndpoint is the endpoint of a service in a region, as described by
+// the endpoints document.
+type docEndpoint struct {
+	host          string
+	signingName   string
+	signingRegion string
+	signer        uint
+	// global is set for services with one endpoint per partition.
+	global bool
+}
+
+var (
+	partitionsMu sync.RWMutex
+	partitions   []*partitionDoc
+)
+
+// partitionOf returns the partition region belongs to, defaulting to the
+// first one of the document.
+func partitionOf(region string) *partitionDoc {
+	partitionsMu.RLock()
+	defer partitionsMu.RUnlock()
+	for _, p := range partitions {
+		if _, ok := p.Regions[region]; ok {
+			return p
+		}
+	}
+	for _, p := range partitions {
+		if p.regionRegex != nil && p.regionRegex.MatchString(region) {
+			return p
+		}
+	}
+	return partitions[0]
+}
+
+// dualStackDNSSuffix returns the DNS suffix of the dual-stack endpoints
+// of p.
+func (p *partitionDoc) dualStackDNSSuffix() string {
+	for _, v := range p.Defaults.Variants {
+		if len(v.Tags) == 1 && v.Tags[0] == "dualstack" {
+			return v.DNSSuffix
+		}
+	}
+	return p.DNSSuffix
+}
+
+// endpoint returns the endpoint of service in region, and whether p knows
+// about it.
+func (p *partitionDoc) endpoint(service, region string) (docEndpoint, bool) {
+	s, ok := p.Services[service]
+	if !ok {
+		return docEndpoint{}, false
+	}
+	global := s.IsRegionalized != nil && !*s.IsRegionalized
+	e, ok := s.Endpoints[region]
+	if !ok || global {
+		if e, ok = s.Endpoints[s.PartitionEndpoint]; !ok {
+			return docEndpoint{}, false
+		}
+		region = s.PartitionEndpoint
+	}
+	e = e.merge(s.Defaults).merge(p.Defaults.endpointDoc)
+	ep := docEndpoint{
+		host: strings.NewReplacer(
+			"{service}", service,
+			"{region}", region,
+			"{dnsSuffix}", p.DNSSuffix,
+		).Replace(e.Hostname),
+		signingName:   e.CredentialScope.Service,
+		signingRegion: e.CredentialScope.Region,
+		signer:        V4Signature,
+		global:        global,
+	}
+	if ep.signingName == "" {
+		ep.signingName = service
+	}
+	if ep.signingRegion == "" {
+		ep.signingRegion = region
+	}
+	for _, v := range e.SignatureVersions {
+		if v == "v2" {
+			ep.signer = V2Signature
+		}
+	}
+	return ep, true
+}
+
+// region returns the Region named name, with the endpoints p describes
+// for it.
+func (p *partitionDoc) region(name string) Region {
+	r := Region{Name: name}
+	for service, field := range regionFields {
+		if ep, ok := p.endpoint(service, name); ok {
+			*field(&r) = "https://" + ep.host
+		}
+	}
+	if ep, ok := p.endpoint("monitoring", name); ok {
+		r.CloudWatchServicepoint.Signer = ep.signer
+	}
+	if ep, ok := p.endpoint("rds", name); ok {
+		r.RDSEndpoint.Signer = ep.signer
+	}
+	// Buckets must be created with a LocationConstraint and a lowercase
+	// name everywhere but in the region served by the partition-wide
+	// endpoint of S3.
+	if ep, ok := p.endpoint("s3", name); ok && strings.Contains(ep.host, name) {
+		r.S3LocationConstraint = true
+		r.S3LowercaseBucket = true
+	}
+	return r
+}
+
+// regionFields maps the services with an endpoint field in Region to that
+// field.
+var regionFields = map[string]func(*Region) *string{
+	"autoscaling":          func(r *Region) *string { return &r.AutoScalingEndpoint },
+	"cloudformation":       func(r *Region) *string { return &r.CloudFormationEndpoint },
+	"dynamodb":             func(r *Region) *string { return &r.DynamoDBEndpoint },
+	"ec2":                  func(r *Region) *string { return &r.EC2Endpoint },
+	"ecs":                  func(r *Region) *string { return &r.ECSEndpoint },
+	"elasticloadbalancing": func(r *Region) *string { return &r.ELBEndpoint },
+	"email":                func(r *Region) *string { return &r.SESEndpoint },
+	"iam":                  func(r *Region) *string { return &r.IAMEndpoint },
+	"monitoring":           func(r *Region) *string { return &r.CloudWatchServicepoint.Endpoint },
+	"rds":                  func(r *Region) *string { return &r.RDSEndpoint.Endpoint },
+	"s3":                   func(r *Region) *string { return &r.S3Endpoint },
+	"sdb":                  func(r *Region) *string { return &r.SDBEndpoint },
+	"sns":                  func(r *Region) *string { return &r.SNSEndpoint },
+	"sqs":                  func(r *Region) *string { return &r.SQSEndpoint },
+	"streams.dynamodb":     func(r *Region) *string { return &r.DynamoDBStreamsEndpoint },
+	"sts":                  func(r *Region) *string { return &r.STSEndpoint },
+}
+
+func parseEndpoints(r io.Reader) ([]*partitionDoc, error) {
+	var doc endpointsDocument
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("aws: cannot parse endpoints: %v", err)
+	}
+	if len(doc.Partitions) == 0 {
+		return nil, fmt.Errorf("aws: no partitions in endpoints")
+	}
+	for _, p := range doc.Partitions {
+		if p.RegionRegex == "" {
+			continue
+		}
+		var err error
+		if p.regionRegex, err = regexp.Compile(p.RegionRegex); err != nil {
+			return nil, fmt.Errorf("aws: bad region regex of partition %q: %v", p.Partition, err)
+		}
+	}
+	return doc.Partitions, nil
+}
+
+// regionsOf returns the regions described by ps, by name.
+func regionsOf(ps []*partitionDoc) map[string]Region {
+	regions := make(map[string]Region)
+	for _, p := range ps {
+		for name := range p.Regions {
+			regions[name] = p.region(name)
+		}
+	}
+	return regions
+}
+
+// loadDefaultEndpoints parses the embedded endpoints document.
+func loadDefaultEndpoints() map[string]Region {
+	ps, err := parseEndpoints(bytes.NewReader(endpointsJSON))
+	if err != nil {
+		panic(err)
+	}
+	partitions = ps
+	return regionsOf(ps)
+}
+
+// LoadEndpoints replaces the region and endpoint data embedded in goamz
+// with the endpoints.json document read from r, such as the one shipped
+// with the AWS SDKs, to learn about regions and services added since.
+// The regions of the document are added to Regions, replacing those of
+// the same name. Regions held elsewhere, such as in the USEast variable
+// or by clients, are not updated.
+//
+// LoadEndpoints should be called before any client is created.
+func LoadEndpoints(r io.Reader) error {
+	ps, err := parseEndpoints(r)
+	if err != nil {
+		return err
+	}
+	partitionsMu.Lock()
+	partitions = ps
+	partitionsMu.Unlock()
+	for name, region := range regionsOf(ps) {
+		Regions[name] = region
+	}
+	return nil
+}
+
+// LoadEndpointsFile calls LoadEndpoints with the content of the file
+// filename.
+func LoadEndpointsFile(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return LoadEndpoints(f)
+}
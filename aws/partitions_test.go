@@ -0,0 +1,115 @@
+package aws_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/goamz/goamz/aws"
+	. "gopkg.in/check.v1"
+)
+
+func (s *S) TestRegions(c *C) {
+	c.Assert(aws.USEast, DeepEquals, aws.Region{
+		Name:                    "us-east-1",
+		EC2Endpoint:             "https://ec2.us-east-1.amazonaws.com",
+		S3Endpoint:              "https://s3.amazonaws.com",
+		SDBEndpoint:             "https://sdb.amazonaws.com",
+		SESEndpoint:             "https://email.us-east-1.amazonaws.com",
+		SNSEndpoint:             "https://sns.us-east-1.amazonaws.com",
+		SQSEndpoint:             "https://sqs.us-east-1.amazonaws.com",
+		IAMEndpoint:             "https://iam.amazonaws.com",
+		ELBEndpoint:             "https://elasticloadbalancing.us-east-1.amazonaws.com",
+		DynamoDBEndpoint:        "https://dynamodb.us-east-1.amazonaws.com",
+		CloudWatchServicepoint:  aws.ServiceInfo{Endpoint: "https://monitoring.us-east-1.amazonaws.com", Signer: aws.V2Signature},
+		AutoScalingEndpoint:     "https://autoscaling.us-east-1.amazonaws.com",
+		RDSEndpoint:             aws.ServiceInfo{Endpoint: "https://rds.us-east-1.amazonaws.com", Signer: aws.V2Signature},
+		STSEndpoint:             "https://sts.amazonaws.com",
+		CloudFormationEndpoint:  "https://cloudformation.us-east-1.amazonaws.com",
+		ECSEndpoint:             "https://ecs.us-east-1.amazonaws.com",
+		DynamoDBStreamsEndpoint: "https://streams.dynamodb.us-east-1.amazonaws.com",
+	})
+	c.Assert(aws.Regions["eu-west-1"], DeepEquals, aws.EUWest)
+
+	// Older regions keep their legacy S3 endpoints.
+	c.Assert(aws.USWest2.S3Endpoint, Equals, "https://s3-us-west-2.amazonaws.com")
+	c.Assert(aws.USGovWest.S3Endpoint, Equals, "https://s3-fips-us-gov-west-1.amazonaws.com")
+
+	// Newer regions and partitions.
+	c.Assert(aws.EUNorth.Name, Equals, "eu-north-1")
+	c.Assert(aws.EUNorth.S3Endpoint, Equals, "https://s3.eu-north-1.amazonaws.com")
+	c.Assert(aws.EUNorth.SDBEndpoint, Equals, "")
+	c.Assert(aws.EUNorth.RDSEndpoint.Signer, Equals, uint(aws.V4Signature))
+	c.Assert(aws.CNNorthwest.EC2Endpoint, Equals, "https://ec2.cn-northwest-1.amazonaws.com.cn")
+	c.Assert(aws.CNNorthwest.IAMEndpoint, Equals, "https://iam.cn-north-1.amazonaws.com.cn")
+	c.Assert(aws.USGovEast.STSEndpoint, Equals, "https://sts.us-gov-east-1.amazonaws.com")
+	c.Assert(aws.USGovEast.IAMEndpoint, Equals, "https://iam.us-gov.amazonaws.com")
+	for _, name := range []string{"ca-central-1", "eu-west-2", "eu-west-3", "ap-south-1", "ap-northeast-3"} {
+		c.Assert(aws.Regions[name].EC2Endpoint, Equals, "https://ec2."+name+".amazonaws.com")
+	}
+
+	// Only the region of the partition-wide S3 endpoint takes buckets
+	// without a LocationConstraint.
+	for name, region := range aws.Regions {
+		c.Check(region.S3LocationConstraint, Equals, name != "us-east-1", Commentf(name))
+		c.Check(region.S3LowercaseBucket, Equals, name != "us-east-1", Commentf(name))
+	}
+}
+
+var testEndpoints = `{
+  "partitions": [{
+    "partition": "aws-test",
+    "dnsSuffix": "example.com",
+    "regionRegex": "^test\\-\\w+\\-\\d+$",
+    "defaults": {"hostname": "{service}.{region}.{dnsSuffix}"},
+    "regions": {"test-east-1": {}, "test-west-1": {}},
+    "services": {
+      "ec2": {"endpoints": {"test-east-1": {}, "test-west-1": {"hostname": "compute.test-west-1.example.com"}}},
+      "rds": {"endpoints": {"test-east-1": {"signatureVersions": ["v2", "v4"]}}},
+      "s3": {"endpoints": {"test-east-1": {"hostname": "s3.example.com"}, "test-west-1": {}}},
+      "iam": {
+        "partitionEndpoint": "test-global",
+        "isRegionalized": false,
+        "endpoints": {"test-global": {"hostname": "iam.example.com", "credentialScope": {"region": "test-east-1"}}}
+      }
+    }
+  }]
+}`
+
+func (s *S) TestLoadEndpoints(c *C) {
+	saved := make(map[string]aws.Region)
+	for name, region := range aws.Regions {
+		saved[name] = region
+	}
+	defer func() {
+		aws.Regions = saved
+		c.Assert(aws.LoadEndpoints(strings.NewReader(aws.EndpointsJSON)), IsNil)
+	}()
+
+	filename := filepath.Join(c.MkDir(), "endpoints.json")
+	c.Assert(ioutil.WriteFile(filename, []byte(testEndpoints), 0600), IsNil)
+	c.Assert(aws.LoadEndpointsFile(filename), IsNil)
+
+	east := aws.Regions["test-east-1"]
+	c.Assert(east.EC2Endpoint, Equals, "https://ec2.test-east-1.example.com")
+	c.Assert(east.RDSEndpoint, Equals, aws.ServiceInfo{Endpoint: "https://rds.test-east-1.example.com", Signer: aws.V2Signature})
+	c.Assert(east.IAMEndpoint, Equals, "https://iam.example.com")
+	c.Assert(east.S3LocationConstraint, Equals, false)
+	c.Assert(east.SQSEndpoint, Equals, "")
+	west := aws.Regions["test-west-1"]
+	c.Assert(west.EC2Endpoint, Equals, "https://compute.test-west-1.example.com")
+	c.Assert(west.S3LocationConstraint, Equals, true)
+	// Regions loaded before are kept.
+	c.Assert(aws.Regions["us-east-1"], DeepEquals, aws.USEast)
+
+	// The resolver follows the loaded document.
+	endpoint, err := aws.ResolveEndpoint("iam", aws.Region{Name: "test-south-1"})
+	c.Assert(err, IsNil)
+	c.Assert(endpoint, Equals, aws.Endpoint{URL: "https://iam.example.com", SigningName: "iam", SigningRegion: "test-east-1"})
+	endpoint, err = aws.ResolveEndpoint("sqs", aws.Region{Name: "test-south-1"})
+	c.Assert(err, IsNil)
+	c.Assert(endpoint.URL, Equals, "https://sqs.test-south-1.example.com")
+
+	c.Assert(aws.LoadEndpoints(strings.NewReader(`{"partitions": []}`)), ErrorMatches, "aws: no partitions in endpoints")
+	c.Assert(aws.LoadEndpointsFile(filepath.Join(c.MkDir(), "missing.json")), ErrorMatches, ".*no such file or directory")
+}
@@ -0,0 +1,204 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiting the rate at which requests are
+// sent. It is safe for concurrent use, so that every goroutine using a
+// client shares the same limit.
+//
+// An adaptive RateLimiter halves its rate every time AWS throttles a
+// request, and then recovers linearly to its configured rate.
+//
+// Add the Handlers of a limiter to a client to limit all its requests:
+//
+//	limiter := aws.NewAdaptiveRateLimiter(20, 5, 1, time.Minute)
+//	e := ec2.New(auth, aws.USEast)
+//	e.Handlers.Append(limiter.Handlers())
+//
+// or use RateLimits to limit some operations separately.
+type RateLimiter struct {
+	mu       sync.Mutex
+	maxRate  float64
+	burst    float64
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+	adaptive bool
+	minRate  float64
+	recovery time.Duration
+
+	// Rate set by the last throttle, and when it happened.
+	throttledRate float64
+	throttledAt   time.Time
+}
+
+// minAdaptiveRate is the lowest rate an adaptive RateLimiter drops to: one
+// request every ten seconds.
+const minAdaptiveRate = 0.1
+
+// NewRateLimiter returns a RateLimiter that lets rate requests per second
+// through on average, with bursts of up to burst requests. It panics if
+// rate is not positive.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if !(rate > 0) {
+		panic(fmt.Sprintf("aws: non-positive rate limit %v", rate))
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		maxRate: rate,
+		burst:   float64(burst),
+		tokens:  float64(burst),
+		now:     time.Now,
+	}
+}
+
+// NewAdaptiveRateLimiter returns a RateLimiter like NewRateLimiter that
+// halves its rate, down to minRate, whenever a request is throttled, and
+// gets back to rate within recovery of the last throttled request. minRate
+// is raised to minAdaptiveRate if lower, so that requests keep flowing. It
+// panics if rate or recovery is not positive.
+func NewAdaptiveRateLimiter(rate float64, burst int, minRate float64, recovery time.Duration) *RateLimiter {
+	if recovery <= 0 {
+		panic(fmt.Sprintf("aws: non-positive rate limit recovery %v", recovery))
+	}
+	l := NewRateLimiter(rate, burst)
+	l.adaptive = true
+	if !(minRate >= minAdaptiveRate) {
+		minRate = minAdaptiveRate
+	}
+	l.minRate = math.Min(minRate, rate)
+	l.recovery = recovery
+	return l
+}
+
+// Rate returns the number of requests per second l currently lets
+// through.
+func (l *RateLimiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate(l.now())
+}
+
+func (l *RateLimiter) rate(now time.Time) float64 {
+	if l.throttledAt.IsZero() {
+		return l.maxRate
+	}
+	elapsed := now.Sub(l.throttledAt)
+	if elapsed >= l.recovery {
+		return l.maxRate
+	}
+	recovered := float64(elapsed) / float64(l.recovery)
+	return l.throttledRate + (l.maxRate-l.throttledRate)*recovered
+}
+
+// refill adds the tokens earned since the last call.
+func (l *RateLimiter) refill(now time.Time) {
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate(now)
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+}
+
+// Wait blocks until l lets one more request through, or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	now := l.now()
+	l.refill(now)
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate(now) * float64(time.Second))
+	}
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		// Give the reserved token back.
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Throttled tells an adaptive l that a request was throttled, halving its
+// rate. Throttles reported while the rate already dropped within the last
+// second are ignored, so that a burst of throttled requests slows l down
+// only once.
+func (l *RateLimiter) Throttled() {
+	if !l.adaptive {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if !l.throttledAt.IsZero() && now.Sub(l.throttledAt) < time.Second {
+		return
+	}
+	l.refill(now)
+	l.throttledRate = math.Max(l.rate(now)/2, l.minRate)
+	l.throttledAt = now
+}
+
+// Handlers returns the handlers applying l to every request of a client.
+func (l *RateLimiter) Handlers() Handlers {
+	return rateLimitHandlers(func(*Request) *RateLimiter { return l })
+}
+
+// RateLimits limits the requests of a client per operation.
+type RateLimits struct {
+	// Operations maps operation names, such as "RunInstances", to the
+	// limiter of their requests. Several operations may share a limiter.
+	Operations map[string]*RateLimiter
+
+	// Default limits the requests of all other operations. If nil,
+	// they are not limited.
+	Default *RateLimiter
+}
+
+// Handlers returns the handlers applying the limits to every request of a
+// client.
+func (l *RateLimits) Handlers() Handlers {
+	return rateLimitHandlers(func(r *Request) *RateLimiter {
+		if limiter, ok := l.Operations[r.Operation]; ok {
+			return limiter
+		}
+		return l.Default
+	})
+}
+
+func rateLimitHandlers(limiter func(*Request) *RateLimiter) Handlers {
+	var h Handlers
+	h.Build.PushBack(NamedHandler{Name: "core.RateLimit", Fn: func(r *Request) {
+		if l := limiter(r); l != nil {
+			r.Error = l.Wait(r.Context)
+		}
+	}})
+	h.Retry.PushBack(NamedHandler{Name: "core.RateLimitThrottled", Fn: func(r *Request) {
+		if l := limiter(r); l != nil && IsThrottle(r.Error) {
+			l.Throttled()
+		}
+	}})
+	return h
+}
@@ -0,0 +1,125 @@
+package aws_test
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/goamz/goamz/aws"
+	. "gopkg.in/check.v1"
+)
+
+func (s *S) TestRateLimiterWait(c *C) {
+	l := aws.NewRateLimiter(100, 2)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Check(l.Wait(context.Background()), IsNil)
+		}()
+	}
+	wg.Wait()
+	// The burst goes through at once, the next 10 at 100 per second.
+	elapsed := time.Since(start)
+	c.Assert(elapsed >= 90*time.Millisecond, Equals, true, Commentf("elapsed %v", elapsed))
+	c.Assert(elapsed < time.Second, Equals, true, Commentf("elapsed %v", elapsed))
+}
+
+func (s *S) TestRateLimiterWaitCanceled(c *C) {
+	l := aws.NewRateLimiter(1, 1)
+	c.Assert(l.Wait(context.Background()), IsNil)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	c.Assert(l.Wait(ctx), Equals, context.DeadlineExceeded)
+}
+
+func (s *S) TestAdaptiveRateLimiter(c *C) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := aws.NewAdaptiveRateLimiter(40, 1, 4, time.Minute)
+	l.SetClock(func() time.Time { return now })
+	c.Assert(l.Rate(), Equals, 40.0)
+
+	l.Throttled()
+	c.Assert(l.Rate(), Equals, 20.0)
+	// Throttles within a second of the last one are the same episode.
+	now = now.Add(500 * time.Millisecond)
+	l.Throttled()
+	c.Assert(l.Rate() < 20.2, Equals, true)
+
+	now = now.Add(500 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		l.Throttled()
+		now = now.Add(time.Second)
+	}
+	c.Assert(l.Rate() >= 4, Equals, true)
+	c.Assert(l.Rate() < 5, Equals, true)
+
+	// Recovery is linear, back to the configured rate.
+	now = now.Add(30 * time.Second)
+	c.Assert(l.Rate() > 20, Equals, true)
+	c.Assert(l.Rate() < 24, Equals, true)
+	now = now.Add(30 * time.Second)
+	c.Assert(l.Rate(), Equals, 40.0)
+
+	// Plain limiters ignore throttles.
+	plain := aws.NewRateLimiter(40, 1)
+	plain.Throttled()
+	c.Assert(plain.Rate(), Equals, 40.0)
+}
+
+func (s *S) TestRateLimiterInvalidRate(c *C) {
+	for _, rate := range []float64{0, -1, math.NaN()} {
+		c.Assert(func() { aws.NewRateLimiter(rate, 1) }, PanicMatches, "aws: non-positive rate limit .*")
+	}
+	c.Assert(func() { aws.NewAdaptiveRateLimiter(0, 1, 0, time.Minute) }, PanicMatches, "aws: non-positive rate limit .*")
+	c.Assert(func() { aws.NewAdaptiveRateLimiter(1, 1, 0, 0) }, PanicMatches, "aws: non-positive rate limit recovery 0s")
+
+	// A limiter throttled down to a zero minRate still lets requests
+	// through.
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := aws.NewAdaptiveRateLimiter(1, 1, 0, time.Hour)
+	l.SetClock(func() time.Time { return now })
+	for i := 0; i < 10; i++ {
+		l.Throttled()
+		now = now.Add(time.Second)
+	}
+	c.Assert(l.Rate() > 0, Equals, true)
+	c.Assert(l.Rate() < 0.11, Equals, true)
+}
+
+func (s *S) TestRateLimitsHandlers(c *C) {
+	var requests []*http.Request
+	ts := newQueryServer(c, 400, "<Response><Errors><Error><Code>Throttling</Code></Error></Errors></Response>", &requests)
+	defer ts.Close()
+
+	throttled := aws.NewAdaptiveRateLimiter(1000, 10, 1, time.Minute)
+	other := aws.NewAdaptiveRateLimiter(1000, 10, 1, time.Minute)
+	limits := aws.RateLimits{
+		Operations: map[string]*aws.RateLimiter{"RunInstances": throttled},
+		Default:    other,
+	}
+	h := aws.QueryHandlers()
+	h.Sign.Remove(aws.V4SignHandler.Name)
+	h.Retry.Remove(aws.RetryHandler.Name)
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("test.UnmarshalError", func(resp *http.Response) error {
+		return &aws.Error{StatusCode: resp.StatusCode, Code: "Throttling"}
+	}))
+	h.Append(limits.Handlers())
+
+	r := &aws.Request{Operation: "RunInstances", Method: "GET", Endpoint: ts.URL, Handlers: h}
+	c.Assert(aws.IsThrottle(r.Send()), Equals, true)
+	c.Assert(requests, HasLen, 1)
+	c.Assert(throttled.Rate() < 501, Equals, true)
+	c.Assert(other.Rate(), Equals, 1000.0)
+
+	// A canceled context fails the request before it is sent.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r = &aws.Request{Operation: "DescribeInstances", Method: "GET", Endpoint: ts.URL, Context: ctx, Handlers: h}
+	c.Assert(r.Send(), Equals, context.Canceled)
+	c.Assert(requests, HasLen, 1)
+}
@@ -0,0 +1,456 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request is a single API call as it travels through the request pipeline
+// shared by the service clients.
+//
+// Send runs the phases of its Handlers in order:
+//
+//	Build          creates HTTPRequest from the fields of the Request
+//	Sign           signs HTTPRequest
+//	Send           sends HTTPRequest and sets HTTPResponse
+//	Unmarshal      decodes a successful response into Result
+//	UnmarshalError decodes an error response into Error
+//	Retry          decides whether a failed attempt is tried again
+//	Complete       runs once when the call is over, whatever its outcome
+//
+// Build, Sign, Send and Unmarshal stop at the first handler that sets
+// Error. Build, Sign and Send run again for every retried attempt.
+type Request struct {
+	// Service is the name the service is signed with, such as "ec2".
+	Service string
+
+	// Operation is the name of the API action, such as
+	// "DescribeInstances".
+	Operation string
+
+	Region  Region
+	Auth    Auth
+	Context context.Context
+
+	// SigningRegion, if set, replaces the name of Region in Signature
+	// Version 4 signatures, as resolved by ResolveEndpoint.
+	SigningRegion string
+
+	// Method and Endpoint are the HTTP method and URL of the request.
+	Method   string
+	Endpoint string
+
+	// Params are the query parameters of the call. They are encoded in
+	// the URL of GET requests and in the body of POST requests, unless
+	// Payload is set.
+	Params map[string]string
+
+	// Header holds additional headers to send.
+	Header http.Header
+
+	// Payload is the body of requests not built from Params, such as
+	// JSON or XML documents.
+	Payload []byte
+
+	// HTTPClient sends the request. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	HTTPRequest  *http.Request
+	HTTPResponse *http.Response
+
+	// Result is the value a successful response is decoded into.
+	Result interface{}
+
+	// Stream leaves the body of a successful response unread in
+	// HTTPResponse, for the caller to read and close, instead of
+	// discarding it once unmarshalled.
+	Stream bool
+
+	// Error is the outcome of the call. A handler sets it to fail the
+	// current attempt.
+	Error error
+
+	// RetryCount is the number of attempts made before the current one.
+	RetryCount int
+
+	// Retryable is set by Retry handlers to try a failed attempt again,
+	// after waiting for RetryDelay.
+	Retryable  bool
+	RetryDelay time.Duration
+
+	// Idempotent marks requests that may safely be sent more than once,
+	// which RetryPolicy cannot tell from their operation or method.
+	Idempotent bool
+
+	// Clock gives the time the request is signed at. It is corrected
+	// when AWS rejects the request for being signed with a skewed clock,
+	// which is then retried once. Clients set it to their own Clock so
+	// that the correction outlives the request; if nil, Send sets it to
+	// a new Clock.
+	Clock *Clock
+
+	// Logger, if set, is given the lines selected by LogLevel about the
+	// attempts of the request. See LogHandler.
+	Logger   Logger
+	LogLevel LogLevel
+
+	// Instrumentation, if set, is told about every attempt of the
+	// request. See InstrumentHandler.
+	Instrumentation Instrumentation
+
+	Handlers Handlers
+
+	clockCorrected bool
+}
+
+// Handlers holds the handlers run for each phase of a Request.
+type Handlers struct {
+	Build          HandlerList
+	Sign           HandlerList
+	Send           HandlerList
+	Unmarshal      HandlerList
+	UnmarshalError HandlerList
+	Retry          HandlerList
+	Complete       HandlerList
+}
+
+// GlobalHandlers are run on requests of every service, after the handlers
+// of the service itself and before those of the client. They should be set
+// up before any request is made, for example:
+//
+//	aws.GlobalHandlers.Build.PushBack(aws.NamedHandler{
+//		Name: "example.UserAgent",
+//		Fn: func(r *aws.Request) {
+//			r.HTTPRequest.Header.Set("User-Agent", "example/1.0")
+//		},
+//	})
+var GlobalHandlers Handlers
+
+// Append adds the handlers of other to the end of each phase of h.
+func (h *Handlers) Append(other Handlers) {
+	h.Build.pushBack(other.Build.list...)
+	h.Sign.pushBack(other.Sign.list...)
+	h.Send.pushBack(other.Send.list...)
+	h.Unmarshal.pushBack(other.Unmarshal.list...)
+	h.UnmarshalError.pushBack(other.UnmarshalError.list...)
+	h.Retry.pushBack(other.Retry.list...)
+	h.Complete.pushBack(other.Complete.list...)
+}
+
+// ChainHandlers returns the handlers of each of lists, in order, for every
+// phase. Services build the handlers of a request with
+//
+//	aws.ChainHandlers(serviceHandlers, aws.GlobalHandlers, client.Handlers)
+func ChainHandlers(lists ...Handlers) Handlers {
+	var h Handlers
+	for _, l := range lists {
+		h.Append(l)
+	}
+	return h
+}
+
+// NamedHandler is a handler with a name it can be removed by.
+type NamedHandler struct {
+	Name string
+	Fn   func(*Request)
+}
+
+// HandlerList is an ordered list of handlers. Its zero value is an empty
+// list ready to use. Copying a HandlerList copies the list, so that
+// changing the copy leaves the original untouched.
+type HandlerList struct {
+	list []NamedHandler
+}
+
+// Len returns the number of handlers in l.
+func (l *HandlerList) Len() int {
+	return len(l.list)
+}
+
+// PushBack adds h to the end of l.
+func (l *HandlerList) PushBack(h NamedHandler) {
+	l.pushBack(h)
+}
+
+// PushFront adds h to the start of l.
+func (l *HandlerList) PushFront(h NamedHandler) {
+	list := make([]NamedHandler, 0, len(l.list)+1)
+	l.list = append(append(list, h), l.list...)
+}
+
+// Remove removes all handlers named name from l.
+func (l *HandlerList) Remove(name string) {
+	list := make([]NamedHandler, 0, len(l.list))
+	for _, h := range l.list {
+		if h.Name != name {
+			list = append(list, h)
+		}
+	}
+	l.list = list
+}
+
+// Run runs every handler of l on r.
+func (l *HandlerList) Run(r *Request) {
+	for _, h := range l.list {
+		h.Fn(r)
+	}
+}
+
+func (l *HandlerList) pushBack(hs ...NamedHandler) {
+	// Never append in place: copies of l share its array.
+	l.list = append(l.list[:len(l.list):len(l.list)], hs...)
+}
+
+func (l *HandlerList) runUntilError(r *Request) {
+	for _, h := range l.list {
+		if r.Error != nil {
+			return
+		}
+		h.Fn(r)
+	}
+}
+
+// Send runs r through the pipeline and returns its Error.
+func (r *Request) Send() error {
+	if r.Context == nil {
+		r.Context = context.Background()
+	}
+	if r.Clock == nil {
+		r.Clock = &Clock{}
+	}
+	for {
+		r.Error = nil
+		r.Retryable = false
+		r.HTTPRequest = nil
+		r.HTTPResponse = nil
+		attempt := StartAttempt(r.Service, r.Operation, r.Region, r.RetryCount+1)
+
+		r.Handlers.Build.runUntilError(r)
+		r.Handlers.Sign.runUntilError(r)
+		if r.Error == nil && r.Logger != nil {
+			logRequest(r.Logger, r.LogLevel, r.logPrefix(), r.HTTPRequest)
+			start := time.Now()
+			r.Handlers.Send.runUntilError(r)
+			logResponse(r.Logger, r.LogLevel, r.logPrefix(), r.HTTPResponse, r.Error, time.Since(start))
+		} else {
+			r.Handlers.Send.runUntilError(r)
+		}
+		var body *countingReader
+		if r.Error == nil {
+			streamed := r.Stream && r.HTTPResponse.StatusCode/100 == 2
+			if !streamed {
+				body = &countingReader{ReadCloser: r.HTTPResponse.Body}
+				r.HTTPResponse.Body = body
+			}
+			if r.HTTPResponse.StatusCode/100 == 2 {
+				r.Handlers.Unmarshal.runUntilError(r)
+				if streamed && r.Error != nil {
+					r.HTTPResponse.Body.Close()
+				}
+			} else {
+				r.Error = &Error{StatusCode: r.HTTPResponse.StatusCode, Message: r.HTTPResponse.Status}
+				r.Handlers.UnmarshalError.Run(r)
+			}
+		}
+		if body != nil {
+			io.Copy(ioutil.Discard, body)
+			body.Close()
+		}
+		if r.Instrumentation != nil {
+			attempt.finish(r.HTTPRequest, r.HTTPResponse, r.Error)
+			if body != nil {
+				attempt.BytesReceived = body.n
+			}
+			r.Instrumentation.AttemptDone(attempt)
+		}
+		if r.Error == nil || r.Context.Err() != nil {
+			break
+		}
+		r.Handlers.Retry.Run(r)
+		if r.Retryable {
+			r.logf(LogRetries, "%s retrying attempt %d after %v: %v", r.logPrefix(), r.RetryCount+1, r.RetryDelay, r.Error)
+		}
+		if !r.Retryable || !r.wait(r.RetryDelay) {
+			break
+		}
+		r.RetryCount++
+	}
+	r.Handlers.Complete.Run(r)
+	return r.Error
+}
+
+// correctClock corrects the Clock of r from the response of an attempt
+// rejected for being signed with a skewed clock, reporting whether the
+// attempt is to be retried with the corrected time. It does so once per
+// request.
+func (r *Request) correctClock() bool {
+	if r.clockCorrected || r.HTTPResponse == nil || !IsClockSkew(r.Error) {
+		return false
+	}
+	r.clockCorrected = true
+	return r.Clock.Correct(r.HTTPResponse)
+}
+
+// wait waits for d to elapse, and reports whether it did before the
+// context of r was done.
+func (r *Request) wait(d time.Duration) bool {
+	if d <= 0 {
+		return r.Context.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-r.Context.Done():
+		return false
+	}
+}
+
+// BuildHandler creates the HTTP request of r from its Method, Endpoint,
+// Params, Header and Payload.
+var BuildHandler = NamedHandler{Name: "core.Build", Fn: func(r *Request) {
+	u, err := url.Parse(r.Endpoint)
+	if err != nil {
+		r.Error = err
+		return
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	var body []byte
+	contentType := ""
+	switch {
+	case r.Payload != nil:
+		body = r.Payload
+	case r.Method == "POST":
+		body = []byte(multimap(r.Params).Encode())
+		contentType = "application/x-www-form-urlencoded; param=value"
+	case len(r.Params) > 0:
+		u.RawQuery = multimap(r.Params).Encode()
+	}
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	hreq, err := http.NewRequest(r.Method, u.String(), rd)
+	if err != nil {
+		r.Error = err
+		return
+	}
+	if contentType != "" {
+		hreq.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range r.Header {
+		hreq.Header[k] = append([]string(nil), v...)
+	}
+	r.HTTPRequest = hreq.WithContext(r.Context)
+}}
+
+// V2SignHandler returns a handler that signs the Params of query requests
+// with Signature Version 2, using the sign function of the service, and
+// encodes the signed parameters into the HTTP request. A Timestamp
+// parameter is set anew from the Clock of the request once corrected.
+func V2SignHandler(name string, sign func(auth Auth, method, path string, params map[string]string, host string)) NamedHandler {
+	return NamedHandler{Name: name, Fn: func(r *Request) {
+		auth, err := r.Auth.Resolve()
+		if err != nil {
+			r.Error = err
+			return
+		}
+		params := make(map[string]string, len(r.Params)+5)
+		for k, v := range r.Params {
+			params[k] = v
+		}
+		if _, ok := params["Timestamp"]; ok && r.Clock.Offset() != 0 {
+			params["Timestamp"] = r.Clock.Now().Format(time.RFC3339)
+		}
+		hreq := r.HTTPRequest
+		sign(auth, hreq.Method, hreq.URL.Path, params, hreq.URL.Host)
+		if r.LogLevel&LogSigning != 0 {
+			r.logf(LogSigning, "%s string to sign:\n%s", r.logPrefix(), RedactSigned(v2StringToSign(hreq.Method, hreq.URL.Host, hreq.URL.Path, params)))
+		}
+		encoded := multimap(params).Encode()
+		if hreq.Method == "POST" {
+			hreq.Body = ioutil.NopCloser(strings.NewReader(encoded))
+			hreq.GetBody = func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(strings.NewReader(encoded)), nil
+			}
+			hreq.ContentLength = int64(len(encoded))
+			hreq.Header.Set("Content-Length", strconv.Itoa(len(encoded)))
+		} else {
+			hreq.URL.RawQuery = encoded
+		}
+	}}
+}
+
+// V4SignHandler signs the HTTP request with Signature Version 4 for the
+// Service and Region, or SigningRegion, of the request, at the time of its
+// Clock.
+var V4SignHandler = NamedHandler{Name: "core.V4Sign", Fn: func(r *Request) {
+	auth, err := r.Auth.Resolve()
+	if err != nil {
+		r.Error = err
+		return
+	}
+	if token := auth.Token(); token != "" {
+		r.HTTPRequest.Header.Set("X-Amz-Security-Token", token)
+	}
+	region := r.Region
+	if r.SigningRegion != "" {
+		region.Name = r.SigningRegion
+	}
+	signer := NewV4Signer(auth, r.Service, region)
+	signer.Clock = r.Clock
+	if r.LogLevel&LogSigning != 0 {
+		signer.Logger = r.Logger
+	}
+	signer.Sign(r.HTTPRequest)
+}}
+
+// SendHandler sends the HTTP request with the HTTPClient of the request.
+var SendHandler = NamedHandler{Name: "core.Send", Fn: func(r *Request) {
+	client := r.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r.HTTPResponse, r.Error = client.Do(r.HTTPRequest)
+}}
+
+// UnmarshalXMLHandler decodes the XML body of a response into the Result
+// of the request, if set.
+var UnmarshalXMLHandler = NamedHandler{Name: "core.UnmarshalXML", Fn: func(r *Request) {
+	if r.Result != nil {
+		r.Error = xml.NewDecoder(r.HTTPResponse.Body).Decode(r.Result)
+	}
+}}
+
+// QueryHandlers returns the handlers of services using the query protocol
+// and Signature Version 4, retrying requests with DefaultRetryPolicy.
+// Services signing with Version 2 replace the Sign handlers, and all set
+// their own UnmarshalError handler.
+func QueryHandlers() Handlers {
+	var h Handlers
+	h.Build.PushBack(BuildHandler)
+	h.Sign.PushBack(V4SignHandler)
+	h.Send.PushBack(SendHandler)
+	h.Unmarshal.PushBack(UnmarshalXMLHandler)
+	h.Retry.PushBack(RetryHandler)
+	return h
+}
+
+// UnmarshalErrorHandler returns a handler that sets the Error of requests
+// that failed to the error decoded from the response by buildError.
+func UnmarshalErrorHandler(name string, buildError func(*http.Response) error) NamedHandler {
+	return NamedHandler{Name: name, Fn: func(r *Request) {
+		r.Error = buildError(r.HTTPResponse)
+	}}
+}
@@ -0,0 +1,262 @@
+package aws_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/goamz/goamz/aws"
+	. "gopkg.in/check.v1"
+)
+
+type queryResult struct {
+	Value string `xml:"Value"`
+}
+
+func newQueryServer(c *C, status int, body string, requests *[]*http.Request) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		c.Check(req.ParseForm(), IsNil)
+		*requests = append(*requests, req)
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+}
+
+func record(name string, calls *[]string) aws.NamedHandler {
+	return aws.NamedHandler{Name: name, Fn: func(r *aws.Request) {
+		*calls = append(*calls, name)
+	}}
+}
+
+func (s *S) TestRequestSend(c *C) {
+	var requests []*http.Request
+	ts := newQueryServer(c, 200, "<Response><Value>ok</Value></Response>", &requests)
+	defer ts.Close()
+
+	var result queryResult
+	r := &aws.Request{
+		Service:   "ec2",
+		Operation: "Test",
+		Region:    aws.USEast,
+		Auth:      aws.Auth{AccessKey: "access", SecretKey: "secret"},
+		Method:    "POST",
+		Endpoint:  ts.URL,
+		Params:    map[string]string{"Action": "Test", "Name": "a b"},
+		Header:    http.Header{"X-Extra": {"extra"}},
+		Result:    &result,
+		Handlers:  aws.QueryHandlers(),
+	}
+	c.Assert(r.Send(), IsNil)
+	c.Assert(result.Value, Equals, "ok")
+
+	c.Assert(requests, HasLen, 1)
+	req := requests[0]
+	c.Assert(req.URL.Path, Equals, "/")
+	c.Assert(req.PostForm.Get("Name"), Equals, "a b")
+	c.Assert(req.Header.Get("X-Extra"), Equals, "extra")
+	c.Assert(req.Header.Get("Authorization"), Matches, "AWS4-HMAC-SHA256 Credential=access/[0-9]+/us-east-1/ec2/aws4_request, .*")
+}
+
+func (s *S) TestRequestHandlerOrder(c *C) {
+	var requests []*http.Request
+	ts := newQueryServer(c, 200, "", &requests)
+	defer ts.Close()
+
+	var calls []string
+	var service, client aws.Handlers
+	service.Build.PushBack(aws.BuildHandler)
+	service.Send.PushBack(aws.SendHandler)
+	service.Send.PushBack(record("service.Send", &calls))
+	service.Complete.PushBack(record("service.Complete", &calls))
+	client.Build.PushBack(record("client.Build", &calls))
+	client.Send.PushFront(record("client.Send", &calls))
+	client.Complete.PushBack(record("client.Complete", &calls))
+
+	saved := aws.GlobalHandlers
+	defer func() { aws.GlobalHandlers = saved }()
+	aws.GlobalHandlers.Build.PushBack(aws.NamedHandler{Name: "global.Header", Fn: func(r *aws.Request) {
+		r.HTTPRequest.Header.Set("X-Global", "yes")
+	}})
+	aws.GlobalHandlers.Build.PushBack(record("global.Build", &calls))
+
+	r := &aws.Request{
+		Method:   "GET",
+		Endpoint: ts.URL,
+		Handlers: aws.ChainHandlers(service, aws.GlobalHandlers, client),
+	}
+	c.Assert(r.Send(), IsNil)
+	c.Assert(calls, DeepEquals, []string{
+		"global.Build", "client.Build", "service.Send", "client.Send", "service.Complete", "client.Complete",
+	})
+	c.Assert(requests, HasLen, 1)
+	c.Assert(requests[0].Header.Get("X-Global"), Equals, "yes")
+
+	// Chaining copies the lists.
+	c.Assert(service.Build.Len(), Equals, 1)
+	c.Assert(aws.GlobalHandlers.Build.Len(), Equals, 2)
+}
+
+func (s *S) TestRequestErrorStopsPhase(c *C) {
+	var calls []string
+	var h aws.Handlers
+	h.Build.PushBack(aws.NamedHandler{Name: "fail", Fn: func(r *aws.Request) {
+		r.Error = errors.New("build failed")
+	}})
+	h.Build.PushBack(record("Build", &calls))
+	h.Sign.PushBack(record("Sign", &calls))
+	h.Send.PushBack(record("Send", &calls))
+	h.Retry.PushBack(record("Retry", &calls))
+	h.Complete.PushBack(record("Complete", &calls))
+
+	r := &aws.Request{Handlers: h}
+	c.Assert(r.Send(), ErrorMatches, "build failed")
+	c.Assert(calls, DeepEquals, []string{"Retry", "Complete"})
+}
+
+func (s *S) TestRequestUnmarshalError(c *C) {
+	var requests []*http.Request
+	ts := newQueryServer(c, 400, "<Error><Code>Bad</Code></Error>", &requests)
+	defer ts.Close()
+
+	h := aws.QueryHandlers()
+	h.Sign.Remove(aws.V4SignHandler.Name)
+	r := &aws.Request{Method: "GET", Endpoint: ts.URL, Handlers: h}
+	err := r.Send()
+	c.Assert(err, FitsTypeOf, &aws.Error{})
+	c.Assert(err.(*aws.Error).StatusCode, Equals, 400)
+
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("test.UnmarshalError", func(resp *http.Response) error {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}))
+	r = &aws.Request{Method: "GET", Endpoint: ts.URL, Handlers: h}
+	c.Assert(r.Send(), ErrorMatches, "status 400")
+}
+
+func (s *S) TestRequestRetry(c *C) {
+	var requests []*http.Request
+	ts := newQueryServer(c, 500, "", &requests)
+	defer ts.Close()
+
+	var h aws.Handlers
+	h.Build.PushBack(aws.BuildHandler)
+	h.Sign.PushBack(aws.V2SignHandler("test.Sign", func(auth aws.Auth, method, path string, params map[string]string, host string) {
+		params["Signature"] = strings.Join([]string{method, host, path}, " ")
+	}))
+	h.Send.PushBack(aws.SendHandler)
+	h.Retry.PushBack(aws.NamedHandler{Name: "test.Retry", Fn: func(r *aws.Request) {
+		r.Retryable = r.RetryCount < 2
+	}})
+	params := map[string]string{"Action": "Test"}
+	r := &aws.Request{Method: "GET", Endpoint: ts.URL, Params: params, Handlers: h}
+	c.Assert(r.Send(), ErrorMatches, ".*500 Internal Server Error")
+	c.Assert(r.RetryCount, Equals, 2)
+	c.Assert(requests, HasLen, 3)
+	for _, req := range requests {
+		c.Assert(req.Form.Get("Signature"), Equals, "GET "+req.Host+" /")
+	}
+	// The parameters of the caller are left untouched.
+	c.Assert(params, DeepEquals, map[string]string{"Action": "Test"})
+}
+
+func (s *S) TestRequestClockSkew(c *C) {
+	var dates []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		dates = append(dates, req.Header.Get("X-Amz-Date"))
+		if len(dates) == 1 {
+			w.Header().Set("Date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+			w.WriteHeader(403)
+		}
+	}))
+	defer ts.Close()
+
+	h := aws.QueryHandlers()
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("test.UnmarshalError", func(resp *http.Response) error {
+		return &aws.Error{Code: "RequestTimeTooSkewed", StatusCode: resp.StatusCode}
+	}))
+	clock := &aws.Clock{}
+	r := &aws.Request{
+		Service:  "ec2",
+		Region:   aws.USEast,
+		Auth:     aws.Auth{AccessKey: "access", SecretKey: "secret"},
+		Method:   "GET",
+		Endpoint: ts.URL,
+		Clock:    clock,
+		Handlers: h,
+	}
+	c.Assert(r.Send(), IsNil)
+	c.Assert(r.RetryCount, Equals, 1)
+	c.Assert(dates, HasLen, 2)
+	first, err := time.Parse(aws.ISO8601BasicFormat, dates[0])
+	c.Assert(err, IsNil)
+	second, err := time.Parse(aws.ISO8601BasicFormat, dates[1])
+	c.Assert(err, IsNil)
+	c.Assert(second.Sub(first) > 59*time.Minute, Equals, true)
+
+	// The correction outlives the request.
+	c.Assert(clock.Offset() > 59*time.Minute, Equals, true)
+}
+
+func (s *S) TestRequestClockSkewRetriedOnce(c *C) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.Header().Set("Date", time.Now().Add(time.Duration(requests)*time.Hour).UTC().Format(http.TimeFormat))
+		w.WriteHeader(403)
+	}))
+	defer ts.Close()
+
+	h := aws.QueryHandlers()
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("test.UnmarshalError", func(resp *http.Response) error {
+		return &aws.Error{Code: "RequestTimeTooSkewed", StatusCode: resp.StatusCode}
+	}))
+	h.Retry.Remove(aws.RetryHandler.Name)
+	h.Retry.PushBack(aws.RetryPolicy{MaxAttempts: 5}.Handler())
+	r := &aws.Request{
+		Service:  "ec2",
+		Region:   aws.USEast,
+		Auth:     aws.Auth{AccessKey: "access", SecretKey: "secret"},
+		Method:   "GET",
+		Endpoint: ts.URL,
+		Handlers: h,
+	}
+	err := r.Send()
+	c.Assert(aws.IsClockSkew(err), Equals, true)
+	// The clock is corrected once, then the policy retries as usual.
+	c.Assert(requests, Equals, 5)
+	offset := r.Clock.Offset()
+	c.Assert(offset > 59*time.Minute && offset < 61*time.Minute, Equals, true)
+}
+
+func (s *S) TestClockCorrect(c *C) {
+	var nilClock *aws.Clock
+	c.Assert(nilClock.Offset(), Equals, time.Duration(0))
+	c.Assert(nilClock.Now().Sub(time.Now()) < time.Second, Equals, true)
+
+	resp := &http.Response{Header: http.Header{}}
+	c.Assert(nilClock.Correct(resp), Equals, false)
+
+	clock := &aws.Clock{}
+	c.Assert(clock.Correct(resp), Equals, false)
+	resp.Header.Set("Date", time.Now().Add(-10*time.Minute).UTC().Format(http.TimeFormat))
+	c.Assert(clock.Correct(resp), Equals, true)
+	c.Assert(clock.Offset() <= -9*time.Minute, Equals, true)
+	c.Assert(clock.Now().Before(time.Now().Add(-9*time.Minute)), Equals, true)
+	// Corrections below MinClockSkew are ignored.
+	c.Assert(clock.Correct(resp), Equals, false)
+}
+
+func (s *S) TestHandlerListRemove(c *C) {
+	var calls []string
+	var l aws.HandlerList
+	l.PushBack(record("a", &calls))
+	l.PushBack(record("b", &calls))
+	l.PushFront(record("c", &calls))
+	copied := l
+	copied.Remove("a")
+	l.Run(&aws.Request{})
+	copied.Run(&aws.Request{})
+	c.Assert(calls, DeepEquals, []string{"c", "a", "b", "c", "b"})
+}
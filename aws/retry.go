@@ -0,0 +1,150 @@
+package aws
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetryPolicy decides which failed requests are sent again, and how long
+// to wait before doing so.
+//
+// Requests that AWS rejected without acting upon them, because they were
+// throttled, signed with a skewed clock or never reached AWS, are always
+// retried. Requests that failed for other transient reasons, such as
+// server errors and broken connections, are only retried when they are
+// idempotent: their Idempotent field is set, they carry a ClientToken,
+// their operation only reads (Describe*, List*, Get*...), or they are REST
+// requests with an idempotent HTTP method.
+//
+// Requests rejected for being signed with a skewed clock are first retried
+// at once, a single time, after correcting their Clock from the Date of
+// the response.
+//
+// The delay before each retry is drawn at random between zero and an
+// exponentially growing ceiling ("full jitter"), so that clients failing
+// together do not retry together.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a request is sent,
+	// including the first. Values below 2 disable retries.
+	MaxAttempts int
+
+	// BaseDelay is the ceiling of the delay before the first retry. The
+	// ceiling doubles with every retry.
+	BaseDelay time.Duration
+
+	// ThrottleBaseDelay replaces BaseDelay for throttled requests.
+	ThrottleBaseDelay time.Duration
+
+	// MaxDelay caps the ceiling of the delay.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is the policy applied by the RetryHandler of every
+// service. It should be changed before any request is made. To change the
+// policy of a single client, add the Handler of another policy to its
+// Retry handlers; it overrides the decision of DefaultRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:       3,
+	BaseDelay:         100 * time.Millisecond,
+	ThrottleBaseDelay: 500 * time.Millisecond,
+	MaxDelay:          20 * time.Second,
+}
+
+// NoRetryPolicy never retries requests.
+var NoRetryPolicy = RetryPolicy{MaxAttempts: 1}
+
+// RetryHandler applies DefaultRetryPolicy to failed requests.
+var RetryHandler = NamedHandler{Name: "core.Retry", Fn: func(r *Request) {
+	DefaultRetryPolicy.retry(r)
+}}
+
+// Handler returns a Retry handler applying p to failed requests.
+func (p RetryPolicy) Handler() NamedHandler {
+	return NamedHandler{Name: "core.Retry", Fn: p.retry}
+}
+
+func (p RetryPolicy) retry(r *Request) {
+	if p.MaxAttempts > 1 && r.correctClock() {
+		r.Retryable, r.RetryDelay = true, 0
+		return
+	}
+	r.Retryable = p.ShouldRetry(r)
+	r.RetryDelay = 0
+	if r.Retryable {
+		r.RetryDelay = p.Delay(r)
+	}
+}
+
+// ShouldRetry reports whether r, which failed with r.Error, is to be sent
+// again.
+func (p RetryPolicy) ShouldRetry(r *Request) bool {
+	if r.RetryCount+1 >= p.MaxAttempts {
+		return false
+	}
+	err := r.Error
+	if IsThrottle(err) || IsClockSkew(err) || notSent(err) {
+		return true
+	}
+	return IsRetryable(err) && isIdempotent(r)
+}
+
+// Delay returns how long to wait before retrying r.
+func (p RetryPolicy) Delay(r *Request) time.Duration {
+	ceiling := p.BaseDelay
+	if IsThrottle(r.Error) && p.ThrottleBaseDelay > 0 {
+		ceiling = p.ThrottleBaseDelay
+	}
+	for i := 0; i < r.RetryCount && ceiling < p.MaxDelay; i++ {
+		ceiling *= 2
+	}
+	if p.MaxDelay > 0 && ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	randMu.Lock()
+	defer randMu.Unlock()
+	return time.Duration(random.Int63n(int64(ceiling) + 1))
+}
+
+var (
+	randMu sync.Mutex
+	random = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// notSent reports whether err shows that the request never reached AWS.
+func notSent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// Operations whose name starts with one of these prefixes only read.
+var readOnlyPrefixes = []string{"Describe", "List", "Get", "Head", "Query", "Scan", "BatchGet"}
+
+func isIdempotent(r *Request) bool {
+	if r.Idempotent || r.Params["ClientToken"] != "" {
+		return true
+	}
+	if r.Operation != "" {
+		for _, prefix := range readOnlyPrefixes {
+			if strings.HasPrefix(r.Operation, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	switch r.Method {
+	case "GET", "HEAD", "PUT", "DELETE", "OPTIONS":
+		return true
+	}
+	return false
+}
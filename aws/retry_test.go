@@ -0,0 +1,94 @@
+package aws_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/goamz/goamz/aws"
+	. "gopkg.in/check.v1"
+)
+
+func (s *S) TestRetryPolicyShouldRetry(c *C) {
+	p := aws.RetryPolicy{MaxAttempts: 3}
+	dialErr := &url.Error{Op: "Post", URL: "http://localhost", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+	readErr := &url.Error{Op: "Post", URL: "http://localhost", Err: &net.OpError{Op: "read", Err: errors.New("connection reset")}}
+	tests := []struct {
+		r     aws.Request
+		retry bool
+	}{
+		// Requests AWS did not act upon are always retried.
+		{aws.Request{Operation: "RunInstances", Error: &aws.Error{StatusCode: 503, Code: "RequestLimitExceeded"}}, true},
+		{aws.Request{Operation: "PutItem", Error: &aws.Error{StatusCode: 400, Code: "ProvisionedThroughputExceededException"}}, true},
+		{aws.Request{Operation: "RunInstances", Error: &aws.Error{StatusCode: 400, Code: "RequestExpired"}}, true},
+		{aws.Request{Operation: "RunInstances", Error: dialErr}, true},
+		// Other transient failures only when idempotent.
+		{aws.Request{Operation: "RunInstances", Error: &aws.Error{StatusCode: 500, Code: "InternalError"}}, false},
+		{aws.Request{Operation: "RunInstances", Params: map[string]string{"ClientToken": "t"}, Error: &aws.Error{StatusCode: 500}}, true},
+		{aws.Request{Operation: "CreateQueue", Idempotent: true, Error: readErr}, true},
+		{aws.Request{Operation: "CreateQueue", Error: readErr}, false},
+		{aws.Request{Operation: "DescribeInstances", Error: &aws.Error{StatusCode: 500}}, true},
+		{aws.Request{Method: "PUT", Error: &aws.Error{StatusCode: 503}}, true},
+		{aws.Request{Method: "POST", Error: &aws.Error{StatusCode: 503}}, false},
+		// Permanent failures never.
+		{aws.Request{Operation: "DescribeInstances", Error: &aws.Error{StatusCode: 400, Code: "InvalidParameterValue"}}, false},
+		{aws.Request{Operation: "DescribeInstances", Error: context.Canceled}, false},
+		// Nor once attempts are exhausted.
+		{aws.Request{Operation: "DescribeInstances", RetryCount: 2, Error: &aws.Error{StatusCode: 500}}, false},
+	}
+	for i, t := range tests {
+		c.Check(p.ShouldRetry(&t.r), Equals, t.retry, Commentf("test %d: %v", i, t.r.Error))
+	}
+	r := &aws.Request{Operation: "DescribeInstances", Error: &aws.Error{StatusCode: 500}}
+	c.Check(aws.NoRetryPolicy.ShouldRetry(r), Equals, false)
+}
+
+func (s *S) TestRetryPolicyDelay(c *C) {
+	p := aws.RetryPolicy{
+		BaseDelay:         10 * time.Millisecond,
+		ThrottleBaseDelay: 50 * time.Millisecond,
+		MaxDelay:          100 * time.Millisecond,
+	}
+	check := func(r *aws.Request, ceiling time.Duration) {
+		for i := 0; i < 50; i++ {
+			d := p.Delay(r)
+			c.Assert(d >= 0 && d <= ceiling, Equals, true, Commentf("delay %v above %v", d, ceiling))
+		}
+	}
+	check(&aws.Request{Error: &aws.Error{StatusCode: 500}}, 10*time.Millisecond)
+	check(&aws.Request{RetryCount: 2, Error: &aws.Error{StatusCode: 500}}, 40*time.Millisecond)
+	check(&aws.Request{RetryCount: 1, Error: &aws.Error{Code: "Throttling"}}, 100*time.Millisecond)
+	check(&aws.Request{RetryCount: 30, Error: &aws.Error{StatusCode: 500}}, 100*time.Millisecond)
+}
+
+func (s *S) TestRequestRetryPolicy(c *C) {
+	var requests []*http.Request
+	ts := newQueryServer(c, 503, "<Response><Errors><Error><Code>Throttling</Code></Error></Errors></Response>", &requests)
+	defer ts.Close()
+
+	h := aws.QueryHandlers()
+	h.Sign.Remove(aws.V4SignHandler.Name)
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("test.UnmarshalError", func(resp *http.Response) error {
+		return &aws.Error{StatusCode: resp.StatusCode, Code: "Throttling"}
+	}))
+	h.Retry.PushBack(aws.RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond}.Handler())
+	r := &aws.Request{Operation: "CreateThing", Method: "POST", Endpoint: ts.URL, Handlers: h}
+	err := r.Send()
+	c.Assert(aws.IsThrottle(err), Equals, true)
+	c.Assert(requests, HasLen, 4)
+	c.Assert(r.RetryCount, Equals, 3)
+
+	// Retries stop with the context.
+	requests = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	h.Retry.PushBack(aws.NamedHandler{Name: "test.Cancel", Fn: func(r *aws.Request) {
+		r.RetryDelay = time.Hour
+		cancel()
+	}})
+	r = &aws.Request{Operation: "CreateThing", Method: "POST", Endpoint: ts.URL, Context: ctx, Handlers: h}
+	c.Assert(aws.IsThrottle(r.Send()), Equals, true)
+	c.Assert(requests, HasLen, 1)
+}
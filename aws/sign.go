@@ -6,11 +6,13 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -19,6 +21,9 @@ type V2Signer struct {
 	auth    Auth
 	service ServiceInfo
 	host    string
+
+	// Clock, if set, gives the Timestamp parameter of signed requests.
+	Clock *Clock
 }
 
 var b64 = base64.StdEncoding
@@ -35,30 +40,41 @@ func (s *V2Signer) Sign(method, path string, params map[string]string) {
 	params["AWSAccessKeyId"] = s.auth.AccessKey
 	params["SignatureVersion"] = "2"
 	params["SignatureMethod"] = "HmacSHA256"
+	if s.Clock != nil {
+		params["Timestamp"] = s.Clock.Now().Format(time.RFC3339)
+	}
 	if s.auth.Token() != "" {
 		params["SecurityToken"] = s.auth.Token()
 	}
 
+	payload := v2StringToSign(method, s.host, path, params)
+	hash := hmac.New(sha256.New, []byte(s.auth.SecretKey))
+	hash.Write([]byte(payload))
+	signature := make([]byte, b64.EncodedLen(hash.Size()))
+	b64.Encode(signature, hash.Sum(nil))
+
+	params["Signature"] = string(signature)
+}
+
+// v2StringToSign returns the string Signature Version 2 signs for a
+// request with params, leaving any previous Signature out.
+func v2StringToSign(method, host, path string, params map[string]string) string {
 	// AWS specifies that the parameters in a signed request must
 	// be provided in the natural order of the keys. This is distinct
 	// from the natural order of the encoded value of key=value.
 	// Percent and Equals affect the sorting order.
 	var keys, sarray []string
-	for k, _ := range params {
-		keys = append(keys, k)
+	for k := range params {
+		if k != "Signature" {
+			keys = append(keys, k)
+		}
 	}
 	sort.Strings(keys)
 	for _, k := range keys {
 		sarray = append(sarray, Encode(k)+"="+Encode(params[k]))
 	}
 	joined := strings.Join(sarray, "&")
-	payload := method + "\n" + s.host + "\n" + path + "\n" + joined
-	hash := hmac.New(sha256.New, []byte(s.auth.SecretKey))
-	hash.Write([]byte(payload))
-	signature := make([]byte, b64.EncodedLen(hash.Size()))
-	b64.Encode(signature, hash.Sum(nil))
-
-	params["Signature"] = string(signature)
+	return method + "\n" + host + "\n" + path + "\n" + joined
 }
 
 // Common date formats for signing requests
@@ -67,30 +83,33 @@ const (
 	ISO8601BasicFormatShort = "20060102"
 )
 
+// Special values of the X-Amz-Content-Sha256 header that V4Signer uses in
+// place of the payload hash when set on a request.
+const (
+	// UnsignedPayload leaves the payload out of the signature.
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+	// StreamingPayload marks a payload signed chunk by chunk, as done
+	// by V4Signer.SignStreaming.
+	StreamingPayload = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+)
+
 type Route53Signer struct {
 	auth Auth
+
+	// Clock gives the date of requests. Route 53 rejects requests
+	// dated more than five minutes away from its own time; clients
+	// correct Clock from the Date of those responses.
+	Clock *Clock
 }
 
 func NewRoute53Signer(auth Auth) *Route53Signer {
 	return &Route53Signer{auth: auth}
 }
 
-// getCurrentDate fetches the date stamp from the aws servers to
-// ensure the auth headers are within 5 minutes of the server time
-func (s *Route53Signer) getCurrentDate() string {
-	response, err := http.Get("https://route53.amazonaws.com/date")
-	if err != nil {
-		fmt.Print("Unable to get date from amazon: ", err)
-		return ""
-	}
-
-	response.Body.Close()
-	return response.Header.Get("Date")
-}
-
 // Creates the authorize signature based on the date stamp and secret key
-func (s *Route53Signer) getHeaderAuthorize(message string) string {
-	hmacSha256 := hmac.New(sha256.New, []byte(s.auth.SecretKey))
+func getHeaderAuthorize(auth Auth, message string) string {
+	hmacSha256 := hmac.New(sha256.New, []byte(auth.SecretKey))
 	hmacSha256.Write([]byte(message))
 	cryptedString := hmacSha256.Sum(nil)
 
@@ -98,16 +117,29 @@ func (s *Route53Signer) getHeaderAuthorize(message string) string {
 }
 
 // Adds all the required headers for AWS Route53 API to the request
-// including the authorization
+// including the authorization, dated by the signer's Clock. If the
+// signer's credentials can't be resolved the request is left unsigned;
+// SignRequest returns the error instead.
 func (s *Route53Signer) Sign(req *http.Request) {
-	date := s.getCurrentDate()
+	s.SignRequest(req)
+}
+
+// SignRequest signs req like Sign, and returns the error resolving the
+// signer's credentials, if any.
+func (s *Route53Signer) SignRequest(req *http.Request) error {
+	auth, err := s.auth.Resolve()
+	if err != nil {
+		return err
+	}
+	date := s.Clock.Now().Format(http.TimeFormat)
 	authHeader := fmt.Sprintf("AWS3-HTTPS AWSAccessKeyId=%s,Algorithm=%s,Signature=%s",
-		s.auth.AccessKey, "HmacSHA256", s.getHeaderAuthorize(date))
+		auth.AccessKey, "HmacSHA256", getHeaderAuthorize(auth, date))
 
 	req.Header.Set("Host", req.Host)
 	req.Header.Set("X-Amzn-Authorization", authHeader)
 	req.Header.Set("X-Amz-Date", date)
 	req.Header.Set("Content-Type", "application/xml")
+	return nil
 }
 
 /*
@@ -118,6 +150,13 @@ type V4Signer struct {
 	auth        Auth
 	serviceName string
 	region      Region
+
+	// Clock gives the time of requests not carrying a date.
+	Clock *Clock
+
+	// Logger, if set, is given the canonical request and the string to
+	// sign of every signature, with credentials redacted.
+	Logger Logger
 }
 
 /*
@@ -135,19 +174,167 @@ or "date" header was not available in the original request. In addition, AWS Sig
 the "host" header to be a signed header, therefor the Sign method will manually set a "host" header from
 the request.Host.
 
+The payload is hashed by reading the request body, unless an "x-amz-content-sha256" header
+already holds its hash or one of UnsignedPayload and StreamingPayload.
+
 The signed request will include a new "Authorization" header indicating that the request has been signed.
 
 Any changes to the request after signing the request will invalidate the signature.
 */
 func (s *V4Signer) Sign(req *http.Request) {
+	s.sign(req)
+}
+
+func (s *V4Signer) sign(req *http.Request) (time.Time, string) {
+	req.Header.Del("Authorization")                   // a previous signature must not be signed
 	req.Header.Set("host", req.Host)                  // host header must be included as a signed header
 	t := s.requestTime(req)                           // Get requst time
 	creq := s.canonicalRequest(req)                   // Build canonical request
 	sts := s.stringToSign(t, creq)                    // Build string to sign
+	s.log(creq, sts)                                  // Log them if asked to
 	signature := s.signature(t, sts)                  // Calculate the AWS Signature Version 4
 	auth := s.authorization(req.Header, t, signature) // Create Authorization header value
 	req.Header.Set("Authorization", auth)             // Add Authorization header to request
-	return
+	return t, signature
+}
+
+/*
+Presign signs a request through its query string rather than its headers, so that its URL
+can be handed to and used by anyone until it expires. The X-Amz-Algorithm, X-Amz-Credential,
+X-Amz-Date, X-Amz-Expires, X-Amz-SignedHeaders and X-Amz-Signature parameters are added to
+the request URL, along with X-Amz-Security-Token when the credentials carry a token.
+
+The request time is taken from an "x-amz-date" header, which is then removed, or is the
+current time. The headers of the request are signed and must be sent along with the URL. The
+payload is not signed for S3 and is expected to be empty for other services.
+*/
+func (s *V4Signer) Presign(req *http.Request, expires time.Duration) {
+	t := s.Clock.Now()
+	if date := req.Header.Get("x-amz-date"); date != "" {
+		if parsed, err := time.Parse(ISO8601BasicFormat, date); err == nil {
+			t = parsed
+		}
+		req.Header.Del("x-amz-date")
+	}
+	req.Header.Del("Authorization")
+	req.Header.Set("host", req.Host)
+
+	query := req.URL.Query()
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.auth.AccessKey+"/"+s.credentialScope(t))
+	query.Set("X-Amz-Date", t.Format(ISO8601BasicFormat))
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", s.signedHeaders(req.Header))
+	if token := s.auth.Token(); token != "" {
+		query.Set("X-Amz-Security-Token", token)
+	}
+	req.URL.RawQuery = query.Encode()
+
+	payloadHash := s.hash("")
+	if s.serviceName == "s3" {
+		payloadHash = UnsignedPayload
+	}
+	creq := s.canonicalRequestWithPayload(req, payloadHash)
+	sts := s.stringToSign(t, creq)
+	s.log(creq, sts)
+	signature := s.signature(t, sts)
+	req.URL.RawQuery += "&X-Amz-Signature=" + signature
+}
+
+/*
+SignStreaming signs a request whose body is sent in chunks of chunkSize bytes, each carrying
+its own signature chained to the previous one (STREAMING-AWS4-HMAC-SHA256-PAYLOAD). Unlike
+Sign, it does not need to read the body beforehand to hash it, so the body may be a stream
+that can only be read once.
+
+req.ContentLength must be set to the length of the body. The request's body and
+ContentLength are replaced by those of the chunk-encoded body.
+*/
+func (s *V4Signer) SignStreaming(req *http.Request, chunkSize int) {
+	req.Header.Set("x-amz-content-sha256", StreamingPayload)
+	req.Header.Set("x-amz-decoded-content-length", strconv.FormatInt(req.ContentLength, 10))
+	if enc := req.Header.Get("content-encoding"); enc == "" {
+		req.Header.Set("content-encoding", "aws-chunked")
+	} else if !strings.HasPrefix(enc, "aws-chunked") {
+		req.Header.Set("content-encoding", "aws-chunked,"+enc)
+	}
+	req.ContentLength = chunkedLength(req.ContentLength, int64(chunkSize))
+	req.Header.Set("content-length", strconv.FormatInt(req.ContentLength, 10))
+
+	t, signature := s.sign(req)
+	body := req.Body
+	if body == nil {
+		body = ioutil.NopCloser(strings.NewReader(""))
+	}
+	req.Body = &chunkedReader{
+		body:      body,
+		signer:    s,
+		t:         t,
+		signature: signature,
+		chunk:     make([]byte, chunkSize),
+	}
+}
+
+// chunkedLength returns the length of a body of n bytes once encoded in
+// signed chunks of size bytes.
+func chunkedLength(n, size int64) int64 {
+	chunk := func(n int64) int64 {
+		return int64(len(strconv.FormatInt(n, 16))) + int64(len(";chunk-signature=")) + 64 + 2 + n + 2
+	}
+	length := n / size * chunk(size)
+	if n%size != 0 {
+		length += chunk(n % size)
+	}
+	return length + chunk(0)
+}
+
+// chunkedReader encodes the body of a request in signed chunks as it is
+// read, ending with the empty chunk.
+type chunkedReader struct {
+	body      io.ReadCloser
+	signer    *V4Signer
+	t         time.Time
+	signature string
+	chunk     []byte
+	buf       bytes.Buffer
+	done      bool
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(r.body, r.chunk)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		r.writeChunk(r.chunk[:n])
+		r.done = n == 0
+	}
+	return r.buf.Read(p)
+}
+
+func (r *chunkedReader) writeChunk(data []byte) {
+	w := new(bytes.Buffer)
+	fmt.Fprint(w, "AWS4-HMAC-SHA256-PAYLOAD\n")
+	fmt.Fprintf(w, "%s\n", r.t.Format(ISO8601BasicFormat))
+	fmt.Fprintf(w, "%s\n", r.signer.credentialScope(r.t))
+	fmt.Fprintf(w, "%s\n", r.signature)
+	fmt.Fprintf(w, "%s\n", r.signer.hash(""))
+	fmt.Fprintf(w, "%x", sha256.Sum256(data))
+	r.signature = r.signer.signature(r.t, w.String())
+
+	fmt.Fprintf(&r.buf, "%x;chunk-signature=%s\r\n", len(data), r.signature)
+	r.buf.Write(data)
+	r.buf.WriteString("\r\n")
+}
+
+func (r *chunkedReader) Close() error {
+	return r.body.Close()
 }
 
 /*
@@ -183,7 +370,7 @@ func (s *V4Signer) requestTime(req *http.Request) time.Time {
 	}
 
 	// Create a current time header to be used
-	t = time.Now().UTC()
+	t = s.Clock.Now()
 	req.Header.Set("x-amz-date", t.Format(ISO8601BasicFormat))
 	return t
 }
@@ -200,17 +387,33 @@ canonicalRequest method creates the canonical request according to Task 1 of the
       HexEncode(Hash(Payload))
 */
 func (s *V4Signer) canonicalRequest(req *http.Request) string {
+	return s.canonicalRequestWithPayload(req, s.payloadHash(req))
+}
+
+func (s *V4Signer) canonicalRequestWithPayload(req *http.Request, payloadHash string) string {
 	c := new(bytes.Buffer)
 	fmt.Fprintf(c, "%s\n", req.Method)
 	fmt.Fprintf(c, "%s\n", s.canonicalURI(req.URL))
 	fmt.Fprintf(c, "%s\n", s.canonicalQueryString(req.URL))
 	fmt.Fprintf(c, "%s\n\n", s.canonicalHeaders(req.Header))
 	fmt.Fprintf(c, "%s\n", s.signedHeaders(req.Header))
-	fmt.Fprintf(c, "%s", s.payloadHash(req))
+	fmt.Fprintf(c, "%s", payloadHash)
 	return c.String()
 }
 
 func (s *V4Signer) canonicalURI(u *url.URL) string {
+	if s.serviceName == "s3" {
+		// S3 paths are object keys: they are encoded once and
+		// never normalized.
+		segments := strings.Split(u.Path, "/")
+		for i, segment := range segments {
+			segments[i] = Encode(segment)
+		}
+		if canonicalPath := strings.Join(segments, "/"); canonicalPath != "" {
+			return canonicalPath
+		}
+		return "/"
+	}
 	canonicalPath := u.RequestURI()
 	if u.RawQuery != "" {
 		canonicalPath = canonicalPath[:len(canonicalPath)-len(u.RawQuery)-1]
@@ -265,6 +468,9 @@ func (s *V4Signer) signedHeaders(h http.Header) string {
 }
 
 func (s *V4Signer) payloadHash(req *http.Request) string {
+	if h := req.Header.Get("x-amz-content-sha256"); h != "" {
+		return h
+	}
 	var b []byte
 	if req.Body == nil {
 		b = []byte("")
@@ -275,8 +481,8 @@ func (s *V4Signer) payloadHash(req *http.Request) string {
 			// TODO: I REALLY DON'T LIKE THIS PANIC!!!!
 			panic(err)
 		}
+		req.Body = ioutil.NopCloser(bytes.NewBuffer(b))
 	}
-	req.Body = ioutil.NopCloser(bytes.NewBuffer(b))
 	return s.hash(string(b))
 }
 
@@ -298,6 +504,14 @@ func (s *V4Signer) stringToSign(t time.Time, creq string) string {
 	return w.String()
 }
 
+// log gives the canonical request and the string to sign to the Logger
+// of the signer, if any.
+func (s *V4Signer) log(creq, sts string) {
+	if s.Logger != nil {
+		s.Logger.Log(fmt.Sprintf("aws: canonical request:\n%s\naws: string to sign:\n%s", RedactSigned(creq), sts))
+	}
+}
+
 func (s *V4Signer) credentialScope(t time.Time) string {
 	return fmt.Sprintf("%s/%s/%s/aws4_request", t.Format(ISO8601BasicFormatShort), s.region.Name, s.serviceName)
 }
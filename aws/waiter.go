@@ -0,0 +1,175 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Errors wrapped by the errors of Waiter.Wait, to be told apart with
+// errors.Is.
+var (
+	ErrWaiterFailure = errors.New("resource reached a failure state")
+	ErrWaiterTimeout = errors.New("timed out")
+)
+
+// WaiterState is the outcome of a poll matched by an Acceptor.
+type WaiterState int
+
+const (
+	// WaiterSuccess ends the wait successfully.
+	WaiterSuccess WaiterState = iota
+	// WaiterFailure ends the wait with an error wrapping ErrWaiterFailure.
+	WaiterFailure
+	// WaiterRetry polls again, such as when the resource is not visible
+	// yet.
+	WaiterRetry
+)
+
+// WaiterMatcher tells what an Acceptor matches the outcome of a poll
+// against.
+type WaiterMatcher int
+
+const (
+	// MatchPathAll matches if there are values at Path, all equal to
+	// Expected.
+	MatchPathAll WaiterMatcher = iota
+	// MatchPathAny matches if any value at Path equals Expected.
+	MatchPathAny
+	// MatchError matches if the poll failed with an APIError whose
+	// code is Expected.
+	MatchError
+)
+
+// Acceptor decides of the state of a waiter after a poll.
+type Acceptor struct {
+	State   WaiterState
+	Matcher WaiterMatcher
+
+	// Path selects values of the result of the poll for MatchPathAll
+	// and MatchPathAny. It is a list of field names separated by dots,
+	// going through pointers and into every element of slices, such as
+	// "Reservations.Instances.State.Name".
+	Path string
+
+	// Expected is the value compared to the values at Path, in its
+	// fmt.Sprint form, or the error code for MatchError.
+	Expected interface{}
+}
+
+// Waiter polls a resource until it reaches a desired state, such as an
+// EC2 instance until it is running. Clients ship waiters for common
+// transitions, along with WaitUntil methods using them.
+type Waiter struct {
+	// Name identifies the waiter in errors, such as "InstanceRunning".
+	Name string
+
+	// Strategy sets how often the resource is polled, and for how long
+	// before giving up.
+	Strategy AttemptStrategy
+
+	// Acceptors are matched in order against the outcome of every poll.
+	// The first one matching decides of the state of the waiter. Polls
+	// matched by none are retried, unless they failed.
+	Acceptors []Acceptor
+}
+
+// Wait calls poll until an acceptor matches its outcome with the
+// WaiterSuccess or WaiterFailure state, the strategy of w runs out or
+// ctx is done. It returns nil on success, and otherwise an error
+// wrapping ErrWaiterFailure, ErrWaiterTimeout, the context error, or the
+// error of a poll matched by no acceptor.
+func (w *Waiter) Wait(ctx context.Context, poll func() (interface{}, error)) error {
+	attempt := w.Strategy.StartWithContext(ctx)
+	for attempt.Next() {
+		result, err := poll()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		acceptor := w.match(result, err)
+		switch {
+		case acceptor == nil && err != nil:
+			return err
+		case acceptor == nil || acceptor.State == WaiterRetry:
+			continue
+		case acceptor.State == WaiterSuccess:
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("aws: waiter %s: %w: %v", w.Name, ErrWaiterFailure, err)
+		}
+		return fmt.Errorf("aws: waiter %s: %w: %s is %v", w.Name, ErrWaiterFailure, acceptor.Path, acceptor.Expected)
+	}
+	if err := attempt.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("aws: waiter %s: %w after %v", w.Name, ErrWaiterTimeout, w.Strategy.Total)
+}
+
+func (w *Waiter) match(result interface{}, err error) *Acceptor {
+	for i := range w.Acceptors {
+		a := &w.Acceptors[i]
+		switch a.Matcher {
+		case MatchError:
+			if apiErr, ok := apiError(err); ok && apiErr.ErrorCode() == fmt.Sprint(a.Expected) {
+				return a
+			}
+		case MatchPathAll, MatchPathAny:
+			if err != nil {
+				continue
+			}
+			values := pathValues(reflect.ValueOf(result), strings.Split(a.Path, "."))
+			expected := fmt.Sprint(a.Expected)
+			matched := 0
+			for _, v := range values {
+				if fmt.Sprint(v.Interface()) == expected {
+					matched++
+				}
+			}
+			if a.Matcher == MatchPathAny && matched > 0 || a.Matcher == MatchPathAll && matched > 0 && matched == len(values) {
+				return a
+			}
+		}
+	}
+	return nil
+}
+
+// pathValues returns the values at path in v.
+func pathValues(v reflect.Value, path []string) []reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		var values []reflect.Value
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, pathValues(v.Index(i), path)...)
+		}
+		return values
+	}
+	if len(path) == 0 || path[0] == "" {
+		if !v.IsValid() {
+			return nil
+		}
+		return []reflect.Value{v}
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	f := v.FieldByName(path[0])
+	if !f.IsValid() {
+		return nil
+	}
+	return pathValues(f, path[1:])
+}
+
+// WaiterStrategy returns the strategy of a waiter polling every delay,
+// up to attempts times.
+func WaiterStrategy(delay time.Duration, attempts int) AttemptStrategy {
+	return AttemptStrategy{Total: delay * time.Duration(attempts), Delay: delay}
+}
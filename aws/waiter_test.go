@@ -0,0 +1,117 @@
+package aws_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/goamz/goamz/aws"
+	. "gopkg.in/check.v1"
+)
+
+type waiterItem struct {
+	State struct{ Name string }
+}
+
+type waiterResult struct {
+	Groups []struct {
+		Items []*waiterItem
+	}
+}
+
+// waiterResultOf returns a result whose items are in the given states,
+// grouped by two.
+func waiterResultOf(states ...string) *waiterResult {
+	r := &waiterResult{}
+	for i, state := range states {
+		if i%2 == 0 {
+			r.Groups = append(r.Groups, struct{ Items []*waiterItem }{})
+		}
+		item := &waiterItem{}
+		item.State.Name = state
+		g := &r.Groups[len(r.Groups)-1]
+		g.Items = append(g.Items, item)
+	}
+	return r
+}
+
+var testWaiter = aws.Waiter{
+	Name:     "ItemReady",
+	Strategy: aws.AttemptStrategy{Total: time.Second, Delay: time.Millisecond},
+	Acceptors: []aws.Acceptor{
+		{State: aws.WaiterSuccess, Matcher: aws.MatchPathAll, Path: "Groups.Items.State.Name", Expected: "ready"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchPathAny, Path: "Groups.Items.State.Name", Expected: "broken"},
+		{State: aws.WaiterRetry, Matcher: aws.MatchError, Expected: "NotFound"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchError, Expected: "Gone"},
+	},
+}
+
+// pollOf returns a poll function returning the given results and errors
+// in turn, and the number of polls made.
+func pollOf(outcomes ...interface{}) (func() (interface{}, error), *int) {
+	var polls int
+	return func() (interface{}, error) {
+		o := outcomes[polls]
+		polls++
+		if err, ok := o.(error); ok {
+			return nil, err
+		}
+		return o, nil
+	}, &polls
+}
+
+func (s *S) TestWaiterSuccess(c *C) {
+	poll, polls := pollOf(
+		&aws.Error{Code: "NotFound", StatusCode: 400},
+		waiterResultOf(),
+		waiterResultOf("pending", "ready", "ready"),
+		waiterResultOf("ready", "ready", "ready"),
+	)
+	err := testWaiter.Wait(context.Background(), poll)
+	c.Assert(err, IsNil)
+	c.Assert(*polls, Equals, 4)
+}
+
+func (s *S) TestWaiterFailure(c *C) {
+	poll, polls := pollOf(waiterResultOf("pending"), waiterResultOf("ready", "broken"))
+	err := testWaiter.Wait(context.Background(), poll)
+	c.Assert(errors.Is(err, aws.ErrWaiterFailure), Equals, true)
+	c.Assert(err, ErrorMatches, "aws: waiter ItemReady: resource reached a failure state: Groups.Items.State.Name is broken")
+	c.Assert(*polls, Equals, 2)
+
+	poll, _ = pollOf(&aws.Error{Code: "Gone", StatusCode: 400})
+	err = testWaiter.Wait(context.Background(), poll)
+	c.Assert(errors.Is(err, aws.ErrWaiterFailure), Equals, true)
+}
+
+func (s *S) TestWaiterUnmatchedError(c *C) {
+	poll, polls := pollOf(waiterResultOf("pending"), &aws.Error{Code: "AccessDenied", StatusCode: 403})
+	err := testWaiter.Wait(context.Background(), poll)
+	c.Assert(err, FitsTypeOf, &aws.Error{})
+	c.Assert(*polls, Equals, 2)
+}
+
+func (s *S) TestWaiterTimeout(c *C) {
+	w := testWaiter
+	w.Strategy = aws.AttemptStrategy{Total: 50 * time.Millisecond, Delay: 10 * time.Millisecond}
+	err := w.Wait(context.Background(), func() (interface{}, error) {
+		return waiterResultOf("pending"), nil
+	})
+	c.Assert(errors.Is(err, aws.ErrWaiterTimeout), Equals, true)
+}
+
+func (s *S) TestWaiterCanceled(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := testWaiter
+	w.Strategy = aws.AttemptStrategy{Total: time.Hour, Delay: 10 * time.Millisecond}
+	var polls int
+	err := w.Wait(ctx, func() (interface{}, error) {
+		polls++
+		if polls == 3 {
+			cancel()
+		}
+		return waiterResultOf("pending"), nil
+	})
+	c.Assert(err, Equals, context.Canceled)
+	c.Assert(polls, Equals, 3)
+}
@@ -7,14 +7,12 @@
 package cloudformation
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
-	"log"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/goamz/goamz/aws"
@@ -24,16 +22,54 @@ import (
 type CloudFormation struct {
 	aws.Auth
 	aws.Region
+	httpClient *http.Client
+	ctx        context.Context
+	clock      *aws.Clock
+
+	// Logger, if set, is given the lines selected by LogLevel about
+	// requests, with credentials redacted.
+	Logger   aws.Logger
+	LogLevel aws.LogLevel
+
+	// Instrumentation, if set, is told about every attempt of the
+	// calls of cloudformation.
+	Instrumentation aws.Instrumentation
+
+	// Handlers are run on every request of the client, after the
+	// aws.GlobalHandlers.
+	Handlers aws.Handlers
 }
 
 // New creates a new CloudFormation Client.
 func New(auth aws.Auth, region aws.Region) *CloudFormation {
+	return NewWithClient(auth, region, http.DefaultClient)
+}
 
-	return &CloudFormation{auth, region}
+// NewWithClient creates a new CloudFormation Client sending its requests
+// with client.
+func NewWithClient(auth aws.Auth, region aws.Region, client *http.Client) *CloudFormation {
+	return &CloudFormation{Auth: auth, Region: region, httpClient: client, clock: &aws.Clock{}}
+}
 
+// WithContext returns a shallow copy of c whose requests are bound to
+// ctx, so that they are abandoned when ctx is cancelled or its deadline
+// expires. The provided ctx must be non-nil.
+func (c *CloudFormation) WithContext(ctx context.Context) *CloudFormation {
+	if ctx == nil {
+		panic("nil context")
+	}
+	cf := *c
+	cf.ctx = ctx
+	return &cf
 }
 
-const debug = false
+// Context returns the context requests made by c are bound to.
+func (c *CloudFormation) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}
 
 // ----------------------------------------------------------------------------
 // Request dispatching logic.
@@ -61,53 +97,51 @@ func (err *Error) Error() string {
 	return fmt.Sprintf("%s (%s)", err.Message, err.Code)
 }
 
+// HTTPStatusCode, ErrorCode, ErrorMessage and RequestID implement
+// aws.APIError.
+func (err *Error) HTTPStatusCode() int  { return err.StatusCode }
+func (err *Error) ErrorCode() string    { return err.Code }
+func (err *Error) ErrorMessage() string { return err.Message }
+func (err *Error) RequestID() string    { return err.RequestId }
+
 type xmlErrors struct {
 	RequestId string  `xml:"RequestId"`
 	Errors    []Error `xml:"Error"`
 }
 
+// handlers are the handlers of CloudFormation requests, run before the
+// aws.GlobalHandlers and the Handlers of the client.
+var handlers = func() aws.Handlers {
+	h := aws.QueryHandlers()
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("cloudformation.UnmarshalError", buildError))
+	return h
+}()
+
 func (c *CloudFormation) query(params map[string]string, resp interface{}) error {
 	params["Version"] = "2010-05-15"
-
-	data := strings.NewReader(multimap(params).Encode())
-
-	hreq, err := http.NewRequest("POST", c.Region.CloudFormationEndpoint+"/", data)
-	if err != nil {
-		return err
-	}
-
-	hreq.Header.Set("Content-Type", "application/x-www-form-urlencoded; param=value")
-
-	token := c.Auth.Token()
-	if token != "" {
-		hreq.Header.Set("X-Amz-Security-Token", token)
-	}
-
-	signer := aws.NewV4Signer(c.Auth, "cloudformation", c.Region)
-	signer.Sign(hreq)
-
-	if debug {
-		log.Printf("%v -> {\n", hreq)
-	}
-	r, err := http.DefaultClient.Do(hreq)
-
+	ep, err := aws.ResolveEndpoint("cloudformation", c.Region)
 	if err != nil {
-		log.Printf("Error calling Amazon")
 		return err
 	}
-
-	defer r.Body.Close()
-
-	if debug {
-		dump, _ := httputil.DumpResponse(r, true)
-		log.Printf("response:\n")
-		log.Printf("%v\n}\n", string(dump))
-	}
-	if r.StatusCode != 200 {
-		return buildError(r)
-	}
-	err = xml.NewDecoder(r.Body).Decode(resp)
-	return err
+	r := &aws.Request{
+		Service:         ep.SigningName,
+		Operation:       params["Action"],
+		Region:          c.Region,
+		Auth:            c.Auth,
+		Context:         c.Context(),
+		Method:          "POST",
+		SigningRegion:   ep.SigningRegion,
+		Endpoint:        ep.URL,
+		Params:          params,
+		Result:          resp,
+		HTTPClient:      c.httpClient,
+		Clock:           c.clock,
+		Logger:          c.Logger,
+		LogLevel:        c.LogLevel,
+		Instrumentation: c.Instrumentation,
+		Handlers:        aws.ChainHandlers(handlers, aws.GlobalHandlers, c.Handlers),
+	}
+	return r.Send()
 }
 
 func buildError(r *http.Response) error {
@@ -339,6 +373,18 @@ func (c *CloudFormation) DescribeStackEvents(stackName string, nextToken string)
 	return resp, nil
 }
 
+// DescribeStackEventsPages returns a Pager over the pages of
+// DescribeStackEvents results.
+func (c *CloudFormation) DescribeStackEventsPages(stackName string) *aws.Pager[*DescribeStackEventsResponse] {
+	return aws.NewPager(func(token string) (*DescribeStackEventsResponse, string, error) {
+		resp, err := c.DescribeStackEvents(stackName, token)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // StackResourceDetail encapsulates the StackResourceDetail data type
 //
 // See http://goo.gl/flce6I for more details
@@ -497,6 +543,18 @@ func (c *CloudFormation) DescribeStacks(stackName string, nextToken string) (
 	return resp, nil
 }
 
+// DescribeStacksPages returns a Pager over the pages of DescribeStacks
+// results.
+func (c *CloudFormation) DescribeStacksPages(stackName string) *aws.Pager[*DescribeStacksResponse] {
+	return aws.NewPager(func(token string) (*DescribeStacksResponse, string, error) {
+		resp, err := c.DescribeStacks(stackName, token)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // EstimateTemplateCostResponse wraps a response returned by EstimateTemplateCost request
 //
 // See http://goo.gl/PD9hle for more information
@@ -639,6 +697,18 @@ func (c *CloudFormation) ListStackResources(stackName, nextToken string) (
 	return resp, nil
 }
 
+// ListStackResourcesPages returns a Pager over the pages of
+// ListStackResources results.
+func (c *CloudFormation) ListStackResourcesPages(stackName string) *aws.Pager[*ListStackResourcesResponse] {
+	return aws.NewPager(func(token string) (*ListStackResourcesResponse, string, error) {
+		resp, err := c.ListStackResources(stackName, token)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // StackSummary encapsulates the StackSummary data type
 //
 // See http://goo.gl/35j3wf for more details
@@ -687,6 +757,17 @@ func (c *CloudFormation) ListStacks(stackStatusFilters []string, nextToken strin
 	return resp, nil
 }
 
+// ListStacksPages returns a Pager over the pages of ListStacks results.
+func (c *CloudFormation) ListStacksPages(stackStatusFilters []string) *aws.Pager[*ListStacksResponse] {
+	return aws.NewPager(func(token string) (*ListStacksResponse, string, error) {
+		resp, err := c.ListStacks(stackStatusFilters, token)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // SetStackPolicy sets a stack policy for a specified stack.
 //
 // Required Params: stackName
@@ -835,3 +916,27 @@ func (c *CloudFormation) ValidateTemplate(templateBody, templateUrl string) (
 	}
 	return resp, nil
 }
+
+// StackCreateComplete waits for a stack to be created. It fails if the
+// creation failed or was rolled back, or if the stack does not exist.
+var StackCreateComplete = aws.Waiter{
+	Name:     "StackCreateComplete",
+	Strategy: aws.WaiterStrategy(30*time.Second, 120),
+	Acceptors: []aws.Acceptor{
+		{State: aws.WaiterSuccess, Matcher: aws.MatchPathAll, Path: "Stacks.StackStatus", Expected: "CREATE_COMPLETE"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchPathAny, Path: "Stacks.StackStatus", Expected: "CREATE_FAILED"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchPathAny, Path: "Stacks.StackStatus", Expected: "DELETE_COMPLETE"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchPathAny, Path: "Stacks.StackStatus", Expected: "DELETE_FAILED"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchPathAny, Path: "Stacks.StackStatus", Expected: "ROLLBACK_FAILED"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchPathAny, Path: "Stacks.StackStatus", Expected: "ROLLBACK_COMPLETE"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchError, Expected: "ValidationError"},
+	},
+}
+
+// WaitUntilStackCreateComplete waits with StackCreateComplete for the
+// stack stackName.
+func (c *CloudFormation) WaitUntilStackCreateComplete(stackName string) error {
+	return StackCreateComplete.Wait(c.Context(), func() (interface{}, error) {
+		return c.DescribeStacks(stackName, "")
+	})
+}
@@ -16,11 +16,13 @@
 package cloudwatch
 
 import (
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"github.com/feyeleanor/sets"
 	"github.com/goamz/goamz/aws"
+	"net/http"
 	"strconv"
 	"time"
 )
@@ -28,6 +30,7 @@ import (
 // The CloudWatch type encapsulates all the CloudWatch operations in a region.
 type CloudWatch struct {
 	Service aws.AWSService
+	ctx     context.Context
 }
 
 type Dimension struct {
@@ -178,20 +181,54 @@ var validComparisonOperators = sets.SSet(
 
 // Create a new CloudWatch object for a given namespace
 func NewCloudWatch(auth aws.Auth, region aws.ServiceInfo) (*CloudWatch, error) {
+	return NewCloudWatchWithClient(auth, region, http.DefaultClient)
+}
+
+// NewCloudWatchWithClient is like NewCloudWatch, with requests sent by
+// client.
+func NewCloudWatchWithClient(auth aws.Auth, region aws.ServiceInfo, client *http.Client) (*CloudWatch, error) {
 	service, err := aws.NewService(auth, region)
 	if err != nil {
 		return nil, err
 	}
+	service.HTTPClient = client
 	return &CloudWatch{
 		Service: service,
 	}, nil
 }
 
+// WithContext returns a shallow copy of c whose requests are bound to
+// ctx, so that they are abandoned when ctx is cancelled or its deadline
+// expires. The context is only honoured if c.Service implements
+// aws.AWSServiceWithContext. The provided ctx must be non-nil.
+func (c *CloudWatch) WithContext(ctx context.Context) *CloudWatch {
+	if ctx == nil {
+		panic("nil context")
+	}
+	cw := *c
+	cw.ctx = ctx
+	return &cw
+}
+
+// Context returns the context requests made by c are bound to.
+func (c *CloudWatch) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}
+
 func (c *CloudWatch) query(method, path string, params map[string]string, resp interface{}) error {
 	// Add basic Cloudwatch param
 	params["Version"] = "2010-08-01"
 
-	r, err := c.Service.Query(method, path, params)
+	var r *http.Response
+	var err error
+	if service, ok := c.Service.(aws.AWSServiceWithContext); ok {
+		r, err = service.QueryWithContext(c.Context(), method, path, params)
+	} else {
+		r, err = c.Service.Query(method, path, params)
+	}
 	if err != nil {
 		return err
 	}
@@ -2,18 +2,81 @@ package dynamodb
 
 import simplejson "github.com/bitly/go-simplejson"
 import (
+	"context"
 	"errors"
 	"github.com/goamz/goamz/aws"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"strings"
-	"time"
+	"sync"
 )
 
+// Server is a DynamoDB endpoint requests are sent to.
+//
+// Server has more fields than Auth and Region, for the options of its
+// requests and the context WithContext binds them to, so its literals
+// must name their fields, as in dynamodb.Server{Auth: auth, Region: region}.
+// The unkeyed dynamodb.Server{auth, region} of older code no longer
+// compiles.
 type Server struct {
 	Auth   aws.Auth
 	Region aws.Region
+	ctx    context.Context
+
+	// Clock gives the time requests are signed at, corrected when DynamoDB
+	// rejects them for being signed with a skewed clock. If nil, it is
+	// set on the first request.
+	Clock *aws.Clock
+
+	// HTTPClient sends the requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// Logger, if set, is given the lines selected by LogLevel about
+	// requests, with credentials redacted.
+	Logger   aws.Logger
+	LogLevel aws.LogLevel
+
+	// Instrumentation, if set, is told about every attempt of the
+	// calls of dynamodb.
+	Instrumentation aws.Instrumentation
+
+	// Handlers are run on every request of the server, after the
+	// aws.GlobalHandlers.
+	Handlers aws.Handlers
+}
+
+// WithContext returns a shallow copy of s whose requests are bound to
+// ctx, so that they are abandoned when ctx is cancelled or its deadline
+// expires. The provided ctx must be non-nil.
+func (s *Server) WithContext(ctx context.Context) *Server {
+	if ctx == nil {
+		panic("nil context")
+	}
+	s.clock()
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
+// clockMu guards the Clock of Servers created on their first request.
+var clockMu sync.Mutex
+
+// clock returns s.Clock, setting it first if nil.
+func (s *Server) clock() *aws.Clock {
+	clockMu.Lock()
+	defer clockMu.Unlock()
+	if s.Clock == nil {
+		s.Clock = &aws.Clock{}
+	}
+	return s.Clock
+}
+
+// Context returns the context requests made by s are bound to.
+func (s *Server) Context() context.Context {
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return context.Background()
 }
 
 /*
@@ -46,23 +109,31 @@ type Error struct {
 	Status     string
 	Code       string // Dynamodb error code ("MalformedQueryString", ...)
 	Message    string // The human-oriented error message
+	RequestId  string // A unique ID for the failed request
 }
 
 func (e *Error) Error() string {
 	return e.Code + ": " + e.Message
 }
 
+// HTTPStatusCode, ErrorCode, ErrorMessage and RequestID implement
+// aws.APIError.
+func (e *Error) HTTPStatusCode() int  { return e.StatusCode }
+func (e *Error) ErrorCode() string    { return e.Code }
+func (e *Error) ErrorMessage() string { return e.Message }
+func (e *Error) RequestID() string    { return e.RequestId }
+
 func buildError(r *http.Response, jsonBody []byte) error {
 
 	ddbError := Error{
 		StatusCode: r.StatusCode,
 		Status:     r.Status,
+		RequestId:  r.Header.Get("X-Amzn-Requestid"),
 	}
 	// TODO return error if Unmarshal fails?
 
 	json, err := simplejson.NewJson(jsonBody)
 	if err != nil {
-		log.Printf("Failed to parse body as JSON")
 		return err
 	}
 	ddbError.Message = json.Get("message").MustString()
@@ -79,53 +150,71 @@ func buildError(r *http.Response, jsonBody []byte) error {
 	return &ddbError
 }
 
+// handlers are the handlers of DynamoDB requests, run before the
+// aws.GlobalHandlers and the Handlers of the server.
+var handlers = func() aws.Handlers {
+	var h aws.Handlers
+	h.Build.PushBack(aws.BuildHandler)
+	h.Sign.PushBack(aws.V4SignHandler)
+	h.Send.PushBack(aws.SendHandler)
+	h.Unmarshal.PushBack(aws.NamedHandler{Name: "dynamodb.Unmarshal", Fn: func(r *aws.Request) {
+		body, err := ioutil.ReadAll(r.HTTPResponse.Body)
+		if err != nil {
+			r.Error = err
+			return
+		}
+		*r.Result.(*[]byte) = body
+	}})
+	h.UnmarshalError.PushBack(aws.NamedHandler{Name: "dynamodb.UnmarshalError", Fn: func(r *aws.Request) {
+		body, err := ioutil.ReadAll(r.HTTPResponse.Body)
+		if err != nil {
+			r.Error = err
+			return
+		}
+		// http://docs.aws.amazon.com/amazondynamodb/latest/developerguide/ErrorHandling.html
+		// "A response code of 200 indicates the operation was successful."
+		r.Error = buildError(r.HTTPResponse, body)
+	}})
+	h.Retry.PushBack(aws.RetryHandler)
+	return h
+}()
+
 func (s *Server) queryServer(target string, query *Query) ([]byte, error) {
-	data := strings.NewReader(query.String())
-	var endpoint string
+	service := "dynamodb"
 	if isStreamsTarget(target) {
-		endpoint = s.Region.DynamoDBStreamsEndpoint
-	} else {
-		endpoint = s.Region.DynamoDBEndpoint
+		service = "streams.dynamodb"
 	}
-	hreq, err := http.NewRequest("POST", endpoint+"/", data)
+	ep, err := aws.ResolveEndpoint(service, s.Region)
 	if err != nil {
 		return nil, err
 	}
 
-	hreq.Header.Set("Content-Type", "application/x-amz-json-1.0")
-	hreq.Header.Set("X-Amz-Date", time.Now().UTC().Format(aws.ISO8601BasicFormat))
-	hreq.Header.Set("X-Amz-Target", target)
-
-	token := s.Auth.Token()
-	if token != "" {
-		hreq.Header.Set("X-Amz-Security-Token", token)
+	var body []byte
+	r := &aws.Request{
+		Service:       ep.SigningName,
+		Operation:     target[strings.Index(target, ".")+1:],
+		Region:        s.Region,
+		Auth:          s.Auth,
+		Context:       s.Context(),
+		Method:        "POST",
+		SigningRegion: ep.SigningRegion,
+		Endpoint:      ep.URL,
+		Header: http.Header{
+			"Content-Type": {"application/x-amz-json-1.0"},
+			"X-Amz-Target": {target},
+		},
+		Payload:         []byte(query.String()),
+		Result:          &body,
+		HTTPClient:      s.HTTPClient,
+		Clock:           s.clock(),
+		Logger:          s.Logger,
+		LogLevel:        s.LogLevel,
+		Instrumentation: s.Instrumentation,
+		Handlers:        aws.ChainHandlers(handlers, aws.GlobalHandlers, s.Handlers),
 	}
-
-	signer := aws.NewV4Signer(s.Auth, "dynamodb", s.Region)
-	signer.Sign(hreq)
-
-	resp, err := http.DefaultClient.Do(hreq)
-
-	if err != nil {
-		log.Printf("Error calling Amazon")
+	if err := r.Send(); err != nil {
 		return nil, err
 	}
-
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Could not read response body")
-		return nil, err
-	}
-
-	// http://docs.aws.amazon.com/amazondynamodb/latest/developerguide/ErrorHandling.html
-	// "A response code of 200 indicates the operation was successful."
-	if resp.StatusCode != 200 {
-		ddbErr := buildError(resp, body)
-		return nil, ddbErr
-	}
-
 	return body, nil
 }
 
@@ -1,6 +1,7 @@
 package dynamodb_test
 
 import (
+	"context"
 	"flag"
 	"testing"
 	"time"
@@ -164,3 +165,14 @@ func findTableByName(tables []string, name string) bool {
 func Test(t *testing.T) {
 	TestingT(t)
 }
+
+type ServerSuite struct{}
+
+var _ = Suite(&ServerSuite{})
+
+func (s *ServerSuite) TestWithContextSharesClock(c *C) {
+	server := &dynamodb.Server{Auth: aws.Auth{AccessKey: "abc", SecretKey: "123"}, Region: aws.USEast}
+	bound := server.WithContext(context.Background())
+	c.Assert(server.Clock, NotNil)
+	c.Assert(bound.Clock, Equals, server.Clock)
+}
@@ -1,9 +1,12 @@
 package dynamodb
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+
 	simplejson "github.com/bitly/go-simplejson"
+	"github.com/goamz/goamz/aws"
 )
 
 func (t *Table) Query(attributeComparisons []AttributeComparison) ([]map[string]*Attribute, error) {
@@ -109,3 +112,40 @@ func runQuery(q *Query, t *Table) ([]map[string]*Attribute, error) {
 	}
 	return results, nil
 }
+
+// QueryPages returns a Pager over the pages of results of q, a query built
+// with NewQuery and AddKeyConditions. Each page starts after the
+// LastEvaluatedKey of the previous one. q is left unchanged.
+func (t *Table) QueryPages(q *Query) *aws.Pager[[]map[string]*Attribute] {
+	return t.pages(target("Query"), q)
+}
+
+func (t *Table) pages(target string, q *Query) *aws.Pager[[]map[string]*Attribute] {
+	return aws.NewPager(func(startKey string) ([]map[string]*Attribute, string, error) {
+		page := q.copy()
+		if startKey != "" {
+			page.AddExclusiveStartKey(json.RawMessage(startKey))
+		}
+		jsonResponse, err := t.Server.queryServer(target, page)
+		if err != nil {
+			return nil, "", err
+		}
+
+		var resp struct {
+			Items            []map[string]interface{}
+			LastEvaluatedKey json.RawMessage
+		}
+		if err := json.Unmarshal(jsonResponse, &resp); err != nil {
+			message := fmt.Sprintf("Unexpected response %s", jsonResponse)
+			return nil, "", errors.New(message)
+		}
+		results := make([]map[string]*Attribute, len(resp.Items))
+		for i, item := range resp.Items {
+			results[i] = parseAttributes(item)
+		}
+		if string(resp.LastEvaluatedKey) == "null" {
+			resp.LastEvaluatedKey = nil
+		}
+		return results, string(resp.LastEvaluatedKey), nil
+	})
+}
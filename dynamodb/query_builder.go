@@ -311,6 +311,21 @@ func (q *Query) AddExpressionAttributes(attributes []Attribute) {
 	}
 }
 
+// AddExclusiveStartKey makes a Query or Scan start after key, the
+// LastEvaluatedKey of a previous page of results in its JSON form.
+func (q *Query) AddExclusiveStartKey(key json.RawMessage) {
+	q.buffer["ExclusiveStartKey"] = key
+}
+
+// copy returns a copy of q, to be added to without changing q.
+func (q *Query) copy() *Query {
+	c := NewEmptyQuery()
+	for k, v := range q.buffer {
+		c.buffer[k] = v
+	}
+	return c
+}
+
 func (q *Query) AddExclusiveStartStreamArn(arn string) {
 	q.buffer["ExclusiveStartStreamArn"] = arn
 }
@@ -3,7 +3,9 @@ package dynamodb
 import (
 	"errors"
 	"fmt"
+
 	simplejson "github.com/bitly/go-simplejson"
+	"github.com/goamz/goamz/aws"
 )
 
 func (t *Table) FetchResults(query *Query) ([]map[string]*Attribute, error) {
@@ -49,3 +51,11 @@ func (t *Table) ParallelScan(attributeComparisons []AttributeComparison, segment
 	q.AddParallelScanConfiguration(segment, totalSegments)
 	return t.FetchResults(q)
 }
+
+// ScanPages returns a Pager over the pages of results of q, a scan built
+// with NewQuery, AddScanFilter and AddParallelScanConfiguration. Each page
+// starts after the LastEvaluatedKey of the previous one. q is left
+// unchanged.
+func (t *Table) ScanPages(q *Query) *aws.Pager[[]map[string]*Attribute] {
+	return t.pages(target("Scan"), q)
+}
@@ -1,12 +1,14 @@
 package dynamodb
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
 
 	simplejson "github.com/bitly/go-simplejson"
+	"github.com/goamz/goamz/aws"
 )
 
 type Stream struct {
@@ -62,7 +64,8 @@ type StreamRecordT struct {
 }
 
 type listStreamsResponse struct {
-	Streams []StreamListItemT
+	Streams                []StreamListItemT
+	LastEvaluatedStreamArn string
 }
 
 type describeStreamResponse struct {
@@ -84,6 +87,26 @@ func (s *Server) ListTableStreams(table, startArn string) ([]StreamListItemT, er
 }
 
 func (s *Server) LimitedListTableStreams(table, startArn string, limit int64) ([]StreamListItemT, error) {
+	r, err := s.listStreams(table, startArn, limit)
+	if err != nil {
+		return nil, err
+	}
+	return r.Streams, nil
+}
+
+// ListTableStreamsPages returns a Pager over the pages of streams of table,
+// or of every table if empty, of up to limit streams each.
+func (s *Server) ListTableStreamsPages(table string, limit int64) *aws.Pager[[]StreamListItemT] {
+	return aws.NewPager(func(startArn string) ([]StreamListItemT, string, error) {
+		r, err := s.listStreams(table, startArn, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return r.Streams, r.LastEvaluatedStreamArn, nil
+	})
+}
+
+func (s *Server) listStreams(table, startArn string, limit int64) (*listStreamsResponse, error) {
 	query := NewEmptyQuery()
 
 	if len(table) != 0 {
@@ -109,7 +132,7 @@ func (s *Server) LimitedListTableStreams(table, startArn string, limit int64) ([
 		return nil, err
 	}
 
-	return r.Streams, nil
+	return &r, nil
 }
 
 func (s *Server) DescribeStream(arn, startShardId string) (*StreamDescriptionT, error) {
@@ -142,10 +165,27 @@ func (s *Server) LimitedDescribeStream(arn, startShardId string, limit int64) (*
 	return &r.StreamDescription, nil
 }
 
+// DescribeStreamPages returns a Pager over the pages of the description of
+// the stream arn, of up to limit shards each.
+func (s *Server) DescribeStreamPages(arn string, limit int64) *aws.Pager[*StreamDescriptionT] {
+	return aws.NewPager(func(startShardId string) (*StreamDescriptionT, string, error) {
+		desc, err := s.LimitedDescribeStream(arn, startShardId, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return desc, desc.LastEvaluatedShardId, nil
+	})
+}
+
 func (s *Server) NewStream(streamArn string) *Stream {
 	return &Stream{s, streamArn}
 }
 
+// WithContext returns a copy of s whose requests are bound to ctx.
+func (s *Stream) WithContext(ctx context.Context) *Stream {
+	return &Stream{s.Server.WithContext(ctx), s.Arn}
+}
+
 func (s *Stream) DescribeStream(startShardId string) (*StreamDescriptionT, error) {
 	return s.Server.DescribeStream(s.Arn, startShardId)
 }
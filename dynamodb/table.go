@@ -1,6 +1,7 @@
 package dynamodb
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -123,6 +124,11 @@ func (s *Server) NewTable(name string, key PrimaryKey) *Table {
 	return &Table{s, name, key}
 }
 
+// WithContext returns a copy of t whose requests are bound to ctx.
+func (t *Table) WithContext(ctx context.Context) *Table {
+	return &Table{t.Server.WithContext(ctx), t.Name, t.Key}
+}
+
 func (s *Server) ListTables() ([]string, error) {
 	var tables []string
 
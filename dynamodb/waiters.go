@@ -0,0 +1,31 @@
+package dynamodb
+
+import (
+	"time"
+
+	"github.com/goamz/goamz/aws"
+)
+
+// TableActive waits for a table to be active, such as after it was
+// created or updated.
+var TableActive = aws.Waiter{
+	Name:     "TableActive",
+	Strategy: aws.WaiterStrategy(20*time.Second, 25),
+	Acceptors: []aws.Acceptor{
+		{State: aws.WaiterSuccess, Matcher: aws.MatchPathAll, Path: "TableStatus", Expected: "ACTIVE"},
+		// Tables just created may not be visible yet.
+		{State: aws.WaiterRetry, Matcher: aws.MatchError, Expected: "ResourceNotFoundException"},
+	},
+}
+
+// WaitUntilTableActive waits with TableActive for the table name.
+func (s *Server) WaitUntilTableActive(name string) error {
+	return TableActive.Wait(s.Context(), func() (interface{}, error) {
+		return s.DescribeTable(name)
+	})
+}
+
+// WaitUntilActive waits with TableActive for t.
+func (t *Table) WaitUntilActive() error {
+	return t.Server.WaitUntilTableActive(t.Name)
+}
@@ -11,13 +11,12 @@
 package ec2
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/xml"
 	"fmt"
-	"log"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"sort"
 	"strconv"
@@ -27,24 +26,58 @@ import (
 	"github.com/goamz/goamz/aws"
 )
 
-const debug = false
-
 // The EC2 type encapsulates operations with a specific EC2 region.
 type EC2 struct {
 	aws.Auth
 	aws.Region
 	httpClient *http.Client
-	private    byte // Reserve the right of using private data.
+	ctx        context.Context
+	clock      *aws.Clock
+
+	// Logger, if set, is given the lines selected by LogLevel about
+	// requests, with credentials redacted.
+	Logger   aws.Logger
+	LogLevel aws.LogLevel
+
+	// Instrumentation, if set, is told about every attempt of the
+	// calls of ec2.
+	Instrumentation aws.Instrumentation
+
+	// Handlers are run on every request of the client, after the
+	// aws.GlobalHandlers.
+	Handlers aws.Handlers
+
+	private byte // Reserve the right of using private data.
 }
 
 // NewWithClient creates a new EC2 with a custom http client
 func NewWithClient(auth aws.Auth, region aws.Region, client *http.Client) *EC2 {
-	return &EC2{auth, region, client, 0}
+	return &EC2{Auth: auth, Region: region, httpClient: client, clock: &aws.Clock{}}
 }
 
 // New creates a new EC2.
 func New(auth aws.Auth, region aws.Region) *EC2 {
-	return NewWithClient(auth, region, aws.RetryingClient)
+	return NewWithClient(auth, region, aws.DefaultHTTPClient)
+}
+
+// WithContext returns a shallow copy of ec2 whose requests are bound to
+// ctx, so that they are abandoned when ctx is cancelled or its deadline
+// expires. The provided ctx must be non-nil.
+func (ec2 *EC2) WithContext(ctx context.Context) *EC2 {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *ec2
+	c.ctx = ctx
+	return &c
+}
+
+// Context returns the context requests made by ec2 are bound to.
+func (ec2 *EC2) Context() context.Context {
+	if ec2.ctx != nil {
+		return ec2.ctx
+	}
+	return context.Background()
 }
 
 // ----------------------------------------------------------------------------
@@ -115,6 +148,13 @@ func (err *Error) Error() string {
 	return fmt.Sprintf("%s (%s)", err.Message, err.Code)
 }
 
+// HTTPStatusCode, ErrorCode, ErrorMessage and RequestID implement
+// aws.APIError.
+func (err *Error) HTTPStatusCode() int  { return err.StatusCode }
+func (err *Error) ErrorCode() string    { return err.Code }
+func (err *Error) ErrorMessage() string { return err.Message }
+func (err *Error) RequestID() string    { return err.RequestId }
+
 // For now a single error inst is being exposed. In the future it may be useful
 // to provide access to all of them, but rather than doing it as an array/slice,
 // use a *next pointer, so that it's backward compatible and it continues to be
@@ -126,37 +166,42 @@ type xmlErrors struct {
 
 var timeNow = time.Now
 
+// handlers are the handlers of EC2 requests, run before the
+// aws.GlobalHandlers and the Handlers of the client.
+var handlers = func() aws.Handlers {
+	h := aws.QueryHandlers()
+	h.Sign.Remove(aws.V4SignHandler.Name)
+	h.Sign.PushBack(aws.V2SignHandler("ec2.Sign", sign))
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("ec2.UnmarshalError", buildError))
+	return h
+}()
+
 func (ec2 *EC2) query(params map[string]string, resp interface{}) error {
 	params["Version"] = "2014-02-01"
 	params["Timestamp"] = timeNow().In(time.UTC).Format(time.RFC3339)
-	endpoint, err := url.Parse(ec2.Region.EC2Endpoint)
+	ep, err := aws.ResolveEndpoint("ec2", ec2.Region)
 	if err != nil {
 		return err
 	}
-	if endpoint.Path == "" {
-		endpoint.Path = "/"
-	}
-	sign(ec2.Auth, "GET", endpoint.Path, params, endpoint.Host)
-	endpoint.RawQuery = multimap(params).Encode()
-	if debug {
-		log.Printf("get { %v } -> {\n", endpoint.String())
-	}
-	r, err := ec2.httpClient.Get(endpoint.String())
-	if err != nil {
-		return err
-	}
-	defer r.Body.Close()
-
-	if debug {
-		dump, _ := httputil.DumpResponse(r, true)
-		log.Printf("response:\n")
-		log.Printf("%v\n}\n", string(dump))
-	}
-	if r.StatusCode != 200 {
-		return buildError(r)
-	}
-	err = xml.NewDecoder(r.Body).Decode(resp)
-	return err
+	r := &aws.Request{
+		Service:         ep.SigningName,
+		Operation:       params["Action"],
+		Region:          ec2.Region,
+		Auth:            ec2.Auth,
+		Context:         ec2.Context(),
+		Method:          "GET",
+		SigningRegion:   ep.SigningRegion,
+		Endpoint:        ep.URL,
+		Params:          params,
+		HTTPClient:      ec2.httpClient,
+		Result:          resp,
+		Clock:           ec2.clock,
+		Logger:          ec2.Logger,
+		LogLevel:        ec2.LogLevel,
+		Instrumentation: ec2.Instrumentation,
+		Handlers:        aws.ChainHandlers(handlers, aws.GlobalHandlers, ec2.Handlers),
+	}
+	return r.Send()
 }
 
 func multimap(p map[string]string) url.Values {
@@ -930,6 +975,25 @@ func (ec2 *EC2) DescribeInstanceStatus(options *DescribeInstanceStatusOptions, f
 	return
 }
 
+// DescribeInstanceStatusPages returns a Pager over the pages of
+// DescribeInstanceStatus results, starting from options.NextToken.
+func (ec2 *EC2) DescribeInstanceStatusPages(options *DescribeInstanceStatusOptions, filter *Filter) *aws.Pager[*DescribeInstanceStatusResp] {
+	return aws.NewPager(func(token string) (*DescribeInstanceStatusResp, string, error) {
+		var page DescribeInstanceStatusOptions
+		if options != nil {
+			page = *options
+		}
+		if token != "" {
+			page.NextToken = token
+		}
+		resp, err := ec2.DescribeInstanceStatus(&page, filter)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // ----------------------------------------------------------------------------
 // KeyPair management functions and types.
 
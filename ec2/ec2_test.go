@@ -1,7 +1,12 @@
 package ec2_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/goamz/goamz/aws"
 	"github.com/goamz/goamz/ec2"
@@ -35,6 +40,97 @@ func (s *S) TearDownTest(c *C) {
 	testServer.Flush()
 }
 
+func (s *S) TestWithContextDeadline(c *C) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	resp, err := s.ec2.WithContext(ctx).DescribeInstances(nil, nil)
+
+	testServer.WaitRequest()
+	// Release the handler still waiting for a response.
+	testServer.Response(200, nil, "")
+
+	c.Assert(resp, IsNil)
+	c.Assert(err, ErrorMatches, ".*context deadline exceeded.*")
+	c.Assert(s.ec2.Context(), Equals, context.Background())
+}
+
+func (s *S) TestProviderAuth(c *C) {
+	testServer.Response(200, nil, DescribeInstancesExample1)
+
+	auth := aws.NewProviderAuth(&aws.StaticProvider{Auth: aws.Auth{AccessKey: "provided", SecretKey: "456"}})
+	e := ec2.NewWithClient(auth, aws.Region{EC2Endpoint: testServer.URL}, testutil.DefaultClient)
+	_, err := e.DescribeInstances(nil, nil)
+
+	req := testServer.WaitRequest()
+	c.Assert(err, IsNil)
+	c.Assert(req.Form["AWSAccessKeyId"], DeepEquals, []string{"provided"})
+}
+
+func (s *S) TestProviderAuthError(c *C) {
+	e := ec2.NewWithClient(aws.NewProviderAuth(&aws.StaticProvider{}), aws.Region{EC2Endpoint: testServer.URL}, testutil.DefaultClient)
+	_, err := e.DescribeInstances(nil, nil)
+	c.Assert(err, ErrorMatches, "static credentials are empty")
+}
+
+func (s *S) TestHandlers(c *C) {
+	testServer.Response(200, nil, DescribeInstancesExample1)
+
+	var operations []string
+	e := ec2.NewWithClient(s.ec2.Auth, s.ec2.Region, testutil.DefaultClient)
+	e.Handlers.Build.PushBack(aws.NamedHandler{Name: "test.Header", Fn: func(r *aws.Request) {
+		r.HTTPRequest.Header.Set("X-Test", "yes")
+	}})
+	e.Handlers.Complete.PushBack(aws.NamedHandler{Name: "test.Complete", Fn: func(r *aws.Request) {
+		operations = append(operations, r.Operation)
+	}})
+	_, err := e.DescribeInstances(nil, nil)
+	c.Assert(err, IsNil)
+
+	req := testServer.WaitRequest()
+	c.Assert(req.Header.Get("X-Test"), Equals, "yes")
+	c.Assert(req.Form["Signature"], HasLen, 1)
+	c.Assert(operations, DeepEquals, []string{"DescribeInstances"})
+}
+
+func (s *S) TestEndpointResolver(c *C) {
+	testServer.Response(200, nil, DescribeInstancesExample1)
+
+	region := aws.EUWest
+	region.EndpointResolver = aws.EndpointResolverFunc(func(service string, region aws.Region) (aws.Endpoint, error) {
+		c.Check(service, Equals, "ec2")
+		return aws.Endpoint{URL: testServer.URL, SigningName: "ec2", SigningRegion: region.Name}, nil
+	})
+	e := ec2.NewWithClient(s.ec2.Auth, region, testutil.DefaultClient)
+	_, err := e.DescribeInstances(nil, nil)
+	c.Assert(err, IsNil)
+
+	req := testServer.WaitRequest()
+	c.Assert(req.Form["Action"], DeepEquals, []string{"DescribeInstances"})
+}
+
+func (s *S) TestDescribeInstancesCassette(c *C) {
+	rec, err := testutil.NewRecorder("testdata/describe_instances.json", testutil.Record)
+	c.Assert(err, IsNil)
+	defer rec.Save()
+	auth := s.ec2.Auth
+	if testutil.Record {
+		auth, err = aws.EnvAuth()
+		c.Assert(err, IsNil)
+	}
+	e := ec2.NewWithClient(auth, aws.USEast, rec.Client())
+
+	filter := ec2.NewFilter()
+	filter.Add("instance-state-name", "running")
+	resp, err := e.DescribeInstances(nil, filter)
+	c.Assert(err, IsNil)
+	c.Assert(resp.Reservations, HasLen, 1)
+	c.Assert(resp.Reservations[0].Instances[0].InstanceId, Equals, "i-1a2b3c4d")
+
+	_, err = e.DescribeInstances([]string{"i-deadbeef"}, nil)
+	c.Assert(aws.IsNotFound(err), Equals, true)
+}
+
 func (s *S) TestRunInstancesErrorDump(c *C) {
 	testServer.Response(400, nil, ErrorDump)
 
@@ -58,6 +154,12 @@ func (s *S) TestRunInstancesErrorDump(c *C) {
 	c.Assert(ec2err.Code, Equals, "UnsupportedOperation")
 	c.Assert(ec2err.Message, Matches, msg)
 	c.Assert(ec2err.RequestId, Equals, "0503f4e9-bbd6-483c-b54f-c4ae9f3b30f4")
+
+	var apiErr aws.APIError
+	c.Assert(errors.As(err, &apiErr), Equals, true)
+	c.Assert(apiErr.HTTPStatusCode(), Equals, 400)
+	c.Assert(apiErr.ErrorCode(), Equals, "UnsupportedOperation")
+	c.Assert(apiErr.RequestID(), Equals, "0503f4e9-bbd6-483c-b54f-c4ae9f3b30f4")
 }
 
 func (s *S) TestRequestSpotInstancesErrorDump(c *C) {
@@ -336,6 +438,43 @@ func (s *S) TestDescribeSpotRequestsExample(c *C) {
 	c.Assert(resp.SpotRequestResults[0].SpotLaunchSpec.ImageId, Equals, "ami-1a2b3c4d")
 }
 
+func (s *S) TestInstrumentation(c *C) {
+	testServer.Response(200, nil, DescribeInstancesExample1)
+
+	inst := &aws.MemoryInstrumentation{}
+	s.ec2.Instrumentation = inst
+	defer func() { s.ec2.Instrumentation = nil }()
+
+	_, err := s.ec2.DescribeInstances([]string{"i-1"}, nil)
+	c.Assert(err, IsNil)
+	testServer.WaitRequest()
+
+	attempts := inst.Attempts()
+	c.Assert(attempts, HasLen, 1)
+	c.Assert(attempts[0].Service, Equals, "ec2")
+	c.Assert(attempts[0].Operation, Equals, "DescribeInstances")
+	c.Assert(attempts[0].StatusCode, Equals, 200)
+}
+
+func (s *S) TestLogger(c *C) {
+	testServer.Response(200, nil, DescribeInstancesExample1)
+
+	var lines []string
+	s.ec2.Logger = aws.LoggerFunc(func(args ...interface{}) {
+		lines = append(lines, fmt.Sprint(args...))
+	})
+	s.ec2.LogLevel = aws.LogRequests
+	defer func() { s.ec2.Logger, s.ec2.LogLevel = nil, aws.LogOff }()
+
+	_, err := s.ec2.DescribeInstances([]string{"i-1"}, nil)
+	c.Assert(err, IsNil)
+	testServer.WaitRequest()
+
+	c.Assert(lines, HasLen, 2)
+	c.Assert(lines[0], Matches, "aws: DescribeInstances: GET .*Signature=REDACTED.*")
+	c.Assert(lines[1], Matches, "aws: DescribeInstances: 200 OK in .*")
+}
+
 func (s *S) TestDescribeInstancesExample1(c *C) {
 	testServer.Response(200, nil, DescribeInstancesExample1)
 
@@ -370,6 +509,30 @@ func (s *S) TestDescribeInstancesExample1(c *C) {
 	c.Assert(r0i.PrivateIPAddress, Equals, "10.198.85.190")
 }
 
+func (s *S) TestWaitUntilInstanceRunning(c *C) {
+	defer func(strategy aws.AttemptStrategy) {
+		ec2.InstanceRunning.Strategy = strategy
+	}(ec2.InstanceRunning.Strategy)
+	ec2.InstanceRunning.Strategy = aws.AttemptStrategy{Total: 5 * time.Second, Delay: time.Millisecond}
+
+	notFound := strings.Replace(ErrorDump, "UnsupportedOperation", "InvalidInstanceID.NotFound", 1)
+	pending := strings.Replace(DescribeInstancesExample1, "<name>running</name>", "<name>pending</name>", 1)
+	testServer.Response(400, nil, notFound)
+	testServer.Response(200, nil, pending)
+	testServer.Response(200, nil, DescribeInstancesExample1)
+
+	err := s.ec2.WaitUntilInstanceRunning([]string{"i-2ba64342", "i-2bc64242"}, nil)
+	c.Assert(err, IsNil)
+	reqs := testServer.WaitRequests(3)
+	c.Assert(reqs[2].Form["Action"], DeepEquals, []string{"DescribeInstances"})
+	c.Assert(reqs[2].Form["InstanceId.1"], DeepEquals, []string{"i-2ba64342"})
+
+	terminated := strings.Replace(DescribeInstancesExample1, "<name>running</name>", "<name>terminated</name>", 1)
+	testServer.Response(200, nil, terminated)
+	err = s.ec2.WaitUntilInstanceRunning(nil, nil)
+	c.Assert(errors.Is(err, aws.ErrWaiterFailure), Equals, true)
+}
+
 func (s *S) TestDescribeInstancesExample2(c *C) {
 	testServer.Response(200, nil, DescribeInstancesExample2)
 
@@ -0,0 +1,69 @@
+package ec2
+
+import (
+	"time"
+
+	"github.com/goamz/goamz/aws"
+)
+
+// InstanceRunning waits for instances to be running. It fails if any of
+// them is terminated, or being stopped or terminated.
+var InstanceRunning = aws.Waiter{
+	Name:     "InstanceRunning",
+	Strategy: aws.WaiterStrategy(15*time.Second, 40),
+	Acceptors: []aws.Acceptor{
+		{State: aws.WaiterSuccess, Matcher: aws.MatchPathAll, Path: "Reservations.Instances.State.Name", Expected: "running"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchPathAny, Path: "Reservations.Instances.State.Name", Expected: "shutting-down"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchPathAny, Path: "Reservations.Instances.State.Name", Expected: "terminated"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchPathAny, Path: "Reservations.Instances.State.Name", Expected: "stopping"},
+		// Instances just launched may not be visible yet.
+		{State: aws.WaiterRetry, Matcher: aws.MatchError, Expected: "InvalidInstanceID.NotFound"},
+	},
+}
+
+// InstanceTerminated waits for instances to be terminated. It fails if
+// any of them is pending or being stopped.
+var InstanceTerminated = aws.Waiter{
+	Name:     "InstanceTerminated",
+	Strategy: aws.WaiterStrategy(15*time.Second, 40),
+	Acceptors: []aws.Acceptor{
+		{State: aws.WaiterSuccess, Matcher: aws.MatchPathAll, Path: "Reservations.Instances.State.Name", Expected: "terminated"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchPathAny, Path: "Reservations.Instances.State.Name", Expected: "pending"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchPathAny, Path: "Reservations.Instances.State.Name", Expected: "stopping"},
+	},
+}
+
+// VolumeAvailable waits for volumes to be available. It fails if any of
+// them is deleted.
+var VolumeAvailable = aws.Waiter{
+	Name:     "VolumeAvailable",
+	Strategy: aws.WaiterStrategy(15*time.Second, 40),
+	Acceptors: []aws.Acceptor{
+		{State: aws.WaiterSuccess, Matcher: aws.MatchPathAll, Path: "Volumes.Status", Expected: "available"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchPathAny, Path: "Volumes.Status", Expected: "deleted"},
+	},
+}
+
+// WaitUntilInstanceRunning waits with InstanceRunning for the instances
+// DescribeInstances returns for instIds and filter.
+func (ec2 *EC2) WaitUntilInstanceRunning(instIds []string, filter *Filter) error {
+	return InstanceRunning.Wait(ec2.Context(), func() (interface{}, error) {
+		return ec2.DescribeInstances(instIds, filter)
+	})
+}
+
+// WaitUntilInstanceTerminated waits with InstanceTerminated for the
+// instances DescribeInstances returns for instIds and filter.
+func (ec2 *EC2) WaitUntilInstanceTerminated(instIds []string, filter *Filter) error {
+	return InstanceTerminated.Wait(ec2.Context(), func() (interface{}, error) {
+		return ec2.DescribeInstances(instIds, filter)
+	})
+}
+
+// WaitUntilVolumeAvailable waits with VolumeAvailable for the volumes
+// Volumes returns for volIds and filter.
+func (ec2 *EC2) WaitUntilVolumeAvailable(volIds []string, filter *Filter) error {
+	return VolumeAvailable.Wait(ec2.Context(), func() (interface{}, error) {
+		return ec2.Volumes(volIds, filter)
+	})
+}
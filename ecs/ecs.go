@@ -9,32 +9,71 @@
 package ecs
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
-	"log"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/goamz/goamz/arn"
 	"github.com/goamz/goamz/aws"
 )
 
-const debug = false
-
 var timeNow = time.Now
 
 // ECS contains the details of the AWS region to perform operations against.
 type ECS struct {
 	aws.Auth
 	aws.Region
+	httpClient *http.Client
+	ctx        context.Context
+	clock      *aws.Clock
+
+	// Logger, if set, is given the lines selected by LogLevel about
+	// requests, with credentials redacted.
+	Logger   aws.Logger
+	LogLevel aws.LogLevel
+
+	// Instrumentation, if set, is told about every attempt of the
+	// calls of ecs.
+	Instrumentation aws.Instrumentation
+
+	// Handlers are run on every request of the client, after the
+	// aws.GlobalHandlers.
+	Handlers aws.Handlers
 }
 
 // New creates a new ECS Client.
 func New(auth aws.Auth, region aws.Region) *ECS {
-	return &ECS{auth, region}
+	return NewWithClient(auth, region, http.DefaultClient)
+}
+
+// NewWithClient creates a new ECS Client sending its requests with client.
+func NewWithClient(auth aws.Auth, region aws.Region, client *http.Client) *ECS {
+	return &ECS{Auth: auth, Region: region, httpClient: client, clock: &aws.Clock{}}
+}
+
+// WithContext returns a shallow copy of e whose requests are bound to
+// ctx, so that they are abandoned when ctx is cancelled or its deadline
+// expires. The provided ctx must be non-nil.
+func (e *ECS) WithContext(ctx context.Context) *ECS {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *e
+	c.ctx = ctx
+	return &c
+}
+
+// Context returns the context requests made by e are bound to.
+func (e *ECS) Context() context.Context {
+	if e.ctx != nil {
+		return e.ctx
+	}
+	return context.Background()
 }
 
 // ----------------------------------------------------------------------------
@@ -63,52 +102,51 @@ func (err *Error) Error() string {
 	return fmt.Sprintf("%s (%s)", err.Message, err.Code)
 }
 
+// HTTPStatusCode, ErrorCode, ErrorMessage and RequestID implement
+// aws.APIError.
+func (err *Error) HTTPStatusCode() int  { return err.StatusCode }
+func (err *Error) ErrorCode() string    { return err.Code }
+func (err *Error) ErrorMessage() string { return err.Message }
+func (err *Error) RequestID() string    { return err.RequestId }
+
 type xmlErrors struct {
 	RequestId string  `xml:"RequestId"`
 	Errors    []Error `xml:"Error"`
 }
 
+// handlers are the handlers of ECS requests, run before the
+// aws.GlobalHandlers and the Handlers of the client.
+var handlers = func() aws.Handlers {
+	h := aws.QueryHandlers()
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("ecs.UnmarshalError", buildError))
+	return h
+}()
+
 func (e *ECS) query(params map[string]string, resp interface{}) error {
 	params["Version"] = "2014-11-13"
-	data := strings.NewReader(multimap(params).Encode())
-
-	hreq, err := http.NewRequest("POST", e.Region.ECSEndpoint+"/", data)
+	ep, err := aws.ResolveEndpoint("ecs", e.Region)
 	if err != nil {
 		return err
 	}
-
-	hreq.Header.Set("Content-Type", "application/x-www-form-urlencoded; param=value")
-
-	token := e.Auth.Token()
-	if token != "" {
-		hreq.Header.Set("X-Amz-Security-Token", token)
-	}
-
-	signer := aws.NewV4Signer(e.Auth, "ecs", e.Region)
-	signer.Sign(hreq)
-
-	if debug {
-		log.Printf("%v -> {\n", hreq)
-	}
-	r, err := http.DefaultClient.Do(hreq)
-
-	if err != nil {
-		log.Printf("Error calling Amazon %v", err)
-		return err
-	}
-
-	defer r.Body.Close()
-
-	if debug {
-		dump, _ := httputil.DumpResponse(r, true)
-		log.Printf("response:\n")
-		log.Printf("%v\n}\n", string(dump))
-	}
-	if r.StatusCode != 200 {
-		return buildError(r)
-	}
-	err = xml.NewDecoder(r.Body).Decode(resp)
-	return err
+	r := &aws.Request{
+		Service:         ep.SigningName,
+		Operation:       params["Action"],
+		Region:          e.Region,
+		Auth:            e.Auth,
+		Context:         e.Context(),
+		Method:          "POST",
+		SigningRegion:   ep.SigningRegion,
+		Endpoint:        ep.URL,
+		Params:          params,
+		Result:          resp,
+		HTTPClient:      e.httpClient,
+		Clock:           e.clock,
+		Logger:          e.Logger,
+		LogLevel:        e.LogLevel,
+		Instrumentation: e.Instrumentation,
+		Handlers:        aws.ChainHandlers(handlers, aws.GlobalHandlers, e.Handlers),
+	}
+	return r.Send()
 }
 
 func buildError(r *http.Response) error {
@@ -486,6 +524,19 @@ type Task struct {
 	TaskDefinitionArn    string       `xml:"taskDefinitionArn"`
 }
 
+// ID returns the id of the task, taken from its ARN, such as "UUID" for
+// both arn:aws:ecs:region:aws_account_id:task/UUID and the newer
+// arn:aws:ecs:region:aws_account_id:task/cluster/UUID, or "" if the ARN
+// is not the one of a task.
+func (t Task) ID() string {
+	a, err := arn.Parse(t.TaskArn)
+	if err != nil || a.Service != "ecs" || a.ResourceType() != "task" {
+		return ""
+	}
+	id := a.ResourceID()
+	return id[strings.LastIndex(id, "/")+1:]
+}
+
 // DescribeTasksReq encapsulates DescribeTasks req params
 type DescribeTasksReq struct {
 	Cluster string
@@ -586,6 +637,25 @@ func (e *ECS) ListClusters(req *ListClustersReq) (
 	return resp, nil
 }
 
+// ListClustersPages returns a Pager over the pages of ListClusters results,
+// starting from req.NextToken.
+func (e *ECS) ListClustersPages(req *ListClustersReq) *aws.Pager[*ListClustersResp] {
+	return aws.NewPager(func(token string) (*ListClustersResp, string, error) {
+		var page ListClustersReq
+		if req != nil {
+			page = *req
+		}
+		if token != "" {
+			page.NextToken = token
+		}
+		resp, err := e.ListClusters(&page)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // ListContainerInstancesReq encapsulates ListContainerInstances req params
 type ListContainerInstancesReq struct {
 	Cluster    string
@@ -625,6 +695,25 @@ func (e *ECS) ListContainerInstances(req *ListContainerInstancesReq) (
 	return resp, nil
 }
 
+// ListContainerInstancesPages returns a Pager over the pages of
+// ListContainerInstances results, starting from req.NextToken.
+func (e *ECS) ListContainerInstancesPages(req *ListContainerInstancesReq) *aws.Pager[*ListContainerInstancesResp] {
+	return aws.NewPager(func(token string) (*ListContainerInstancesResp, string, error) {
+		var page ListContainerInstancesReq
+		if req != nil {
+			page = *req
+		}
+		if token != "" {
+			page.NextToken = token
+		}
+		resp, err := e.ListContainerInstances(&page)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // ListTaskDefinitionsReq encapsulates ListTaskDefinitions req params
 type ListTaskDefinitionsReq struct {
 	FamilyPrefix string
@@ -664,6 +753,25 @@ func (e *ECS) ListTaskDefinitions(req *ListTaskDefinitionsReq) (
 	return resp, nil
 }
 
+// ListTaskDefinitionsPages returns a Pager over the pages of
+// ListTaskDefinitions results, starting from req.NextToken.
+func (e *ECS) ListTaskDefinitionsPages(req *ListTaskDefinitionsReq) *aws.Pager[*ListTaskDefinitionsResp] {
+	return aws.NewPager(func(token string) (*ListTaskDefinitionsResp, string, error) {
+		var page ListTaskDefinitionsReq
+		if req != nil {
+			page = *req
+		}
+		if token != "" {
+			page.NextToken = token
+		}
+		resp, err := e.ListTaskDefinitions(&page)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // ListTasksReq encapsulates ListTasks req params
 type ListTasksReq struct {
 	Cluster           string
@@ -713,6 +821,25 @@ func (e *ECS) ListTasks(req *ListTasksReq) (
 	return resp, nil
 }
 
+// ListTasksPages returns a Pager over the pages of ListTasks results,
+// starting from req.NextToken.
+func (e *ECS) ListTasksPages(req *ListTasksReq) *aws.Pager[*ListTasksResp] {
+	return aws.NewPager(func(token string) (*ListTasksResp, string, error) {
+		var page ListTasksReq
+		if req != nil {
+			page = *req
+		}
+		if token != "" {
+			page.NextToken = token
+		}
+		resp, err := e.ListTasks(&page)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // RegisterContainerInstanceReq encapsulates RegisterContainerInstance req params
 type RegisterContainerInstanceReq struct {
 	Cluster                           string
@@ -332,6 +332,13 @@ func (s *S) TestDescribeTasks(c *C) {
 	c.Assert(resp.RequestId, Equals, "8d798a29-f083-11e1-bdfb-cb223EXAMPLE")
 }
 
+func (s *S) TestTaskID(c *C) {
+	c.Assert(Task{TaskArn: "arn:aws:ecs:us-east-1:aws_account_id:task/UUID"}.ID(), Equals, "UUID")
+	c.Assert(Task{TaskArn: "arn:aws:ecs:us-east-1:aws_account_id:task/default/UUID"}.ID(), Equals, "UUID")
+	c.Assert(Task{TaskArn: "arn:aws:ecs:us-east-1:aws_account_id:container/UUID"}.ID(), Equals, "")
+	c.Assert(Task{TaskArn: "UUID"}.ID(), Equals, "")
+}
+
 func (s *S) TestDiscoverPollEndpoint(c *C) {
 	testServer.Response(200, nil, DiscoverPollEndpointResponse)
 	req := &DiscoverPollEndpointReq{
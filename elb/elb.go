@@ -2,13 +2,13 @@
 package elb
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"net/http"
 	"net/url"
 	"sort"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/goamz/goamz/aws"
@@ -17,10 +17,51 @@ import (
 type ELB struct {
 	aws.Auth
 	aws.Region
+	httpClient *http.Client
+	ctx        context.Context
+	clock      *aws.Clock
+
+	// Logger, if set, is given the lines selected by LogLevel about
+	// requests, with credentials redacted.
+	Logger   aws.Logger
+	LogLevel aws.LogLevel
+
+	// Instrumentation, if set, is told about every attempt of the
+	// calls of elb.
+	Instrumentation aws.Instrumentation
+
+	// Handlers are run on every request of the client, after the
+	// aws.GlobalHandlers.
+	Handlers aws.Handlers
 }
 
 func New(auth aws.Auth, region aws.Region) *ELB {
-	return &ELB{auth, region}
+	return NewWithClient(auth, region, http.DefaultClient)
+}
+
+// NewWithClient creates a new ELB sending its requests with client.
+func NewWithClient(auth aws.Auth, region aws.Region, client *http.Client) *ELB {
+	return &ELB{Auth: auth, Region: region, httpClient: client, clock: &aws.Clock{}}
+}
+
+// WithContext returns a shallow copy of elb whose requests are bound to
+// ctx, so that they are abandoned when ctx is cancelled or its deadline
+// expires. The provided ctx must be non-nil.
+func (elb *ELB) WithContext(ctx context.Context) *ELB {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *elb
+	c.ctx = ctx
+	return &c
+}
+
+// Context returns the context requests made by elb are bound to.
+func (elb *ELB) Context() context.Context {
+	if elb.ctx != nil {
+		return elb.ctx
+	}
+	return context.Background()
 }
 
 // The CreateLoadBalancer type encapsulates options for the respective request in AWS.
@@ -328,34 +369,40 @@ func (elb *ELB) RemoveTags(elbName string, tagKeys []string) (*SimpleResp, error
 	return response, nil
 }
 
+// handlers are the handlers of ELB requests, run before the
+// aws.GlobalHandlers and the Handlers of the client.
+var handlers = func() aws.Handlers {
+	h := aws.QueryHandlers()
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("elb.UnmarshalError", buildError))
+	return h
+}()
+
 func (elb *ELB) query(params map[string]string, resp interface{}) error {
 	params["Version"] = "2012-06-01"
 	params["Timestamp"] = time.Now().In(time.UTC).Format(time.RFC3339)
-	data := strings.NewReader(multimap(params).Encode())
-	hreq, err := http.NewRequest("GET", elb.Region.ELBEndpoint+"/", data)
+	ep, err := aws.ResolveEndpoint("elasticloadbalancing", elb.Region)
 	if err != nil {
 		return err
 	}
-
-	hreq.URL.RawQuery = multimap(params).Encode()
-	token := elb.Auth.Token()
-	if token != "" {
-		hreq.Header.Set("X-Amz-Security-Token", token)
-	}
-
-	signer := aws.NewV4Signer(elb.Auth, "elasticloadbalancing", elb.Region)
-	signer.Sign(hreq)
-
-	r, err := http.DefaultClient.Do(hreq)
-
-	if err != nil {
-		return err
-	}
-	defer r.Body.Close()
-	if r.StatusCode != 200 {
-		return buildError(r)
-	}
-	return xml.NewDecoder(r.Body).Decode(resp)
+	r := &aws.Request{
+		Service:         ep.SigningName,
+		Operation:       params["Action"],
+		Region:          elb.Region,
+		Auth:            elb.Auth,
+		Context:         elb.Context(),
+		Method:          "GET",
+		SigningRegion:   ep.SigningRegion,
+		Endpoint:        ep.URL,
+		Params:          params,
+		Result:          resp,
+		HTTPClient:      elb.httpClient,
+		Clock:           elb.clock,
+		Logger:          elb.Logger,
+		LogLevel:        elb.LogLevel,
+		Instrumentation: elb.Instrumentation,
+		Handlers:        aws.ChainHandlers(handlers, aws.GlobalHandlers, elb.Handlers),
+	}
+	return r.Send()
 }
 
 // Error encapsulates an error returned by ELB.
@@ -366,6 +413,8 @@ type Error struct {
 	Code string
 	// The human-oriented error message
 	Message string
+	// A unique ID for the failed request
+	RequestId string
 }
 
 func (err *Error) Error() string {
@@ -376,8 +425,16 @@ func (err *Error) Error() string {
 	return fmt.Sprintf("%s (%s)", err.Message, err.Code)
 }
 
+// HTTPStatusCode, ErrorCode, ErrorMessage and RequestID implement
+// aws.APIError.
+func (err *Error) HTTPStatusCode() int  { return err.StatusCode }
+func (err *Error) ErrorCode() string    { return err.Code }
+func (err *Error) ErrorMessage() string { return err.Message }
+func (err *Error) RequestID() string    { return err.RequestId }
+
 type xmlErrors struct {
-	Errors []Error `xml:"Error"`
+	RequestId string
+	Errors    []Error `xml:"Error"`
 }
 
 func buildError(r *http.Response) error {
@@ -389,6 +446,7 @@ func buildError(r *http.Response) error {
 	if len(errors.Errors) > 0 {
 		err = errors.Errors[0]
 	}
+	err.RequestId = errors.RequestId
 	err.StatusCode = r.StatusCode
 	if err.Message == "" {
 		err.Message = r.Status
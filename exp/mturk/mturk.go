@@ -14,25 +14,43 @@
 package mturk
 
 import (
+	"context"
 	"encoding/xml"
-	"errors"
 	"fmt"
 	"github.com/goamz/goamz/aws"
 	"net/http"
-	//"net/http/httputil"
 	"net/url"
 	"strconv"
 	"strings"
-	"time"
 )
 
 type MTurk struct {
 	aws.Auth
-	URL *url.URL
+	URL        *url.URL
+	httpClient *http.Client
+	ctx        context.Context
+	clock      *aws.Clock
+
+	// Logger, if set, is given the lines selected by LogLevel about
+	// requests, with credentials redacted.
+	Logger   aws.Logger
+	LogLevel aws.LogLevel
+
+	// Instrumentation, if set, is told about every call of mt.
+	Instrumentation aws.Instrumentation
+
+	// Handlers are run on every request of the client, after the
+	// aws.GlobalHandlers.
+	Handlers aws.Handlers
 }
 
 func New(auth aws.Auth, sandbox bool) *MTurk {
-	mt := &MTurk{Auth: auth}
+	return NewWithClient(auth, sandbox, http.DefaultClient)
+}
+
+// NewWithClient creates a new MTurk sending its requests with client.
+func NewWithClient(auth aws.Auth, sandbox bool, client *http.Client) *MTurk {
+	mt := &MTurk{Auth: auth, httpClient: client, clock: &aws.Clock{}}
 	var err error
 	if sandbox {
 		mt.URL, err = url.Parse("https://mechanicalturk.sandbox.amazonaws.com/")
@@ -45,6 +63,26 @@ func New(auth aws.Auth, sandbox bool) *MTurk {
 	return mt
 }
 
+// WithContext returns a shallow copy of mt whose requests are bound to
+// ctx, so that they are abandoned when ctx is cancelled or its deadline
+// expires. The provided ctx must be non-nil.
+func (mt *MTurk) WithContext(ctx context.Context) *MTurk {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *mt
+	c.ctx = ctx
+	return &c
+}
+
+// Context returns the context requests made by mt are bound to.
+func (mt *MTurk) Context() context.Context {
+	if mt.ctx != nil {
+		return mt.ctx
+	}
+	return context.Background()
+}
+
 // ----------------------------------------------------------------------------
 // Request dispatching logic.
 
@@ -60,6 +98,13 @@ func (err *Error) Error() string {
 	return err.Message
 }
 
+// HTTPStatusCode, ErrorCode, ErrorMessage and RequestID implement
+// aws.APIError.
+func (err *Error) HTTPStatusCode() int  { return err.StatusCode }
+func (err *Error) ErrorCode() string    { return err.Code }
+func (err *Error) ErrorMessage() string { return err.Message }
+func (err *Error) RequestID() string    { return err.RequestId }
+
 // The request stanza included in several response types, for example
 // in a "CreateHITResponse".  http://goo.gl/qGeKf
 type xmlRequest struct {
@@ -433,41 +478,45 @@ func (mt *MTurk) SearchHITs() (s *SearchHITsResult, err error) {
 // to the server.  It then unmarshals the response in to the "resp"
 // parameter using xml.Unmarshal()
 func (mt *MTurk) query(params map[string]string, operation string, resp interface{}) error {
-	service := "AWSMechanicalTurkRequester"
-	timestamp := time.Now().UTC().Format("2006-01-02T15:04:05Z")
-
-	params["AWSAccessKeyId"] = mt.Auth.AccessKey
-	params["Service"] = service
-	params["Timestamp"] = timestamp
+	params["Service"] = "AWSMechanicalTurkRequester"
+	params["Timestamp"] = mt.clock.Now().Format("2006-01-02T15:04:05Z")
 	params["Operation"] = operation
-
-	// make a copy
-	url := *mt.URL
-
-	sign(mt.Auth, service, operation, timestamp, params)
-	url.RawQuery = multimap(params).Encode()
-	r, err := http.Get(url.String())
-	if err != nil {
-		return err
+	r := &aws.Request{
+		Service:         "mturk",
+		Operation:       operation,
+		Auth:            mt.Auth,
+		Context:         mt.Context(),
+		Method:          "GET",
+		Endpoint:        mt.URL.String(),
+		Params:          params,
+		HTTPClient:      mt.httpClient,
+		Result:          resp,
+		Clock:           mt.clock,
+		Logger:          mt.Logger,
+		LogLevel:        mt.LogLevel,
+		Instrumentation: mt.Instrumentation,
+		Handlers:        aws.ChainHandlers(handlers, aws.GlobalHandlers, mt.Handlers),
 	}
-	//dump, _ := httputil.DumpResponse(r, true)
-	//println("DUMP:\n", string(dump))
-	if r.StatusCode != 200 {
-		return errors.New(fmt.Sprintf("%d: unexpected status code", r.StatusCode))
-	}
-	dec := xml.NewDecoder(r.Body)
-	err = dec.Decode(resp)
-	r.Body.Close()
-	return err
+	return r.Send()
 }
 
-func multimap(p map[string]string) url.Values {
-	q := make(url.Values, len(p))
-	for k, v := range p {
-		q[k] = []string{v}
-	}
-	return q
-}
+// handlers are the handlers of Mechanical Turk requests, run before the
+// aws.GlobalHandlers and the Handlers of the client.
+var handlers = func() aws.Handlers {
+	h := aws.QueryHandlers()
+	h.Sign.Remove(aws.V4SignHandler.Name)
+	h.Sign.PushBack(aws.V2SignHandler("mturk.Sign", func(auth aws.Auth, method, path string, params map[string]string, host string) {
+		params["AWSAccessKeyId"] = auth.AccessKey
+		sign(auth, params["Service"], params["Operation"], params["Timestamp"], params)
+	}))
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("mturk.UnmarshalError", func(resp *http.Response) error {
+		return &Error{
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("%d: unexpected status code", resp.StatusCode),
+		}
+	}))
+	return h
+}()
 
 func xmlEncode(i interface{}) (s string, err error) {
 	var buf []byte
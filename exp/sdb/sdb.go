@@ -21,28 +21,67 @@ package sdb
 //
 
 import (
+	"context"
 	"encoding/xml"
 	"github.com/goamz/goamz/aws"
-	"log"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"strconv"
 	"time"
 )
 
-const debug = false
-
 // The SDB type encapsulates operations with a specific SimpleDB region.
 type SDB struct {
 	aws.Auth
 	aws.Region
-	private byte // Reserve the right of using private data.
+
+	// Logger, if set, is given the lines selected by LogLevel about
+	// requests, with credentials redacted.
+	Logger   aws.Logger
+	LogLevel aws.LogLevel
+
+	// Instrumentation, if set, is told about every attempt of the
+	// calls of sdb.
+	Instrumentation aws.Instrumentation
+
+	// Handlers are run on every request of the client, after the
+	// aws.GlobalHandlers.
+	Handlers aws.Handlers
+
+	httpClient *http.Client
+	ctx        context.Context
+	clock      *aws.Clock
+	private    byte // Reserve the right of using private data.
 }
 
 // New creates a new SDB.
 func New(auth aws.Auth, region aws.Region) *SDB {
-	return &SDB{auth, region, 0}
+	return NewWithClient(auth, region, http.DefaultClient)
+}
+
+// NewWithClient creates a new SDB sending its requests with client.
+func NewWithClient(auth aws.Auth, region aws.Region, client *http.Client) *SDB {
+	return &SDB{Auth: auth, Region: region, httpClient: client, clock: &aws.Clock{}}
+}
+
+// WithContext returns a shallow copy of sdb whose requests are bound to
+// ctx, so that they are abandoned when ctx is cancelled or its deadline
+// expires. The provided ctx must be non-nil.
+func (sdb *SDB) WithContext(ctx context.Context) *SDB {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *sdb
+	c.ctx = ctx
+	return &c
+}
+
+// Context returns the context requests made by sdb are bound to.
+func (sdb *SDB) Context() context.Context {
+	if sdb.ctx != nil {
+		return sdb.ctx
+	}
+	return context.Background()
 }
 
 // The Domain type represents a collection of items that are described
@@ -57,6 +96,11 @@ func (sdb *SDB) Domain(name string) *Domain {
 	return &Domain{sdb, name}
 }
 
+// WithContext returns a copy of domain whose requests are bound to ctx.
+func (domain *Domain) WithContext(ctx context.Context) *Domain {
+	return domain.SDB.WithContext(ctx).Domain(domain.Name)
+}
+
 // The Item type represent individual objects that contain one or more
 // name-value attributes stored within a SDB Domain as rows.
 type Item struct {
@@ -70,6 +114,11 @@ func (domain *Domain) Item(name string) *Item {
 	return &Item{domain.SDB, domain, name}
 }
 
+// WithContext returns a copy of item whose requests are bound to ctx.
+func (item *Item) WithContext(ctx context.Context) *Item {
+	return item.Domain.WithContext(ctx).Item(item.Name)
+}
+
 // The Attr type represent categories of data that can be assigned to items.
 type Attr struct {
 	Name  string
@@ -114,6 +163,17 @@ func (sdb *SDB) ListDomainsN(maxDomains int, nextToken string) (resp *ListDomain
 	return
 }
 
+// ListDomainsNPages returns a Pager over the pages of ListDomainsN results.
+func (sdb *SDB) ListDomainsNPages(maxDomains int) *aws.Pager[*ListDomainsResp] {
+	return aws.NewPager(func(token string) (*ListDomainsResp, string, error) {
+		resp, err := sdb.ListDomainsN(maxDomains, token)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // --- SelectExpression
 
 // Response to a Select request.
@@ -300,6 +360,13 @@ func (err *Error) Error() string {
 	return err.Message
 }
 
+// HTTPStatusCode, ErrorCode, ErrorMessage and RequestID implement
+// aws.APIError.
+func (err *Error) HTTPStatusCode() int  { return err.StatusCode }
+func (err *Error) ErrorCode() string    { return err.Code }
+func (err *Error) ErrorMessage() string { return err.Message }
+func (err *Error) RequestID() string    { return err.RequestId }
+
 // SimpleResp represents a response to an SDB request which on success
 // will return no other information besides ResponseMetadata.
 type SimpleResp struct {
@@ -332,10 +399,6 @@ func (domain *Domain) query(item *Item, params url.Values, headers http.Header,
 }
 
 func (sdb *SDB) query(domain *Domain, item *Item, params url.Values, headers http.Header, resp interface{}) error {
-	// all SimpleDB operations have path="/"
-	method := "GET"
-	path := "/"
-
 	// if we have been given no headers or params, create them
 	if headers == nil {
 		headers = map[string][]string{}
@@ -346,7 +409,6 @@ func (sdb *SDB) query(domain *Domain, item *Item, params url.Values, headers htt
 
 	// setup some default parameters
 	params["Version"] = []string{"2009-04-15"}
-	params["Timestamp"] = []string{time.Now().UTC().Format(time.RFC3339)}
 
 	// set the DomainName param (every request must have one)
 	if domain != nil {
@@ -358,54 +420,57 @@ func (sdb *SDB) query(domain *Domain, item *Item, params url.Values, headers htt
 		params["ItemName"] = []string{item.Name}
 	}
 
-	// check the endpoint URL
-	u, err := url.Parse(sdb.Region.SDBEndpoint)
-	if err != nil {
-		return err
-	}
-	headers["Host"] = []string{u.Host}
-	sign(sdb.Auth, method, path, params, headers)
-
-	u.Path = path
-	if len(params) > 0 {
-		u.RawQuery = params.Encode()
-	}
-	req := http.Request{
-		URL:        u,
-		Method:     method,
-		ProtoMajor: 1,
-		ProtoMinor: 1,
-		Close:      true,
-		Header:     headers,
-	}
-
-	if v, ok := headers["Content-Length"]; ok {
-		req.ContentLength, _ = strconv.ParseInt(v[0], 10, 64)
-		delete(headers, "Content-Length")
-	}
+	params["Timestamp"] = []string{sdb.clock.Now().Format(time.RFC3339)}
 
-	r, err := http.DefaultClient.Do(&req)
+	ep, err := aws.ResolveEndpoint("sdb", sdb.Region)
 	if err != nil {
 		return err
 	}
-	defer r.Body.Close()
-
-	if debug {
-		dump, _ := httputil.DumpResponse(r, true)
-		log.Printf("response:\n")
-		log.Printf("%v\n}\n", string(dump))
+	flat := make(map[string]string, len(params))
+	for k, v := range params {
+		flat[k] = v[0]
 	}
-
-	// status code is always 200 when successful (since we're always doing a GET)
-	if r.StatusCode != 200 {
-		return buildError(r)
+	r := &aws.Request{
+		Service:         ep.SigningName,
+		Operation:       params.Get("Action"),
+		Region:          sdb.Region,
+		Auth:            sdb.Auth,
+		Context:         sdb.Context(),
+		Method:          "GET",
+		SigningRegion:   ep.SigningRegion,
+		Endpoint:        ep.URL,
+		Params:          flat,
+		Header:          headers,
+		HTTPClient:      sdb.httpClient,
+		Result:          resp,
+		Clock:           sdb.clock,
+		Logger:          sdb.Logger,
+		LogLevel:        sdb.LogLevel,
+		Instrumentation: sdb.Instrumentation,
+		Handlers:        aws.ChainHandlers(handlers, aws.GlobalHandlers, sdb.Handlers),
 	}
-
-	// everything was fine, so unmarshal the XML and return what it's err is (if any)
-	err = xml.NewDecoder(r.Body).Decode(resp)
-	return err
+	return r.Send()
 }
 
+// handlers are the handlers of SimpleDB requests, run before the
+// aws.GlobalHandlers and the Handlers of the client.
+var handlers = func() aws.Handlers {
+	h := aws.QueryHandlers()
+	h.Sign.Remove(aws.V4SignHandler.Name)
+	h.Sign.PushBack(aws.V2SignHandler("sdb.Sign", func(auth aws.Auth, method, path string, params map[string]string, host string) {
+		values := make(url.Values, len(params))
+		for k, v := range params {
+			values[k] = []string{v}
+		}
+		sign(auth, method, path, values, http.Header{"Host": {host}})
+		for k, v := range values {
+			params[k] = v[0]
+		}
+	}))
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("sdb.UnmarshalError", buildError))
+	return h
+}()
+
 func makeParams(action string) map[string][]string {
 	params := make(map[string][]string)
 	params["Action"] = []string{action}
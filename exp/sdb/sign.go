@@ -26,6 +26,9 @@ func sign(auth aws.Auth, method, path string, params url.Values, headers http.He
 		}
 	}
 
+	// a previous signature must not be signed
+	delete(params, "Signature")
+
 	// set up some defaults used for signing the request
 	params["AWSAccessKeyId"] = []string{auth.AccessKey}
 	params["SignatureVersion"] = []string{"2"}
@@ -2,28 +2,67 @@
 package ses
 
 import (
+	"context"
 	"encoding/xml"
 	"github.com/goamz/goamz/aws"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strconv"
-	"strings"
 )
 
 type SES struct {
 	auth   aws.Auth
 	region aws.Region
 	client *http.Client
+	ctx    context.Context
+	clock  *aws.Clock
+
+	// Logger, if set, is given the lines selected by LogLevel about
+	// requests, with credentials redacted.
+	Logger   aws.Logger
+	LogLevel aws.LogLevel
+
+	// Instrumentation, if set, is told about every attempt of the
+	// calls of ses.
+	Instrumentation aws.Instrumentation
+
+	// Handlers are run on every request of the client, after the
+	// aws.GlobalHandlers.
+	Handlers aws.Handlers
 }
 
 // Initializes a pointer to an SES struct which can be used
 // to perform SES API calls.
 func NewSES(auth aws.Auth, region aws.Region) *SES {
-	ses := SES{auth, region, nil}
+	return NewSESWithClient(auth, region, &http.Client{})
+}
+
+// NewSESWithClient is like NewSES, with requests sent by client.
+func NewSESWithClient(auth aws.Auth, region aws.Region, client *http.Client) *SES {
+	ses := SES{auth: auth, region: region, client: client, clock: &aws.Clock{}}
 	return &ses
 }
 
+// WithContext returns a shallow copy of ses whose requests are bound to
+// ctx, so that they are abandoned when ctx is cancelled or its deadline
+// expires. The provided ctx must be non-nil.
+func (ses *SES) WithContext(ctx context.Context) *SES {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *ses
+	c.ctx = ctx
+	return &c
+}
+
+// Context returns the context requests made by ses are bound to.
+func (ses *SES) Context() context.Context {
+	if ses.ctx != nil {
+		return ses.ctx
+	}
+	return context.Background()
+}
+
 // Sends an email to the specifications stored in the Email struct.
 func (ses *SES) SendEmail(email *Email) error {
 	data := make(url.Values)
@@ -98,52 +137,68 @@ func (ses *SES) SendEmail(email *Email) error {
 
 // Do an SES POST action.
 func (ses *SES) doPost(action string, data url.Values) error {
-	req := http.Request{
-		Method:     "POST",
-		ProtoMajor: 1,
-		ProtoMinor: 1,
-		Close:      true,
-		Header:     http.Header{}}
-
-	URL, err := url.Parse(ses.region.SESEndpoint)
+	ep, err := aws.ResolveEndpoint("email", ses.region)
 	if err != nil {
 		return err
 	}
-	URL.Path = "/"
-
-	req.URL = URL
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	token := ses.auth.Token()
-	if token != "" {
-		req.Header.Set("X-Amz-Security-Token", token)
+	params := make(map[string]string, len(data)+1)
+	for k, v := range data {
+		params[k] = v[0]
 	}
-	sign(ses.auth, "POST", req.Header)
-
-	data.Add("AWSAccessKeyId", ses.auth.AccessKey)
-	data.Add("Action", action)
-
-	body := data.Encode()
-	req.Header.Add("Content-Length", strconv.Itoa(len(body)))
-	req.Body = ioutil.NopCloser(strings.NewReader(body))
-
-	if ses.client == nil {
-		ses.client = &http.Client{}
+	params["Action"] = action
+	r := &aws.Request{
+		Service:         ep.SigningName,
+		Operation:       action,
+		Region:          ses.region,
+		Auth:            ses.auth,
+		Context:         ses.Context(),
+		Method:          "POST",
+		SigningRegion:   ep.SigningRegion,
+		Endpoint:        ep.URL,
+		Params:          params,
+		HTTPClient:      ses.client,
+		Clock:           ses.clock,
+		Logger:          ses.Logger,
+		LogLevel:        ses.LogLevel,
+		Instrumentation: ses.Instrumentation,
+		Handlers:        aws.ChainHandlers(handlers, aws.GlobalHandlers, ses.Handlers),
 	}
+	return r.Send()
+}
 
-	resp, err := ses.client.Do(&req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode > 204 {
+// handlers are the handlers of SES requests, run before the
+// aws.GlobalHandlers and the Handlers of the client.
+var handlers = func() aws.Handlers {
+	h := aws.QueryHandlers()
+	h.Build.PushFront(aws.NamedHandler{Name: "ses.Build", Fn: func(r *aws.Request) {
+		auth, err := r.Auth.Resolve()
+		if err != nil {
+			r.Error = err
+			return
+		}
+		r.Params["AWSAccessKeyId"] = auth.AccessKey
+	}})
+	h.Sign.Remove(aws.V4SignHandler.Name)
+	h.Sign.PushBack(aws.NamedHandler{Name: "ses.Sign", Fn: func(r *aws.Request) {
+		auth, err := r.Auth.Resolve()
+		if err != nil {
+			r.Error = err
+			return
+		}
+		if token := auth.Token(); token != "" {
+			r.HTTPRequest.Header.Set("X-Amz-Security-Token", token)
+		}
+		sign(auth, r.HTTPRequest.Method, r.HTTPRequest.Header, r.Clock.Now())
+	}})
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("ses.UnmarshalError", func(resp *http.Response) error {
 		return buildError(resp)
-	}
-
-	return nil
-}
+	}))
+	return h
+}()
 
 func buildError(r *http.Response) *SESError {
 	rootElem := errorResponse{}
 	xml.NewDecoder(r.Body).Decode(&rootElem)
+	rootElem.Error.StatusCode = r.StatusCode
 	return &rootElem.Error
 }
@@ -38,11 +38,12 @@ type Message struct {
 
 // SES Error structure.
 type SESError struct {
-	Type      string
-	Code      string
-	Message   string
-	Detail    string
-	RequestId string
+	StatusCode int `xml:"-"`
+	Type       string
+	Code       string
+	Message    string
+	Detail     string
+	RequestId  string
 }
 
 type errorResponse struct {
@@ -53,6 +54,13 @@ func (err *SESError) Error() string {
 	return err.Message
 }
 
+// HTTPStatusCode, ErrorCode, ErrorMessage and RequestID implement
+// aws.APIError.
+func (err *SESError) HTTPStatusCode() int  { return err.StatusCode }
+func (err *SESError) ErrorCode() string    { return err.Code }
+func (err *SESError) ErrorMessage() string { return err.Message }
+func (err *SESError) RequestID() string    { return err.RequestId }
+
 // Returns a pointer to an empty but initialized Email.
 func NewEmail() *Email {
 	return &Email{
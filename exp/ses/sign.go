@@ -14,9 +14,10 @@ const (
 	AMZ_DATE_STYLE = "Mon, 02 Jan 2006 15:04:05 -0700"
 )
 
-// Sign SES request as dictated by Amazon's Version 3 signature method.
-func sign(auth aws.Auth, method string, headers map[string][]string) {
-	date := time.Now().UTC().Format(AMZ_DATE_STYLE)
+// Sign SES request as dictated by Amazon's Version 3 signature method,
+// dated at t.
+func sign(auth aws.Auth, method string, headers map[string][]string, t time.Time) {
+	date := t.UTC().Format(AMZ_DATE_STYLE)
 	h := hmac.New(sha256.New, []byte(auth.SecretKey))
 	h.Write([]byte(date))
 	signature := base64.StdEncoding.EncodeToString(h.Sum(nil))
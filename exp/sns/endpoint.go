@@ -3,6 +3,8 @@ package sns
 import (
 	"fmt"
 	"strconv"
+
+	"github.com/goamz/goamz/aws"
 )
 
 type DeleteEndpointResponse struct {
@@ -33,6 +35,7 @@ type PlatformEndpoints struct {
 
 type ListEndpointsByPlatformApplicationResponse struct {
 	Endpoints []PlatformEndpoints `xml:"ListEndpointsByPlatformApplicationResult>Endpoints>member"`
+	NextToken string              `xml:"ListEndpointsByPlatformApplicationResult>NextToken"`
 	ResponseMetadata
 }
 
@@ -113,6 +116,18 @@ func (sns *SNS) ListEndpointsByPlatformApplication(platformApplicationArn, nextT
 
 }
 
+// ListEndpointsByPlatformApplicationPages returns a Pager over the pages of
+// ListEndpointsByPlatformApplication results.
+func (sns *SNS) ListEndpointsByPlatformApplicationPages(platformApplicationArn string) *aws.Pager[*ListEndpointsByPlatformApplicationResponse] {
+	return aws.NewPager(func(token string) (*ListEndpointsByPlatformApplicationResponse, string, error) {
+		resp, err := sns.ListEndpointsByPlatformApplication(platformApplicationArn, token)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // SetEndpointAttributes
 //
 // See http://goo.gl/GTktCj for more detail.
@@ -3,6 +3,8 @@ package sns
 import (
 	"fmt"
 	"strconv"
+
+	"github.com/goamz/goamz/aws"
 )
 
 type CreatePlatformApplicationResponse struct {
@@ -40,7 +42,7 @@ type PlatformApplication struct {
 }
 
 type ListPlatformApplicationsResponse struct {
-	NextToken            string
+	NextToken            string                `xml:"ListPlatformApplicationsResult>NextToken"`
 	PlatformApplications []PlatformApplication `xml:"ListPlatformApplicationsResult>PlatformApplications>member"`
 	ResponseMetadata
 }
@@ -116,6 +118,18 @@ func (sns *SNS) ListPlatformApplications(nextToken string) (resp *ListPlatformAp
 	return
 }
 
+// ListPlatformApplicationsPages returns a Pager over the pages of
+// ListPlatformApplications results.
+func (sns *SNS) ListPlatformApplicationsPages() *aws.Pager[*ListPlatformApplicationsResponse] {
+	return aws.NewPager(func(token string) (*ListPlatformApplicationsResponse, string, error) {
+		resp, err := sns.ListPlatformApplications(token)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // SetPlatformApplicationAttributes
 //
 // See http://goo.gl/RWnzzb for more detail.
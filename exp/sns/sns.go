@@ -16,6 +16,7 @@ package sns
 // BUG(niemeyer): Package needs documentation.
 
 import (
+	"context"
 	"encoding/xml"
 	"net/http"
 	"net/url"
@@ -28,6 +29,23 @@ import (
 type SNS struct {
 	aws.Auth
 	aws.Region
+	httpClient *http.Client
+	ctx        context.Context
+	clock      *aws.Clock
+
+	// Logger, if set, is given the lines selected by LogLevel about
+	// requests, with credentials redacted.
+	Logger   aws.Logger
+	LogLevel aws.LogLevel
+
+	// Instrumentation, if set, is told about every attempt of the
+	// calls of exp/sns.
+	Instrumentation aws.Instrumentation
+
+	// Handlers are run on every request of the client, after the
+	// aws.GlobalHandlers.
+	Handlers aws.Handlers
+
 	private byte // Reserve the right of using private data.
 }
 
@@ -42,7 +60,32 @@ type ResponseMetadata struct {
 }
 
 func New(auth aws.Auth, region aws.Region) *SNS {
-	return &SNS{auth, region, 0}
+	return NewWithClient(auth, region, http.DefaultClient)
+}
+
+// NewWithClient creates a new SNS sending its requests with client.
+func NewWithClient(auth aws.Auth, region aws.Region, client *http.Client) *SNS {
+	return &SNS{Auth: auth, Region: region, httpClient: client, clock: &aws.Clock{}}
+}
+
+// WithContext returns a shallow copy of sns whose requests are bound to
+// ctx, so that they are abandoned when ctx is cancelled or its deadline
+// expires. The provided ctx must be non-nil.
+func (sns *SNS) WithContext(ctx context.Context) *SNS {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *sns
+	c.ctx = ctx
+	return &c
+}
+
+// Context returns the context requests made by sns are bound to.
+func (sns *SNS) Context() context.Context {
+	if sns.ctx != nil {
+		return sns.ctx
+	}
+	return context.Background()
 }
 
 func makeParams(action string) map[string]string {
@@ -62,31 +105,53 @@ func (err *Error) Error() string {
 	return err.Message
 }
 
+// HTTPStatusCode, ErrorCode, ErrorMessage and RequestID implement
+// aws.APIError.
+func (err *Error) HTTPStatusCode() int  { return err.StatusCode }
+func (err *Error) ErrorCode() string    { return err.Code }
+func (err *Error) ErrorMessage() string { return err.Message }
+func (err *Error) RequestID() string    { return err.RequestId }
+
 type xmlErrors struct {
 	RequestId string
 	Errors    []Error `xml:"Errors>Error"`
 }
 
+// handlers are the handlers of SNS requests, run before the
+// aws.GlobalHandlers and the Handlers of the client.
+var handlers = func() aws.Handlers {
+	h := aws.QueryHandlers()
+	h.Sign.Remove(aws.V4SignHandler.Name)
+	h.Sign.PushBack(aws.V2SignHandler("sns.Sign", sign))
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("sns.UnmarshalError", buildError))
+	return h
+}()
+
 func (sns *SNS) query(params map[string]string, resp interface{}) error {
 	params["Timestamp"] = time.Now().UTC().Format(time.RFC3339)
-	u, err := url.Parse(sns.Region.SNSEndpoint)
+	ep, err := aws.ResolveEndpoint("sns", sns.Region)
 	if err != nil {
 		return err
 	}
-
-	sign(sns.Auth, "GET", "/", params, u.Host)
-	u.RawQuery = multimap(params).Encode()
-	r, err := http.Get(u.String())
-	if err != nil {
-		return err
-	}
-	defer r.Body.Close()
-
-	if r.StatusCode != 200 {
-		return buildError(r)
+	r := &aws.Request{
+		Service:         ep.SigningName,
+		Operation:       params["Action"],
+		Region:          sns.Region,
+		Auth:            sns.Auth,
+		Context:         sns.Context(),
+		Method:          "GET",
+		SigningRegion:   ep.SigningRegion,
+		Endpoint:        ep.URL,
+		Params:          params,
+		Result:          resp,
+		HTTPClient:      sns.httpClient,
+		Clock:           sns.clock,
+		Logger:          sns.Logger,
+		LogLevel:        sns.LogLevel,
+		Instrumentation: sns.Instrumentation,
+		Handlers:        aws.ChainHandlers(handlers, aws.GlobalHandlers, sns.Handlers),
 	}
-	err = xml.NewDecoder(r.Body).Decode(resp)
-	return err
+	return r.Send()
 }
 
 func buildError(r *http.Response) error {
@@ -46,6 +46,13 @@ func (s *S) TestListTopicsOK(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (s *S) TestTopicName(c *C) {
+	topic := &sns.Topic{TopicArn: "arn:aws:sns:us-east-1:123456789012:My-Topic"}
+	c.Assert(topic.Name(), Equals, "My-Topic")
+	topic.TopicArn = "My-Topic"
+	c.Assert(topic.Name(), Equals, "")
+}
+
 func (s *S) TestCreateTopic(c *C) {
 	testServer.Response(200, nil, TestCreateTopicXmlOK)
 
@@ -1,5 +1,9 @@
 package sns
 
+import (
+	"github.com/goamz/goamz/aws"
+)
+
 type Subscription struct {
 	Endpoint        string
 	Owner           string
@@ -10,7 +14,7 @@ type Subscription struct {
 
 type ListSubscriptionsResp struct {
 	Subscriptions []Subscription `xml:"ListSubscriptionsResult>Subscriptions>member"`
-	NextToken     string
+	NextToken     string         `xml:"ListSubscriptionsResult>NextToken"`
 	ResponseMetadata
 }
 
@@ -49,6 +53,7 @@ type ConfirmSubscriptionOpt struct {
 
 type ListSubscriptionByTopicResponse struct {
 	Subscriptions []Subscription `xml:"ListSubscriptionsByTopicResult>Subscriptions>member"`
+	NextToken     string         `xml:"ListSubscriptionsByTopicResult>NextToken"`
 	ResponseMetadata
 }
 
@@ -147,6 +152,21 @@ func (sns *SNS) ListSubscriptions(NextToken *string) (resp *ListSubscriptionsRes
 	return
 }
 
+// ListSubscriptionsPages returns a Pager over the pages of ListSubscriptions results.
+func (sns *SNS) ListSubscriptionsPages() *aws.Pager[*ListSubscriptionsResp] {
+	return aws.NewPager(func(token string) (*ListSubscriptionsResp, string, error) {
+		var nextToken *string
+		if token != "" {
+			nextToken = &token
+		}
+		resp, err := sns.ListSubscriptions(nextToken)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // ListSubscriptionByTopic
 //
 // See http://goo.gl/LaVcC for more details.
@@ -163,3 +183,22 @@ func (sns *SNS) ListSubscriptionByTopic(options *ListSubscriptionByTopicOpt) (re
 	err = sns.query(params, resp)
 	return
 }
+
+// ListSubscriptionByTopicPages returns a Pager over the pages of
+// ListSubscriptionByTopic results, starting from options.NextToken.
+func (sns *SNS) ListSubscriptionByTopicPages(options *ListSubscriptionByTopicOpt) *aws.Pager[*ListSubscriptionByTopicResponse] {
+	return aws.NewPager(func(token string) (*ListSubscriptionByTopicResponse, string, error) {
+		var page ListSubscriptionByTopicOpt
+		if options != nil {
+			page = *options
+		}
+		if token != "" {
+			page.NextToken = token
+		}
+		resp, err := sns.ListSubscriptionByTopic(&page)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
@@ -2,6 +2,9 @@ package sns
 
 import (
 	"errors"
+
+	"github.com/goamz/goamz/arn"
+	"github.com/goamz/goamz/aws"
 )
 
 type Topic struct {
@@ -9,9 +12,19 @@ type Topic struct {
 	TopicArn string
 }
 
+// Name returns the name of the topic, taken from its ARN, or "" if the
+// ARN is not valid.
+func (topic *Topic) Name() string {
+	a, err := arn.Parse(topic.TopicArn)
+	if err != nil {
+		return ""
+	}
+	return a.ResourceID()
+}
+
 type ListTopicsResp struct {
 	Topics    []Topic `xml:"ListTopicsResult>Topics>member"`
-	NextToken string
+	NextToken string  `xml:"ListTopicsResult>NextToken"`
 	ResponseMetadata
 }
 
@@ -50,6 +63,21 @@ func (sns *SNS) ListTopics(NextToken *string) (resp *ListTopicsResp, err error)
 	return
 }
 
+// ListTopicsPages returns a Pager over the pages of ListTopics results.
+func (sns *SNS) ListTopicsPages() *aws.Pager[*ListTopicsResp] {
+	return aws.NewPager(func(token string) (*ListTopicsResp, string, error) {
+		var nextToken *string
+		if token != "" {
+			nextToken = &token
+		}
+		resp, err := sns.ListTopics(nextToken)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.NextToken, nil
+	})
+}
+
 // CreateTopic
 //
 // See http://goo.gl/m9aAt for more details.
@@ -3,13 +3,14 @@
 package iam
 
 import (
+	"context"
 	"encoding/xml"
 	"net/http"
 	"net/url"
 	"strconv"
-	"strings"
 	"time"
 
+	"github.com/goamz/goamz/arn"
 	"github.com/goamz/goamz/aws"
 )
 
@@ -18,64 +19,96 @@ type IAM struct {
 	aws.Auth
 	aws.Region
 	httpClient *http.Client
+	ctx        context.Context
+	clock      *aws.Clock
+
+	// Logger, if set, is given the lines selected by LogLevel about
+	// requests, with credentials redacted.
+	Logger   aws.Logger
+	LogLevel aws.LogLevel
+
+	// Instrumentation, if set, is told about every attempt of the
+	// calls of iam.
+	Instrumentation aws.Instrumentation
+
+	// Handlers are run on every request of the client, after the
+	// aws.GlobalHandlers.
+	Handlers aws.Handlers
 }
 
 // New creates a new IAM instance.
 func New(auth aws.Auth, region aws.Region) *IAM {
-	return NewWithClient(auth, region, aws.RetryingClient)
+	return NewWithClient(auth, region, aws.DefaultHTTPClient)
 }
 
 func NewWithClient(auth aws.Auth, region aws.Region, httpClient *http.Client) *IAM {
-	return &IAM{auth, region, httpClient}
+	return &IAM{Auth: auth, Region: region, httpClient: httpClient, clock: &aws.Clock{}}
 }
 
-func (iam *IAM) query(params map[string]string, resp interface{}) error {
-	params["Version"] = "2010-05-08"
-	params["Timestamp"] = time.Now().In(time.UTC).Format(time.RFC3339)
-	endpoint, err := url.Parse(iam.IAMEndpoint)
-	if err != nil {
-		return err
+// WithContext returns a shallow copy of iam whose requests are bound to
+// ctx, so that they are abandoned when ctx is cancelled or its deadline
+// expires. The provided ctx must be non-nil.
+func (iam *IAM) WithContext(ctx context.Context) *IAM {
+	if ctx == nil {
+		panic("nil context")
 	}
-	sign(iam.Auth, "GET", "/", params, endpoint.Host)
-	endpoint.RawQuery = multimap(params).Encode()
-	r, err := iam.httpClient.Get(endpoint.String())
-	if err != nil {
-		return err
-	}
-	defer r.Body.Close()
-	if r.StatusCode > 200 {
-		return buildError(r)
+	c := *iam
+	c.ctx = ctx
+	return &c
+}
+
+// Context returns the context requests made by iam are bound to.
+func (iam *IAM) Context() context.Context {
+	if iam.ctx != nil {
+		return iam.ctx
 	}
+	return context.Background()
+}
 
-	return xml.NewDecoder(r.Body).Decode(resp)
+// handlers are the handlers of IAM requests, run before the
+// aws.GlobalHandlers and the Handlers of the client.
+var handlers = func() aws.Handlers {
+	h := aws.QueryHandlers()
+	h.Sign.Remove(aws.V4SignHandler.Name)
+	h.Sign.PushBack(aws.V2SignHandler("iam.Sign", sign))
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("iam.UnmarshalError", buildError))
+	return h
+}()
+
+func (iam *IAM) query(params map[string]string, resp interface{}) error {
+	return iam.send("GET", params, resp)
 }
 
 func (iam *IAM) postQuery(params map[string]string, resp interface{}) error {
-	endpoint, err := url.Parse(iam.IAMEndpoint)
-	if err != nil {
-		return err
-	}
+	return iam.send("POST", params, resp)
+}
+
+func (iam *IAM) send(method string, params map[string]string, resp interface{}) error {
 	params["Version"] = "2010-05-08"
 	params["Timestamp"] = time.Now().In(time.UTC).Format(time.RFC3339)
-	sign(iam.Auth, "POST", "/", params, endpoint.Host)
-	encoded := multimap(params).Encode()
-	body := strings.NewReader(encoded)
-	req, err := http.NewRequest("POST", endpoint.String(), body)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Host", endpoint.Host)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Content-Length", strconv.Itoa(len(encoded)))
-	r, err := http.DefaultClient.Do(req)
+	ep, err := aws.ResolveEndpoint("iam", iam.Region)
 	if err != nil {
 		return err
 	}
-	defer r.Body.Close()
-	if r.StatusCode > 200 {
-		return buildError(r)
-	}
-	return xml.NewDecoder(r.Body).Decode(resp)
+	r := &aws.Request{
+		Service:         ep.SigningName,
+		Operation:       params["Action"],
+		Region:          iam.Region,
+		Auth:            iam.Auth,
+		Context:         iam.Context(),
+		Method:          method,
+		SigningRegion:   ep.SigningRegion,
+		Endpoint:        ep.URL,
+		Params:          params,
+		HTTPClient:      iam.httpClient,
+		Result:          resp,
+		Clock:           iam.clock,
+		Logger:          iam.Logger,
+		LogLevel:        iam.LogLevel,
+		Instrumentation: iam.Instrumentation,
+		Handlers:        aws.ChainHandlers(handlers, aws.GlobalHandlers, iam.Handlers),
+	}
+	return r.Send()
 }
 
 func buildError(r *http.Response) error {
@@ -87,6 +120,7 @@ func buildError(r *http.Response) error {
 	if len(errors.Errors) > 0 {
 		err = errors.Errors[0]
 	}
+	err.RequestId = errors.RequestId
 	err.StatusCode = r.StatusCode
 	if err.Message == "" {
 		err.Message = r.Status
@@ -120,6 +154,16 @@ type User struct {
 	Name string `xml:"UserName"`
 }
 
+// AccountID returns the id of the account of the user, taken from its ARN,
+// or "" if the ARN is not valid.
+func (u User) AccountID() string {
+	a, err := arn.Parse(u.Arn)
+	if err != nil || a.Service != "iam" {
+		return ""
+	}
+	return a.AccountID
+}
+
 // CreateUser creates a new user in IAM.
 //
 // See http://goo.gl/JS9Gz for more details.
@@ -501,7 +545,8 @@ type SimpleResp struct {
 }
 
 type xmlErrors struct {
-	Errors []Error `xml:"Error"`
+	RequestId string
+	Errors    []Error `xml:"Error"`
 }
 
 // ServerCertificateMetadata represents a ServerCertificateMetadata object
@@ -579,6 +624,7 @@ type ListServerCertificatesResp struct {
 	ServerCertificates []ServerCertificateMetadata `xml:"ListServerCertificatesResult>ServerCertificateMetadataList>member>ServerCertificateMetadata"`
 	RequestId          string                      `xml:"ResponseMetadata>RequestId"`
 	IsTruncated        bool                        `xml:"ListServerCertificatesResult>IsTruncated"`
+	Marker             string                      `xml:"ListServerCertificatesResult>Marker"`
 }
 
 func (iam *IAM) ListServerCertificates(options *ListServerCertificatesParams) (
@@ -603,6 +649,28 @@ func (iam *IAM) ListServerCertificates(options *ListServerCertificatesParams) (
 	return resp, nil
 }
 
+// ListServerCertificatesPages returns a Pager over the pages of
+// ListServerCertificates results, starting from options.Marker.
+func (iam *IAM) ListServerCertificatesPages(options *ListServerCertificatesParams) *aws.Pager[*ListServerCertificatesResp] {
+	return aws.NewPager(func(marker string) (*ListServerCertificatesResp, string, error) {
+		var page ListServerCertificatesParams
+		if options != nil {
+			page = *options
+		}
+		if marker != "" {
+			page.Marker = marker
+		}
+		resp, err := iam.ListServerCertificates(&page)
+		if err != nil {
+			return nil, "", err
+		}
+		if !resp.IsTruncated {
+			return resp, "", nil
+		}
+		return resp, resp.Marker, nil
+	})
+}
+
 // DeleteServerCertificate deletes the specified server certificate.
 //
 // See http://goo.gl/W4nmxQ for more details.
@@ -629,6 +697,9 @@ type Error struct {
 
 	// Message explaining the error.
 	Message string
+
+	// RequestId is the unique ID of the failed request.
+	RequestId string
 }
 
 func (e *Error) Error() string {
@@ -641,3 +712,10 @@ func (e *Error) Error() string {
 	}
 	return prefix + e.Message
 }
+
+// HTTPStatusCode, ErrorCode, ErrorMessage and RequestID implement
+// aws.APIError.
+func (e *Error) HTTPStatusCode() int  { return e.StatusCode }
+func (e *Error) ErrorCode() string    { return e.Code }
+func (e *Error) ErrorMessage() string { return e.Message }
+func (e *Error) RequestID() string    { return e.RequestId }
@@ -1,6 +1,7 @@
 package iam_test
 
 import (
+	"net/http"
 	"strings"
 	"testing"
 	"time"
@@ -49,6 +50,7 @@ func (s *S) TestCreateUser(c *C) {
 		Arn:  "arn:aws:iam::123456789012:user/division_abc/subdivision_xyz/Bob",
 	}
 	c.Assert(resp.User, DeepEquals, expected)
+	c.Assert(resp.User.AccountID(), Equals, "123456789012")
 }
 
 func (s *S) TestCreateUserConflict(c *C) {
@@ -61,6 +63,8 @@ func (s *S) TestCreateUserConflict(c *C) {
 	c.Assert(ok, Equals, true)
 	c.Assert(e.Message, Equals, "User with name Bob already exists.")
 	c.Assert(e.Code, Equals, "EntityAlreadyExists")
+	c.Assert(e.RequestId, Equals, "1d5f5000-1316-11e2-a60f-91a8e6fb6d21")
+	c.Assert(e.HTTPStatusCode(), Equals, 409)
 }
 
 func (s *S) TestGetUser(c *C) {
@@ -268,6 +272,26 @@ func (s *S) TestPutUserPolicy(c *C) {
 	c.Assert(resp.RequestId, Equals, "7a62c49f-347e-4fc4-9331-6e8eEXAMPLE")
 }
 
+type recordingTransport struct {
+	methods []string
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.methods = append(t.methods, req.Method)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (s *S) TestPostQueryClient(c *C) {
+	testServer.Response(200, nil, RequestIdExample)
+	transport := &recordingTransport{}
+	auth := aws.Auth{AccessKey: "abc", SecretKey: "123"}
+	client := iam.NewWithClient(auth, aws.Region{IAMEndpoint: testServer.URL}, &http.Client{Transport: transport})
+	_, err := client.PutUserPolicy("Bob", "AllAccessPolicy", "{}")
+	c.Assert(err, IsNil)
+	testServer.WaitRequest()
+	c.Assert(transport.methods, DeepEquals, []string{"POST"})
+}
+
 func (s *S) TestDeleteUserPolicy(c *C) {
 	testServer.Response(200, nil, RequestIdExample)
 	resp, err := s.iam.DeleteUserPolicy("Bob", "AllAccessPolicy")
@@ -0,0 +1,34 @@
+package rds
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goamz/goamz/aws"
+)
+
+// BuildAuthToken returns a token to use as the password when connecting as
+// dbUser to the database at endpoint ("host:port") with IAM database
+// authentication. The token is valid for 15 minutes.
+//
+// See http://goo.gl/UELbj2 for details.
+func BuildAuthToken(endpoint string, region aws.Region, dbUser string, auth aws.Auth) (string, error) {
+	auth, err := auth.Resolve()
+	if err != nil {
+		return "", err
+	}
+	u := &url.URL{
+		Scheme:   "https",
+		Host:     endpoint,
+		Path:     "/",
+		RawQuery: url.Values{"Action": {"connect"}, "DBUser": {dbUser}}.Encode(),
+	}
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	aws.NewV4Signer(auth, "rds-db", region).Presign(req, 15*time.Minute)
+	return strings.TrimPrefix(req.URL.String(), "https://"), nil
+}
@@ -1,15 +1,14 @@
 package rds
 
 import (
+	"context"
 	"encoding/xml"
 	"github.com/goamz/goamz/aws"
-	"log"
-	"net/http/httputil"
+	"net/http"
 	"strconv"
+	"time"
 )
 
-const debug = false
-
 const (
 	ServiceName = "rds"
 	ApiVersion  = "2013-09-09"
@@ -18,19 +17,52 @@ const (
 // The RDS type encapsulates operations within a specific EC2 region.
 type RDS struct {
 	Service aws.AWSService
+	ctx     context.Context
 }
 
 // New creates a new RDS Client.
 func New(auth aws.Auth, region aws.Region) (*RDS, error) {
-	service, err := aws.NewService(auth, region.RDSEndpoint)
+	return NewWithClient(auth, region, http.DefaultClient)
+}
+
+// NewWithClient creates a new RDS Client sending its requests with client.
+func NewWithClient(auth aws.Auth, region aws.Region, client *http.Client) (*RDS, error) {
+	ep, err := aws.ResolveEndpoint("rds", region)
+	if err != nil {
+		return nil, err
+	}
+	service, err := aws.NewService(auth, aws.ServiceInfo{Endpoint: ep.URL, Signer: region.RDSEndpoint.Signer})
 	if err != nil {
 		return nil, err
 	}
+	service.HTTPClient = client
+	service.SigningName, service.SigningRegion = ep.SigningName, ep.SigningRegion
 	return &RDS{
 		Service: service,
 	}, nil
 }
 
+// WithContext returns a shallow copy of rds whose requests are bound to
+// ctx, so that they are abandoned when ctx is cancelled or its deadline
+// expires. The context is only honoured if rds.Service implements
+// aws.AWSServiceWithContext. The provided ctx must be non-nil.
+func (rds *RDS) WithContext(ctx context.Context) *RDS {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *rds
+	c.ctx = ctx
+	return &c
+}
+
+// Context returns the context requests made by rds are bound to.
+func (rds *RDS) Context() context.Context {
+	if rds.ctx != nil {
+		return rds.ctx
+	}
+	return context.Background()
+}
+
 // ----------------------------------------------------------------------------
 // Request dispatching logic.
 
@@ -39,18 +71,18 @@ func (rds *RDS) query(method, path string, params map[string]string, resp interf
 	// Add basic RDS param
 	params["Version"] = ApiVersion
 
-	r, err := rds.Service.Query(method, path, params)
+	var r *http.Response
+	var err error
+	if service, ok := rds.Service.(aws.AWSServiceWithContext); ok {
+		r, err = service.QueryWithContext(rds.Context(), method, path, params)
+	} else {
+		r, err = rds.Service.Query(method, path, params)
+	}
 	if err != nil {
 		return err
 	}
 	defer r.Body.Close()
 
-	if debug {
-		dump, _ := httputil.DumpResponse(r, true)
-		log.Printf("response:\n")
-		log.Printf("%v\n}\n", string(dump))
-	}
-
 	if r.StatusCode != 200 {
 		return rds.Service.BuildError(r)
 	}
@@ -94,3 +126,38 @@ func (rds *RDS) DescribeDBInstances(id string, maxRecords int, marker string) (*
 	err := rds.query("POST", "/", params, resp)
 	return resp, err
 }
+
+// DescribeDBInstancesPages returns a Pager over the pages of
+// DescribeDBInstances results.
+func (rds *RDS) DescribeDBInstancesPages(id string, maxRecords int) *aws.Pager[*DescribeDBInstancesResponse] {
+	return aws.NewPager(func(token string) (*DescribeDBInstancesResponse, string, error) {
+		resp, err := rds.DescribeDBInstances(id, maxRecords, token)
+		if err != nil {
+			return nil, "", err
+		}
+		return resp, resp.Marker, nil
+	})
+}
+
+// DBInstanceAvailable waits for a database instance to be available. It
+// fails if the instance is deleted, or in a state it cannot recover from.
+var DBInstanceAvailable = aws.Waiter{
+	Name:     "DBInstanceAvailable",
+	Strategy: aws.WaiterStrategy(30*time.Second, 60),
+	Acceptors: []aws.Acceptor{
+		{State: aws.WaiterSuccess, Matcher: aws.MatchPathAll, Path: "DBInstances.DBInstanceStatus", Expected: "available"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchPathAny, Path: "DBInstances.DBInstanceStatus", Expected: "deleted"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchPathAny, Path: "DBInstances.DBInstanceStatus", Expected: "deleting"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchPathAny, Path: "DBInstances.DBInstanceStatus", Expected: "failed"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchPathAny, Path: "DBInstances.DBInstanceStatus", Expected: "incompatible-restore"},
+		{State: aws.WaiterFailure, Matcher: aws.MatchPathAny, Path: "DBInstances.DBInstanceStatus", Expected: "incompatible-parameters"},
+	},
+}
+
+// WaitUntilDBInstanceAvailable waits with DBInstanceAvailable for the
+// database instance id.
+func (rds *RDS) WaitUntilDBInstanceAvailable(id string) error {
+	return DBInstanceAvailable.Wait(rds.Context(), func() (interface{}, error) {
+		return rds.DescribeDBInstances(id, 0, "")
+	})
+}
@@ -1,6 +1,7 @@
 package rds_test
 
 import (
+	"net/url"
 	"testing"
 
 	"github.com/goamz/goamz/aws"
@@ -75,3 +76,37 @@ func (s *S) TestDescribeDBInstancesExample1(c *C) {
 	c.Assert(db0.PreferredMaintenanceWindow, Equals, "sat:07:30-sat:08:00")
 	c.Assert(db0.PubliclyAccessible, Equals, false)
 }
+
+func (s *S) TestInstrumentation(c *C) {
+	testServer.Response(200, nil, DescribeDBInstancesExample1)
+
+	auth := aws.Auth{AccessKey: "abc", SecretKey: "123"}
+	client, err := rds.New(auth, aws.Region{Name: "faux-region-1", RDSEndpoint: aws.ServiceInfo{Endpoint: testServer.URL, Signer: aws.V2Signature}})
+	c.Assert(err, IsNil)
+	inst := &aws.MemoryInstrumentation{}
+	client.Service.(*aws.Service).Instrumentation = inst
+
+	_, err = client.DescribeDBInstances("simcoprod01", 0, "")
+	c.Assert(err, IsNil)
+
+	attempts := inst.Attempts()
+	c.Assert(attempts, HasLen, 1)
+	c.Assert(attempts[0].Service, Equals, "rds")
+	c.Assert(attempts[0].Operation, Equals, "DescribeDBInstances")
+	c.Assert(attempts[0].Region, Equals, "faux-region-1")
+}
+
+func (s *S) TestBuildAuthToken(c *C) {
+	auth := aws.Auth{AccessKey: "AKIDEXAMPLE", SecretKey: "secret"}
+	token, err := rds.BuildAuthToken("prod-instance.us-east-1.rds.amazonaws.com:3306", aws.USEast, "mysqlUser", auth)
+	c.Assert(err, IsNil)
+
+	c.Assert(token, Matches, `prod-instance\.us-east-1\.rds\.amazonaws\.com:3306/\?Action=connect&DBUser=mysqlUser&X-Amz-Algorithm=AWS4-HMAC-SHA256&.*`)
+	u, err := url.Parse("https://" + token)
+	c.Assert(err, IsNil)
+	query := u.Query()
+	c.Assert(query.Get("X-Amz-Credential"), Matches, "AKIDEXAMPLE/[0-9]{8}/us-east-1/rds-db/aws4_request")
+	c.Assert(query.Get("X-Amz-Expires"), Equals, "900")
+	c.Assert(query.Get("X-Amz-SignedHeaders"), Equals, "host")
+	c.Assert(query.Get("X-Amz-Signature"), Matches, "[0-9a-f]{64}")
+}
@@ -2,11 +2,15 @@ package route53
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
 	"github.com/goamz/goamz/aws"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type Route53 struct {
@@ -14,21 +18,67 @@ type Route53 struct {
 	Endpoint string
 	Signer   *aws.Route53Signer
 	Service  *aws.Service
-}
+	ctx      context.Context
+
+	httpClient *http.Client
+
+	// Logger, if set, is given the lines selected by LogLevel about
+	// requests, with credentials redacted.
+	Logger   aws.Logger
+	LogLevel aws.LogLevel
 
-const route53_host = "https://route53.amazonaws.com"
+	// Instrumentation, if set, is told about every attempt of the
+	// calls of route53.
+	Instrumentation aws.Instrumentation
+
+	// Handlers are run on every request of the client, after the
+	// aws.GlobalHandlers.
+	Handlers aws.Handlers
+}
 
 // Factory for the route53 type
 func NewRoute53(auth aws.Auth) (*Route53, error) {
+	return NewRoute53WithClient(auth, http.DefaultClient)
+}
+
+// NewRoute53WithClient is like NewRoute53, with requests sent by client.
+func NewRoute53WithClient(auth aws.Auth, client *http.Client) (*Route53, error) {
 	signer := aws.NewRoute53Signer(auth)
+	signer.Clock = &aws.Clock{}
 
+	// Route 53 is a global service, resolved in the main region.
+	ep, err := aws.ResolveEndpoint("route53", aws.USEast)
+	if err != nil {
+		return nil, err
+	}
 	return &Route53{
-		Auth:     auth,
-		Signer:   signer,
-		Endpoint: route53_host + "/2013-04-01/hostedzone",
+		Auth:       auth,
+		Signer:     signer,
+		Endpoint:   ep.URL + "/2013-04-01/hostedzone",
+		httpClient: client,
 	}, nil
 }
 
+// WithContext returns a shallow copy of r whose requests are bound to
+// ctx, so that they are abandoned when ctx is cancelled or its deadline
+// expires. The provided ctx must be non-nil.
+func (r *Route53) WithContext(ctx context.Context) *Route53 {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *r
+	c.ctx = ctx
+	return &c
+}
+
+// Context returns the context requests made by r are bound to.
+func (r *Route53) Context() context.Context {
+	if r.ctx != nil {
+		return r.ctx
+	}
+	return context.Background()
+}
+
 // General Structs used in all types of requests
 type HostedZone struct {
 	XMLName                xml.Name `xml:"HostedZone"`
@@ -164,31 +214,44 @@ type DeleteHostedZoneResponse struct {
 //
 // Automatically decodes the response into the the result interface
 func (r *Route53) query(method string, path string, body io.Reader, result interface{}) error {
-	var err error
-
-	// Create the POST request and sign the headers
-	req, err := http.NewRequest(method, path, body)
-	r.Signer.Sign(req)
-
-	// Send the request and capture the response
-	client := &http.Client{}
-	res, err := client.Do(req)
-	if err != nil {
-		return err
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = ioutil.ReadAll(body); err != nil {
+			return err
+		}
 	}
 
-	if method == "POST" {
-		defer req.Body.Close()
+	var h aws.Handlers
+	h.Build.PushBack(aws.BuildHandler)
+	h.Sign.PushBack(aws.NamedHandler{Name: "route53.Sign", Fn: func(req *aws.Request) {
+		signer := *r.Signer
+		signer.Clock = req.Clock
+		req.Error = signer.SignRequest(req.HTTPRequest)
+	}})
+	h.Send.PushBack(aws.SendHandler)
+	h.Unmarshal.PushBack(aws.UnmarshalXMLHandler)
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("route53.UnmarshalError", func(res *http.Response) error {
+		return r.Service.BuildError(res)
+	}))
+	h.Retry.PushBack(aws.RetryHandler)
+
+	req := &aws.Request{
+		Service:         "route53",
+		Auth:            r.Auth,
+		Context:         r.Context(),
+		Method:          method,
+		Endpoint:        path,
+		Payload:         payload,
+		Result:          result,
+		HTTPClient:      r.httpClient,
+		Clock:           r.Signer.Clock,
+		Logger:          r.Logger,
+		LogLevel:        r.LogLevel,
+		Instrumentation: r.Instrumentation,
+		Handlers:        aws.ChainHandlers(h, aws.GlobalHandlers, r.Handlers),
 	}
-
-	if res.StatusCode != 201 && res.StatusCode != 200 {
-		err = r.Service.BuildError(res)
-		return err
-	}
-
-	err = xml.NewDecoder(res.Body).Decode(result)
-
-	return err
+	return req.Send()
 }
 
 // CreateHostedZone send a creation request to the AWS Route53 API
@@ -235,6 +298,21 @@ func (r *Route53) ListHostedZones(marker string, maxItems int) (result *ListHost
 	return
 }
 
+// ListHostedZonesPages returns a Pager over the pages of ListHostedZones
+// results.
+func (r *Route53) ListHostedZonesPages(maxItems int) *aws.Pager[*ListHostedZonesResponse] {
+	return aws.NewPager(func(marker string) (*ListHostedZonesResponse, string, error) {
+		result, err := r.ListHostedZones(marker, maxItems)
+		if err != nil {
+			return nil, "", err
+		}
+		if !result.IsTruncated {
+			return result, "", nil
+		}
+		return result, result.NextMarker, nil
+	})
+}
+
 // GetHostedZone fetches a particular hostedzones DelegationSet by id
 func (r *Route53) GetHostedZone(id string) (result *GetHostedZoneResponse, err error) {
 	result = new(GetHostedZoneResponse)
@@ -252,3 +330,38 @@ func (r *Route53) DeleteHostedZone(id string) (result *DeleteHostedZoneResponse,
 
 	return
 }
+
+type GetChangeResponse struct {
+	XMLName    xml.Name `xml:"GetChangeResponse"`
+	ChangeInfo ChangeInfo
+}
+
+// GetChange fetches the status of the change with the given id, such as
+// the Id of the ChangeInfo of a ChangeResourceRecordSet response.
+func (r *Route53) GetChange(id string) (result *GetChangeResponse, err error) {
+	id = strings.TrimPrefix(id, "/change/")
+	path := fmt.Sprintf("%s/change/%s", strings.TrimSuffix(r.Endpoint, "/hostedzone"), id)
+
+	result = new(GetChangeResponse)
+	err = r.query("GET", path, nil, result)
+
+	return
+}
+
+// ChangeInsync waits for a change to be propagated to all Route 53 DNS
+// servers.
+var ChangeInsync = aws.Waiter{
+	Name:     "ChangeInsync",
+	Strategy: aws.WaiterStrategy(30*time.Second, 60),
+	Acceptors: []aws.Acceptor{
+		{State: aws.WaiterSuccess, Matcher: aws.MatchPathAll, Path: "ChangeInfo.Status", Expected: "INSYNC"},
+	},
+}
+
+// WaitUntilChangeInsync waits with ChangeInsync for the change with the
+// given id.
+func (r *Route53) WaitUntilChangeInsync(id string) error {
+	return ChangeInsync.Wait(r.Context(), func() (interface{}, error) {
+		return r.GetChange(id)
+	})
+}
@@ -0,0 +1,220 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/goamz/goamz/aws"
+)
+
+const (
+	// DefaultDownloadPartSize is the size of the ranges fetched by a
+	// Downloader whose PartSize is zero.
+	DefaultDownloadPartSize = 8 << 20
+
+	// DefaultDownloadConcurrency is the number of ranges fetched at once
+	// by a Downloader whose Concurrency is zero.
+	DefaultDownloadConcurrency = 5
+)
+
+// Downloader downloads objects with concurrent ranged GETs.
+//
+// The size and ETag of the object are looked up first, and every range is
+// then fetched with If-Match set to that ETag, so that an object replaced
+// during the download makes it fail instead of mixing the content of both.
+// Requests are retried like those of the bucket's S3, and a range whose
+// transfer breaks is fetched again from where it stopped, as many times in
+// a row as the retry policy of that S3 attempts requests.
+type Downloader struct {
+	Bucket *Bucket
+
+	// PartSize is the size of the ranges fetched. Zero means
+	// DefaultDownloadPartSize.
+	PartSize int64
+
+	// Concurrency is the number of ranges fetched at once. Zero means
+	// DefaultDownloadConcurrency.
+	Concurrency int
+
+	// VersionId, if set, is the version of the objects downloaded.
+	VersionId string
+}
+
+// NewDownloader returns a Downloader of b with the default part size and
+// concurrency.
+func NewDownloader(b *Bucket) *Downloader {
+	return &Downloader{Bucket: b}
+}
+
+// Download writes the object at key to w, at the offsets it has in the
+// object, and returns its size. Ranges are written concurrently, and
+// after an error w may hold part of the object.
+func (d *Downloader) Download(w io.WriterAt, key string) (int64, error) {
+	size, etag, err := d.head(d.Bucket, key)
+	if err != nil {
+		return 0, err
+	}
+
+	// The first error cancels the ranges being fetched.
+	ctx, cancel := context.WithCancel(d.Bucket.S3.Context())
+	defer cancel()
+	b := d.Bucket.WithContext(ctx)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		written int64
+		err1    error
+	)
+	ranges := make(chan int64)
+	for i := 0; i < d.concurrency(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range ranges {
+				end := start + d.partSize() - 1
+				if end >= size {
+					end = size - 1
+				}
+				n, err := d.getRange(b, w, key, etag, start, end)
+				mu.Lock()
+				written += n
+				if err != nil && err1 == nil {
+					err1 = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+Ranges:
+	for start := int64(0); start < size; start += d.partSize() {
+		select {
+		case ranges <- start:
+		case <-ctx.Done():
+			break Ranges
+		}
+	}
+	close(ranges)
+	wg.Wait()
+
+	if err1 != nil {
+		return written, err1
+	}
+	if written != size {
+		return written, fmt.Errorf("s3: downloaded %d bytes of %d", written, size)
+	}
+	return size, nil
+}
+
+// head returns the size and ETag of the object at key.
+func (d *Downloader) head(b *Bucket, key string) (size int64, etag string, err error) {
+	req := &request{
+		method: "HEAD",
+		bucket: b.Name,
+		path:   key,
+		params: d.params(),
+	}
+	if err := b.S3.prepare(req); err != nil {
+		return 0, "", err
+	}
+	resp, err := b.S3.run(req, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	resp.Body.Close()
+	if resp.ContentLength < 0 {
+		return 0, "", errors.New("s3: object size unknown")
+	}
+	return resp.ContentLength, resp.Header.Get("ETag"), nil
+}
+
+// getRange writes the bytes of the object at key from start to end,
+// inclusive, to w and returns how many were written.
+func (d *Downloader) getRange(b *Bucket, w io.WriterAt, key, etag string, start, end int64) (int64, error) {
+	cur := start
+	// failures counts the transfers that broke in a row without
+	// making progress.
+	failures := 0
+	for {
+		headers := map[string][]string{
+			"Range": {fmt.Sprintf("bytes=%d-%d", cur, end)},
+		}
+		if etag != "" {
+			headers["If-Match"] = []string{etag}
+		}
+		req := &request{
+			bucket:  b.Name,
+			path:    key,
+			headers: headers,
+			params:  d.params(),
+		}
+		if err := b.S3.prepare(req); err != nil {
+			return cur - start, err
+		}
+		resp, err := b.S3.run(req, nil)
+		if err != nil {
+			return cur - start, err
+		}
+		if resp.StatusCode != 206 {
+			resp.Body.Close()
+			return cur - start, fmt.Errorf("s3: range request answered with status %d", resp.StatusCode)
+		}
+		n, err := io.CopyN(&offsetWriter{w, cur}, resp.Body, end-cur+1)
+		resp.Body.Close()
+		cur += n
+		if err == nil {
+			return cur - start, nil
+		}
+		if n > 0 {
+			failures = 0
+		}
+		failures++
+		if !brokenTransfer(err) || failures >= b.S3.retryPolicy().MaxAttempts {
+			return cur - start, err
+		}
+		// Resume from where the transfer stopped.
+	}
+}
+
+// brokenTransfer reports whether err, returned while reading the body of
+// a response, shows that the transfer broke and may be resumed.
+func brokenTransfer(err error) bool {
+	return err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) || aws.IsRetryable(err)
+}
+
+func (d *Downloader) params() map[string][]string {
+	if d.VersionId == "" {
+		return nil
+	}
+	return map[string][]string{"versionId": {d.VersionId}}
+}
+
+func (d *Downloader) partSize() int64 {
+	if d.PartSize <= 0 {
+		return DefaultDownloadPartSize
+	}
+	return d.PartSize
+}
+
+func (d *Downloader) concurrency() int {
+	if d.Concurrency <= 0 {
+		return DefaultDownloadConcurrency
+	}
+	return d.Concurrency
+}
+
+// offsetWriter writes to an io.WriterAt from an offset on.
+type offsetWriter struct {
+	w   io.WriterAt
+	off int64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.w.WriteAt(p, w.off)
+	w.off += int64(n)
+	return n, err
+}
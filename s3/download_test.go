@@ -0,0 +1,159 @@
+package s3_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goamz/goamz/aws"
+	"github.com/goamz/goamz/s3"
+	. "gopkg.in/check.v1"
+)
+
+const downloadContent = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// writerAt is an io.WriterAt of a fixed size.
+type writerAt struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (w *writerAt) WriteAt(p []byte, off int64) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return copy(w.data[off:], p), nil
+}
+
+// rangeServer serves downloadContent with an ETag, handing the requests
+// to f first, which returns whether it answered them.
+func rangeServer(etag string, f func(w http.ResponseWriter, req *http.Request) bool) (*httptest.Server, *s3.Bucket) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if f != nil && f(w, req) {
+			return
+		}
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, req, "", time.Time{}, strings.NewReader(downloadContent))
+	}))
+	s := s3.New(aws.Auth{AccessKey: "abc", SecretKey: "123"}, aws.Region{Name: "faux-region-1", S3Endpoint: ts.URL})
+	return ts, s.Bucket("sample")
+}
+
+func (s *S) TestDownload(c *C) {
+	var mu sync.Mutex
+	var ranges []string
+	ts, b := rangeServer(`"etag"`, func(w http.ResponseWriter, req *http.Request) bool {
+		c.Check(req.URL.Path, Equals, "/sample/name")
+		if req.Method == "GET" {
+			c.Check(req.Header.Get("If-Match"), Equals, `"etag"`)
+			mu.Lock()
+			ranges = append(ranges, req.Header.Get("Range"))
+			mu.Unlock()
+		}
+		return false
+	})
+	defer ts.Close()
+
+	d := &s3.Downloader{Bucket: b, PartSize: 10, Concurrency: 3}
+	w := &writerAt{data: make([]byte, len(downloadContent))}
+	n, err := d.Download(w, "name")
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(len(downloadContent)))
+	c.Assert(string(w.data), Equals, downloadContent)
+	c.Assert(ranges, HasLen, 4)
+	for _, r := range []string{"bytes=0-9", "bytes=10-19", "bytes=20-29", "bytes=30-35"} {
+		found := false
+		for _, got := range ranges {
+			found = found || got == r
+		}
+		c.Assert(found, Equals, true, Commentf("range %s not requested", r))
+	}
+}
+
+func (s *S) TestDownloadResumesRange(c *C) {
+	var ranges []string
+	ts, b := rangeServer(`"etag"`, func(w http.ResponseWriter, req *http.Request) bool {
+		if req.Method != "GET" {
+			return false
+		}
+		ranges = append(ranges, req.Header.Get("Range"))
+		if len(ranges) > 1 {
+			return false
+		}
+		// Break the connection in the middle of the body.
+		size := len(downloadContent)
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", size-1, size))
+		w.Header().Set("Content-Length", fmt.Sprint(size))
+		w.WriteHeader(206)
+		fmt.Fprint(w, downloadContent[:5])
+		return true
+	})
+	defer ts.Close()
+
+	d := &s3.Downloader{Bucket: b, Concurrency: 1}
+	w := &writerAt{data: make([]byte, len(downloadContent))}
+	n, err := d.Download(w, "name")
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(len(downloadContent)))
+	c.Assert(string(w.data), Equals, downloadContent)
+	c.Assert(ranges, DeepEquals, []string{"bytes=0-35", "bytes=5-35"})
+}
+
+func (s *S) TestDownloadResumeRetryPolicy(c *C) {
+	var ranges []string
+	ts, b := rangeServer(`"etag"`, func(w http.ResponseWriter, req *http.Request) bool {
+		if req.Method != "GET" {
+			return false
+		}
+		ranges = append(ranges, req.Header.Get("Range"))
+		size := len(downloadContent)
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", size-1, size))
+		w.Header().Set("Content-Length", fmt.Sprint(size))
+		w.WriteHeader(206)
+		fmt.Fprint(w, downloadContent[:5])
+		return true
+	})
+	defer ts.Close()
+
+	b.S3.RetryPolicy = &aws.NoRetryPolicy
+	d := &s3.Downloader{Bucket: b, Concurrency: 1}
+	w := &writerAt{data: make([]byte, len(downloadContent))}
+	_, err := d.Download(w, "name")
+	c.Assert(err, NotNil)
+	c.Assert(ranges, DeepEquals, []string{"bytes=0-35"})
+}
+
+func (s *S) TestDownloadChangedObject(c *C) {
+	ts, b := rangeServer(`"new"`, func(w http.ResponseWriter, req *http.Request) bool {
+		if req.Method != "HEAD" {
+			return false
+		}
+		w.Header().Set("ETag", `"old"`)
+		w.Header().Set("Content-Length", fmt.Sprint(len(downloadContent)))
+		return true
+	})
+	defer ts.Close()
+
+	d := &s3.Downloader{Bucket: b, PartSize: 10}
+	w := &writerAt{data: make([]byte, len(downloadContent))}
+	_, err := d.Download(w, "name")
+	c.Assert(err, NotNil)
+	c.Assert(err.(*s3.Error).StatusCode, Equals, 412)
+}
+
+func (s *S) TestDownloadVersion(c *C) {
+	ts, b := rangeServer(`"etag"`, func(w http.ResponseWriter, req *http.Request) bool {
+		c.Check(req.URL.Query().Get("versionId"), Equals, "v1")
+		return false
+	})
+	defer ts.Close()
+
+	d := &s3.Downloader{Bucket: b, VersionId: "v1"}
+	w := &writerAt{data: make([]byte, len(downloadContent))}
+	n, err := d.Download(w, "name")
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, int64(len(downloadContent)))
+	c.Assert(string(w.data), Equals, downloadContent)
+}
@@ -15,3 +15,11 @@ func SetListPartsMax(n int) {
 func SetListMultiMax(n int) {
 	listMultiMax = n
 }
+
+func SetMinUploadPartSize(n int64) {
+	minUploadPartSize = n
+}
+
+func UploadPartSize(u *Uploader, n int) int64 {
+	return u.partSize(n)
+}
@@ -2,6 +2,7 @@ package s3
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
@@ -30,6 +31,11 @@ type Multi struct {
 	UploadId string
 }
 
+// WithContext returns a copy of m whose requests are bound to ctx.
+func (m *Multi) WithContext(ctx context.Context) *Multi {
+	return &Multi{m.Bucket.WithContext(ctx), m.Key, m.UploadId}
+}
+
 // That's the default. Here just for testing.
 var listMultiMax = 1000
 
@@ -60,7 +66,7 @@ func (b *Bucket) ListMulti(prefix, delim string) (multis []*Multi, prefixes []st
 		"prefix":      {prefix},
 		"delimiter":   {delim},
 	}
-	for attempt := b.S3.AttemptStrategy.Start(); attempt.Next(); {
+	for {
 		req := &request{
 			method: "GET",
 			bucket: b.Name,
@@ -68,9 +74,6 @@ func (b *Bucket) ListMulti(prefix, delim string) (multis []*Multi, prefixes []st
 		}
 		var resp listMultiResp
 		err := b.S3.query(req, &resp)
-		if shouldRetry(err) && attempt.HasNext() {
-			continue
-		}
 		if err != nil {
 			return nil, nil, err
 		}
@@ -85,9 +88,7 @@ func (b *Bucket) ListMulti(prefix, delim string) (multis []*Multi, prefixes []st
 		}
 		params["key-marker"] = []string{resp.NextKeyMarker}
 		params["upload-id-marker"] = []string{resp.NextUploadIdMarker}
-		attempt = b.S3.AttemptStrategy.Start() // Last request worked.
 	}
-	panic("unreachable")
 }
 
 // Multi returns a multipart upload handler for the provided key
@@ -111,11 +112,17 @@ func (b *Bucket) Multi(key, contType string, perm ACL) (*Multi, error) {
 //
 // See http://goo.gl/XP8kL for details.
 func (b *Bucket) InitMulti(key string, contType string, perm ACL) (*Multi, error) {
+	return b.initMulti(key, contType, perm, Options{})
+}
+
+// initMulti is like InitMulti, with the headers of options.
+func (b *Bucket) initMulti(key string, contType string, perm ACL, options Options) (*Multi, error) {
 	headers := map[string][]string{
 		"Content-Type":   {contType},
 		"Content-Length": {"0"},
 		"x-amz-acl":      {string(perm)},
 	}
+	options.addHeaders(headers)
 	params := map[string][]string{
 		"uploads": {""},
 	}
@@ -130,12 +137,7 @@ func (b *Bucket) InitMulti(key string, contType string, perm ACL) (*Multi, error
 	var resp struct {
 		UploadId string `xml:"UploadId"`
 	}
-	for attempt := b.S3.AttemptStrategy.Start(); attempt.Next(); {
-		err = b.S3.query(req, &resp)
-		if !shouldRetry(err) {
-			break
-		}
-	}
+	err = b.S3.query(req, &resp)
 	if err != nil {
 		return nil, err
 	}
@@ -211,37 +213,32 @@ func (m *Multi) putPart(n int, r io.ReadSeeker, partSize int64, md5b64 string) (
 		"uploadId":   {m.UploadId},
 		"partNumber": {strconv.FormatInt(int64(n), 10)},
 	}
-	for attempt := m.Bucket.S3.AttemptStrategy.Start(); attempt.Next(); {
-		_, err := r.Seek(0, 0)
-		if err != nil {
-			return Part{}, err
-		}
-		req := &request{
-			method:  "PUT",
-			bucket:  m.Bucket.Name,
-			path:    m.Key,
-			headers: headers,
-			params:  params,
-			payload: r,
-		}
-		err = m.Bucket.S3.prepare(req)
-		if err != nil {
-			return Part{}, err
-		}
-		resp, err := m.Bucket.S3.run(req, nil)
-		if shouldRetry(err) && attempt.HasNext() {
-			continue
-		}
-		if err != nil {
-			return Part{}, err
-		}
-		etag := resp.Header.Get("ETag")
-		if etag == "" {
-			return Part{}, errors.New("part upload succeeded with no ETag")
-		}
-		return Part{n, etag, partSize}, nil
+	_, err := r.Seek(0, 0)
+	if err != nil {
+		return Part{}, err
+	}
+	req := &request{
+		method:  "PUT",
+		bucket:  m.Bucket.Name,
+		path:    m.Key,
+		headers: headers,
+		params:  params,
+		payload: r,
+	}
+	err = m.Bucket.S3.prepare(req)
+	if err != nil {
+		return Part{}, err
 	}
-	panic("unreachable")
+	resp, err := m.Bucket.S3.run(req, nil)
+	if err != nil {
+		return Part{}, err
+	}
+	resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return Part{}, errors.New("part upload succeeded with no ETag")
+	}
+	return Part{n, etag, partSize}, nil
 }
 
 func seekerInfo(r io.ReadSeeker) (size int64, md5hex string, md5b64 string, err error) {
@@ -291,7 +288,7 @@ func (m *Multi) ListParts() ([]Part, error) {
 		"max-parts": {strconv.FormatInt(int64(listPartsMax), 10)},
 	}
 	var parts partSlice
-	for attempt := m.Bucket.S3.AttemptStrategy.Start(); attempt.Next(); {
+	for {
 		req := &request{
 			method: "GET",
 			bucket: m.Bucket.Name,
@@ -300,9 +297,6 @@ func (m *Multi) ListParts() ([]Part, error) {
 		}
 		var resp listPartsResp
 		err := m.Bucket.S3.query(req, &resp)
-		if shouldRetry(err) && attempt.HasNext() {
-			continue
-		}
 		if err != nil {
 			return nil, err
 		}
@@ -312,9 +306,7 @@ func (m *Multi) ListParts() ([]Part, error) {
 			return parts, nil
 		}
 		params["part-number-marker"] = []string{resp.NextPartNumberMarker}
-		attempt = m.Bucket.S3.AttemptStrategy.Start() // Last request worked.
 	}
-	panic("unreachable")
 }
 
 type ReaderAtSeeker interface {
@@ -425,35 +417,28 @@ func (m *Multi) Complete(parts []Part) error {
 	}
 
 	// Setting Content-Length prevents breakage on DreamObjects
-	for attempt := m.Bucket.S3.AttemptStrategy.Start(); attempt.Next(); {
-		req := &request{
-			method:  "POST",
-			bucket:  m.Bucket.Name,
-			path:    m.Key,
-			params:  params,
-			payload: bytes.NewReader(data),
-			headers: map[string][]string{
-				"Content-Length": []string{strconv.Itoa(len(data))},
-			},
-		}
-
-		resp := &completeResponse{}
-		err := m.Bucket.S3.query(req, resp)
-		if shouldRetry(err) && attempt.HasNext() {
-			continue
-		}
-		if err == nil && resp.XMLName.Local == "Error" {
-			err = &Error{
-				StatusCode: 200,
-				Code:       resp.Code,
-				Message:    resp.Message,
-				RequestId:  resp.RequestId,
-				HostId:     resp.HostId,
-			}
+	req := &request{
+		method:  "POST",
+		bucket:  m.Bucket.Name,
+		path:    m.Key,
+		params:  params,
+		payload: bytes.NewReader(data),
+		headers: map[string][]string{
+			"Content-Length": []string{strconv.Itoa(len(data))},
+		},
+	}
+	resp := &completeResponse{}
+	err = m.Bucket.S3.query(req, resp)
+	if err == nil && resp.XMLName.Local == "Error" {
+		err = &Error{
+			StatusCode: 200,
+			Code:       resp.Code,
+			Message:    resp.Message,
+			RequestId:  resp.RequestId,
+			HostId:     resp.HostId,
 		}
-		return err
 	}
-	panic("unreachable")
+	return err
 }
 
 // Abort deletes an unifinished multipart upload and any previously
@@ -475,18 +460,11 @@ func (m *Multi) Abort() error {
 	params := map[string][]string{
 		"uploadId": {m.UploadId},
 	}
-	for attempt := m.Bucket.S3.AttemptStrategy.Start(); attempt.Next(); {
-		req := &request{
-			method: "DELETE",
-			bucket: m.Bucket.Name,
-			path:   m.Key,
-			params: params,
-		}
-		err := m.Bucket.S3.query(req, nil)
-		if shouldRetry(err) && attempt.HasNext() {
-			continue
-		}
-		return err
+	req := &request{
+		method: "DELETE",
+		bucket: m.Bucket.Name,
+		path:   m.Key,
+		params: params,
 	}
-	panic("unreachable")
+	return m.Bucket.S3.query(req, nil)
 }
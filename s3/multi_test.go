@@ -164,7 +164,8 @@ func (s *S) TestPutPartReader(c *C) {
 	c.Assert(req.URL.Path, Equals, "/sample/multi")
 	c.Assert(req.Form.Get("uploadId"), Matches, "JNbR_[A-Za-z0-9.]+QQ--")
 	c.Assert(req.Form["partNumber"], DeepEquals, []string{"1"})
-	c.Assert(req.Header["Content-Length"], DeepEquals, []string{"8"})
+	c.Assert(req.Header["X-Amz-Decoded-Content-Length"], DeepEquals, []string{"8"})
+	c.Assert(readAll(req.Body), Matches, "8;chunk-signature=[0-9a-f]{64}\r\n<part 1>\r\n0;chunk-signature=[0-9a-f]{64}\r\n\r\n")
 }
 
 // Makes sure that our upload fails if S3 returns a different Etag from what we
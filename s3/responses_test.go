@@ -69,6 +69,16 @@ var InitMultiResultDump = `
 </InitiateMultipartUploadResult>
 `
 
+var CompleteMultiResultDump = `
+<?xml version="1.0" encoding="UTF-8"?>
+<CompleteMultipartUploadResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Location>http://sample.s3.amazonaws.com/multi</Location>
+  <Bucket>sample</Bucket>
+  <Key>multi</Key>
+  <ETag>"3858f62230ac3c915f300c664312c11f-9"</ETag>
+</CompleteMultipartUploadResult>
+`
+
 var ListPartsResultDump1 = `
 <?xml version="1.0" encoding="UTF-8"?>
 <ListPartsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
@@ -200,3 +210,12 @@ var InternalErrorDump = `
   <HostId>kjhwqk</HostId>
 </Error>
 `
+
+var SlowDownErrorDump = `
+<?xml version="1.0" encoding="UTF-8"?>
+<Error>
+  <Code>SlowDown</Code>
+  <Message>Please reduce your request rate.</Message>
+  <RequestId>3F1B667FAD71C3D8</RequestId>
+</Error>
+`
@@ -12,18 +12,19 @@ package s3
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/md5"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"net"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"strconv"
 	"strings"
@@ -33,8 +34,6 @@ import (
 	"github.com/goamz/goamz/aws"
 )
 
-const debug = false
-
 // The S3 type encapsulates operations with an S3 region.
 type S3 struct {
 	aws.Auth
@@ -69,14 +68,53 @@ type S3 struct {
 	RequestTimeout time.Duration
 
 	// AttemptStrategy is the attempt strategy used for requests.
+	//
+	// Deprecated: set RetryPolicy instead. An AttemptStrategy other than
+	// DefaultAttemptStrategy is used, when RetryPolicy is nil, as a policy
+	// of at least Min attempts, Delay apart, for at least Total.
 	aws.AttemptStrategy
 
+	// RetryPolicy, if set, replaces aws.DefaultRetryPolicy for the
+	// requests of s3.
+	RetryPolicy *aws.RetryPolicy
+
+	// Signature is the scheme requests are signed with, either
+	// aws.V2Signature or aws.V4Signature. New selects V4, which all
+	// regions accept while newer ones reject V2.
+	Signature int
+
+	// UnsignedPayload makes V4 signed requests leave their body out
+	// of the signature rather than hashing it beforehand or streaming
+	// it in signed chunks. It should only be used over HTTPS.
+	UnsignedPayload bool
+
+	// Logger, if set, is given the lines selected by LogLevel about
+	// requests, with credentials redacted. Request bodies are streamed
+	// and never logged.
+	Logger   aws.Logger
+	LogLevel aws.LogLevel
+
+	// Instrumentation, if set, is told about every attempt of the
+	// calls of s3.
+	Instrumentation aws.Instrumentation
+
+	// Handlers are run on every request of the client, after the
+	// aws.GlobalHandlers.
+	Handlers aws.Handlers
+
 	// Reserve the right of using private data.
 	private byte
 
 	// client used for requests
 	httpClient *http.Client
 	clientLock sync.RWMutex
+
+	// context requests are bound to
+	ctx context.Context
+
+	// clock requests are signed at, corrected when S3 rejects them
+	// for being signed with a skewed clock
+	clock *aws.Clock
 }
 
 // The Bucket type encapsulates operations with an S3 bucket.
@@ -119,6 +157,8 @@ type CopyObjectResult struct {
 }
 
 // DefaultAttemptStrategy is the default AttemptStrategy used by S3 objects created by New.
+//
+// Deprecated: requests are retried according to the RetryPolicy of S3.
 var DefaultAttemptStrategy = aws.AttemptStrategy{
 	Min:   5,
 	Total: 5 * time.Second,
@@ -134,7 +174,50 @@ func New(auth aws.Auth, region aws.Region, client ...*http.Client) *S3 {
 		httpclient = client[0]
 	}
 
-	return &S3{Auth: auth, Region: region, AttemptStrategy: DefaultAttemptStrategy, httpClient: httpclient}
+	return &S3{Auth: auth, Region: region, AttemptStrategy: DefaultAttemptStrategy, Signature: aws.V4Signature, httpClient: httpclient, clock: &aws.Clock{}}
+}
+
+// NewWithClient creates a new S3 sending its requests with client, as New
+// does when given one. Its timeout fields are then left to client.
+func NewWithClient(auth aws.Auth, region aws.Region, client *http.Client) *S3 {
+	return New(auth, region, client)
+}
+
+// WithContext returns a shallow copy of s3 whose requests are bound to
+// ctx, so that they are abandoned when ctx is cancelled or its deadline
+// expires. This includes the reading of response bodies such as those
+// returned by GetReader. The provided ctx must be non-nil.
+func (s3 *S3) WithContext(ctx context.Context) *S3 {
+	if ctx == nil {
+		panic("nil context")
+	}
+	return &S3{
+		Auth:            s3.Auth,
+		Region:          s3.Region,
+		ConnectTimeout:  s3.ConnectTimeout,
+		ReadTimeout:     s3.ReadTimeout,
+		WriteTimeout:    s3.WriteTimeout,
+		RequestTimeout:  s3.RequestTimeout,
+		AttemptStrategy: s3.AttemptStrategy,
+		RetryPolicy:     s3.RetryPolicy,
+		Signature:       s3.Signature,
+		UnsignedPayload: s3.UnsignedPayload,
+		Logger:          s3.Logger,
+		LogLevel:        s3.LogLevel,
+		Instrumentation: s3.Instrumentation,
+		Handlers:        s3.Handlers,
+		httpClient:      s3.client(),
+		ctx:             ctx,
+		clock:           s3.clock,
+	}
+}
+
+// Context returns the context requests made by s3 are bound to.
+func (s3 *S3) Context() context.Context {
+	if s3.ctx != nil {
+		return s3.ctx
+	}
+	return context.Background()
 }
 
 // Bucket returns a Bucket with the given name.
@@ -145,6 +228,11 @@ func (s3 *S3) Bucket(name string) *Bucket {
 	return &Bucket{s3, name}
 }
 
+// WithContext returns a copy of b whose requests are bound to ctx.
+func (b *Bucket) WithContext(ctx context.Context) *Bucket {
+	return &Bucket{b.S3.WithContext(ctx), b.Name}
+}
+
 var createBucketConfiguration = `<CreateBucketConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
   <LocationConstraint>%s</LocationConstraint>
 </CreateBucketConfiguration>`
@@ -199,12 +287,7 @@ func (b *Bucket) DelBucket() (err error) {
 		bucket: b.Name,
 		path:   "/",
 	}
-	for attempt := b.S3.AttemptStrategy.Start(); attempt.Next(); {
-		err = b.S3.query(req, nil)
-		if !shouldRetry(err) {
-			break
-		}
-	}
+	err = b.S3.query(req, nil)
 	return err
 }
 
@@ -260,17 +343,7 @@ func (b *Bucket) GetResponseWithHeaders(path string, headers map[string][]string
 	if err != nil {
 		return nil, err
 	}
-	for attempt := b.S3.AttemptStrategy.Start(); attempt.Next(); {
-		resp, err := b.S3.run(req, nil)
-		if shouldRetry(err) && attempt.HasNext() {
-			continue
-		}
-		if err != nil {
-			return nil, err
-		}
-		return resp, nil
-	}
-	panic("unreachable")
+	return b.S3.run(req, nil)
 }
 
 // Exists checks whether or not an object exists on an S3 bucket using a HEAD request.
@@ -284,27 +357,16 @@ func (b *Bucket) Exists(path string) (exists bool, err error) {
 	if err != nil {
 		return
 	}
-	for attempt := b.S3.AttemptStrategy.Start(); attempt.Next(); {
-		resp, err := b.S3.run(req, nil)
-
-		if shouldRetry(err) && attempt.HasNext() {
-			continue
-		}
-
-		if err != nil {
-			// We can treat a 403 or 404 as non existance
-			if e, ok := err.(*Error); ok && (e.StatusCode == 403 || e.StatusCode == 404) {
-				return false, nil
-			}
-			return false, err
-		}
-
-		if resp.StatusCode/100 == 2 {
-			exists = true
+	resp, err := b.S3.run(req, nil)
+	if err != nil {
+		// We can treat a 403 or 404 as non existance
+		if e, ok := err.(*Error); ok && (e.StatusCode == 403 || e.StatusCode == 404) {
+			return false, nil
 		}
-		return exists, err
+		return false, err
 	}
-	return false, fmt.Errorf("S3 Currently Unreachable")
+	resp.Body.Close()
+	return resp.StatusCode/100 == 2, nil
 }
 
 // Head HEADs an object in the S3 bucket, returns the response with
@@ -321,24 +383,14 @@ func (b *Bucket) Head(path string, headers map[string][]string) (*http.Response,
 		return nil, err
 	}
 
-	for attempt := b.S3.AttemptStrategy.Start(); attempt.Next(); {
-		resp, err := b.S3.run(req, nil)
-		if shouldRetry(err) && attempt.HasNext() {
-			continue
-		}
-		if err != nil {
-			return nil, err
-		}
-		return resp, err
-	}
-	return nil, fmt.Errorf("S3 Currently Unreachable")
+	return b.S3.run(req, nil)
 }
 
 // Put inserts an object into the S3 bucket.
 //
 // See http://goo.gl/FEBPD for details.
 func (b *Bucket) Put(path string, data []byte, contType string, perm ACL, options Options) error {
-	body := bytes.NewBuffer(data)
+	body := bytes.NewReader(data)
 	return b.PutReader(path, body, int64(len(data)), contType, perm, options)
 }
 
@@ -356,12 +408,7 @@ func (b *Bucket) PutCopy(path string, perm ACL, options CopyOptions, source stri
 		headers: headers,
 	}
 	result = &CopyObjectResult{}
-	for attempt := b.S3.AttemptStrategy.Start(); attempt.Next(); {
-		err = b.S3.query(req, result)
-		if !shouldRetry(err) {
-			break
-		}
-	}
+	err = b.S3.query(req, result)
 	if err != nil {
 		return nil, err
 	}
@@ -373,7 +420,7 @@ PutHeader - like Put, inserts an object into the S3 bucket.
 Instead of Content-Type string, pass in custom headers to override defaults.
 */
 func (b *Bucket) PutHeader(path string, data []byte, customHeaders map[string][]string, perm ACL) error {
-	body := bytes.NewBuffer(data)
+	body := bytes.NewReader(data)
 	return b.PutReaderHeader(path, body, int64(len(data)), customHeaders, perm)
 }
 
@@ -659,29 +706,50 @@ func (b *Bucket) List(prefix, delim, marker string, max int) (result *ListResp,
 		params: params,
 	}
 	result = &ListResp{}
-	for attempt := b.S3.AttemptStrategy.Start(); attempt.Next(); {
-		err = b.S3.query(req, result)
-		if !shouldRetry(err) {
-			break
-		}
-	}
+	err = b.S3.query(req, result)
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
+// ListPages returns a Pager over the pages of List results, of up to max
+// keys and common prefixes each.
+func (b *Bucket) ListPages(prefix, delim string, max int) *aws.Pager[*ListResp] {
+	return aws.NewPager(func(marker string) (*ListResp, string, error) {
+		result, err := b.List(prefix, delim, marker, max)
+		if err != nil {
+			return nil, "", err
+		}
+		if !result.IsTruncated {
+			return result, "", nil
+		}
+		// NextMarker is only returned along with a delimiter.
+		// Otherwise the listing goes on after the last key.
+		next := result.NextMarker
+		if next == "" && len(result.Contents) > 0 {
+			next = result.Contents[len(result.Contents)-1].Key
+		}
+		if n := len(result.CommonPrefixes); n > 0 && result.CommonPrefixes[n-1] > next {
+			next = result.CommonPrefixes[n-1]
+		}
+		return result, next, nil
+	})
+}
+
 // The VersionsResp type holds the results of a list bucket Versions operation.
 type VersionsResp struct {
-	Name            string
-	Prefix          string
-	KeyMarker       string
-	VersionIdMarker string
-	MaxKeys         int
-	Delimiter       string
-	IsTruncated     bool
-	Versions        []Version
-	CommonPrefixes  []string `xml:">Prefix"`
+	Name                string
+	Prefix              string
+	KeyMarker           string
+	VersionIdMarker     string
+	NextKeyMarker       string
+	NextVersionIdMarker string
+	MaxKeys             int
+	Delimiter           string
+	IsTruncated         bool
+	Versions            []Version
+	CommonPrefixes      []string `xml:">Prefix"`
 }
 
 // The Version type represents an object version stored in an S3 bucket.
@@ -720,18 +788,38 @@ func (b *Bucket) Versions(prefix, delim, keyMarker string, versionIdMarker strin
 		params: params,
 	}
 	result = &VersionsResp{}
-	for attempt := b.S3.AttemptStrategy.Start(); attempt.Next(); {
-		err = b.S3.query(req, result)
-		if !shouldRetry(err) {
-			break
-		}
-	}
+	err = b.S3.query(req, result)
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
+// VersionsPages returns a Pager over the pages of Versions results, of up
+// to max versions and common prefixes each.
+func (b *Bucket) VersionsPages(prefix, delim string, max int) *aws.Pager[*VersionsResp] {
+	return aws.NewPager(func(token string) (*VersionsResp, string, error) {
+		// Tokens hold both markers, as many versions of a key may
+		// span several pages.
+		markers, err := url.ParseQuery(token)
+		if err != nil {
+			return nil, "", err
+		}
+		result, err := b.Versions(prefix, delim, markers.Get("key-marker"), markers.Get("version-id-marker"), max)
+		if err != nil {
+			return nil, "", err
+		}
+		if !result.IsTruncated {
+			return result, "", nil
+		}
+		next := url.Values{
+			"key-marker":        {result.NextKeyMarker},
+			"version-id-marker": {result.NextVersionIdMarker},
+		}
+		return result, next.Encode(), nil
+	})
+}
+
 // Returns a mapping of all key names in this bucket to Key objects
 func (b *Bucket) GetBucketContents() (*map[string]Key, error) {
 	bucket_contents := map[string]Key{}
@@ -778,39 +866,84 @@ func (b *Bucket) URL(path string) string {
 
 // SignedURL returns a signed URL that allows anyone holding the URL
 // to retrieve the object at path. The signature is valid until expires.
+//
+// V4 signatures can't be valid for more than seven days. If expires is
+// further away, or already past, the URL is signed with the V2 scheme
+// instead, which regions opened since 2014 reject.
+//
+// SignedURL returns "" if the URL can't be signed, for example when the
+// credentials of the Auth of the bucket can't be retrieved. SignedGetURL
+// returns the error instead.
 func (b *Bucket) SignedURL(path string, expires time.Time) string {
+	u, _ := b.SignedGetURL(path, expires)
+	return u
+}
+
+// SignedGetURL is like SignedURL, but returns an error if the URL can't be
+// signed.
+func (b *Bucket) SignedGetURL(path string, expires time.Time) (string, error) {
+	if d := expires.Sub(time.Now()); b.S3.Signature == aws.V4Signature && d > 0 && d <= maxPresignExpiry {
+		return b.PresignedURL("GET", path, d)
+	}
 	req := &request{
 		bucket: b.Name,
 		path:   path,
 		params: url.Values{"Expires": {strconv.FormatInt(expires.Unix(), 10)}},
 	}
 	err := b.S3.prepare(req)
+	if err == nil {
+		err = b.S3.signV2(req, req.headers)
+	}
 	if err != nil {
-		panic(err)
+		return "", err
 	}
 	u, err := req.url()
 	if err != nil {
-		panic(err)
+		return "", err
 	}
-	if b.S3.Auth.Token() != "" {
-		return u.String() + "&x-amz-security-token=" + url.QueryEscape(req.headers["X-Amz-Security-Token"][0])
-	} else {
-		return u.String()
+	if token, ok := req.headers["X-Amz-Security-Token"]; ok {
+		return u.String() + "&x-amz-security-token=" + url.QueryEscape(token[0]), nil
 	}
+	return u.String(), nil
 }
 
 // UploadSignedURL returns a signed URL that allows anyone holding the URL
 // to upload the object at path. The signature is valid until expires.
 // contenttype is a string like image/png
 // path is the resource name in s3 terminalogy like images/ali.png [obviously exclusing the bucket name itself]
+//
+// As with SignedURL, the URL is signed with the V2 scheme if expires is
+// more than seven days away or already past. UploadSignedURL returns ""
+// if the URL can't be signed. SignedUploadURL returns the error instead.
 func (b *Bucket) UploadSignedURL(path, method, content_type string, expires time.Time) string {
+	u, _ := b.SignedUploadURL(path, method, content_type, expires)
+	return u
+}
+
+// SignedUploadURL is like UploadSignedURL, but returns an error if the URL
+// can't be signed.
+func (b *Bucket) SignedUploadURL(path, method, content_type string, expires time.Time) (string, error) {
 	expire_date := expires.Unix()
 	if method != "POST" {
 		method = "PUT"
 	}
+	if d := expires.Sub(time.Now()); b.S3.Signature == aws.V4Signature && d > 0 && d <= maxPresignExpiry {
+		req := &request{
+			method:  method,
+			bucket:  b.Name,
+			path:    path,
+			headers: http.Header{"Content-Type": {content_type}},
+		}
+		return b.S3.presign(req, d)
+	}
 	stringToSign := method + "\n\n" + content_type + "\n" + strconv.FormatInt(expire_date, 10) + "\n/" + b.Name + "/" + path
-	fmt.Println("String to sign:\n", stringToSign)
-	a := b.S3.Auth
+	if logger := b.S3.signingLogger(); logger != nil {
+		logger.Log("aws: s3: string to sign:\n" + stringToSign)
+	}
+	a, err := b.S3.Auth.Resolve()
+	if err != nil {
+		return "", err
+	}
 	secretKey := a.SecretKey
 	accessId := a.AccessKey
 	mac := hmac.New(sha1.New, []byte(secretKey))
@@ -821,8 +954,7 @@ func (b *Bucket) UploadSignedURL(path, method, content_type string, expires time
 
 	signedurl, err := url.Parse("https://" + b.Name + ".s3.amazonaws.com/")
 	if err != nil {
-		log.Println("ERROR sining url for S3 upload", err)
-		return ""
+		return "", err
 	}
 	signedurl.Path += path
 	params := url.Values{}
@@ -834,15 +966,42 @@ func (b *Bucket) UploadSignedURL(path, method, content_type string, expires time
 	}
 
 	signedurl.RawQuery = params.Encode()
-	return signedurl.String()
+	return signedurl.String(), nil
+}
+
+// PresignedURL returns a URL, signed with the V4 scheme, that allows anyone
+// holding it to perform the given method ("GET", "PUT", "DELETE", ...) on
+// the object at path for the duration of expires, which can't exceed seven
+// days.
+func (b *Bucket) PresignedURL(method, path string, expires time.Duration) (string, error) {
+	req := &request{
+		method: method,
+		bucket: b.Name,
+		path:   path,
+	}
+	return b.S3.presign(req, expires)
 }
 
 // PostFormArgs returns the action and input fields needed to allow anonymous
 // uploads to a bucket within the expiration limit
+//
+// PostFormArgs returns "" and nil fields if the form can't be signed.
+// PostForm returns the error instead.
 func (b *Bucket) PostFormArgs(path string, expires time.Time, redirect string) (action string, fields map[string]string) {
+	action, fields, _ = b.PostForm(path, expires, redirect)
+	return action, fields
+}
+
+// PostForm is like PostFormArgs, but returns an error if the form can't be
+// signed.
+func (b *Bucket) PostForm(path string, expires time.Time, redirect string) (action string, fields map[string]string, err error) {
+	auth, err := b.Auth.Resolve()
+	if err != nil {
+		return "", nil, err
+	}
 	conditions := make([]string, 0)
 	fields = map[string]string{
-		"AWSAccessKeyId": b.Auth.AccessKey,
+		"AWSAccessKeyId": auth.AccessKey,
 		"key":            path,
 	}
 
@@ -859,12 +1018,16 @@ func (b *Bucket) PostFormArgs(path string, expires time.Time, redirect string) (
 	policy64 := base64.StdEncoding.EncodeToString([]byte(policy))
 	fields["policy"] = policy64
 
-	signer := hmac.New(sha1.New, []byte(b.Auth.SecretKey))
+	signer := hmac.New(sha1.New, []byte(auth.SecretKey))
 	signer.Write([]byte(policy64))
 	fields["signature"] = base64.StdEncoding.EncodeToString(signer.Sum(nil))
 
-	action = fmt.Sprintf("%s/%s/", b.S3.Region.S3Endpoint, b.Name)
-	return
+	ep, err := aws.ResolveEndpoint("s3", b.S3.Region)
+	if err != nil {
+		return "", nil, err
+	}
+	action = fmt.Sprintf("%s/%s/", ep.URL, b.Name)
+	return action, fields, nil
 }
 
 type request struct {
@@ -877,6 +1040,69 @@ type request struct {
 	baseurl  string
 	payload  io.Reader
 	prepared bool
+
+	// op is the name of the operation of req, set by prepare before
+	// the bucket may be moved into path.
+	op string
+}
+
+// operation returns the name of the S3 API operation of req, such as
+// "PutObject", as given to Instrumentation.
+func (req *request) operation() string {
+	verb := map[string]string{"GET": "Get", "PUT": "Put", "POST": "Post", "DELETE": "Delete", "HEAD": "Head"}[req.method]
+	has := func(param string) bool {
+		_, ok := req.params[param]
+		return ok
+	}
+	copied := false
+	for k := range req.headers {
+		copied = copied || strings.EqualFold(k, "X-Amz-Copy-Source")
+	}
+	object := req.path != "" && req.path != "/"
+	switch {
+	case req.bucket == "":
+		return "ListBuckets"
+	case object && has("uploadId"):
+		switch req.method {
+		case "GET":
+			return "ListParts"
+		case "POST":
+			return "CompleteMultipartUpload"
+		case "DELETE":
+			return "AbortMultipartUpload"
+		case "PUT":
+			if copied {
+				return "UploadPartCopy"
+			}
+			return "UploadPart"
+		}
+	case object && has("uploads"):
+		return "CreateMultipartUpload"
+	case object && req.method == "PUT" && copied:
+		return "CopyObject"
+	case object:
+		return verb + "Object"
+	case has("uploads"):
+		return "ListMultipartUploads"
+	case has("versions"):
+		return "ListObjectVersions"
+	case has("delete"):
+		return "DeleteObjects"
+	case len(req.params) == 1:
+		// A subresource of the bucket, such as website.
+		for sub, v := range req.params {
+			if len(v) == 1 && v[0] == "" {
+				return verb + "Bucket" + strings.ToUpper(sub[:1]) + sub[1:]
+			}
+		}
+	}
+	switch req.method {
+	case "GET":
+		return "ListObjects"
+	case "PUT":
+		return "CreateBucket"
+	}
+	return verb + "Bucket"
 }
 
 func (req *request) url() (*url.URL, error) {
@@ -904,60 +1130,173 @@ func (s3 *S3) query(req *request, resp interface{}) error {
 	return err
 }
 
-// prepare sets up req to be delivered to S3.
+// prepare sets up req to be delivered to S3. It is signed by the Sign
+// handlers of the request it is sent with, or by presign.
 func (s3 *S3) prepare(req *request) error {
-	var signpath = req.path
-
-	if !req.prepared {
-		req.prepared = true
-		if req.method == "" {
-			req.method = "GET"
-		}
-		// Copy so they can be mutated without affecting on retries.
-		params := make(url.Values)
-		headers := make(http.Header)
-		for k, v := range req.params {
-			params[k] = v
-		}
-		for k, v := range req.headers {
-			headers[k] = v
-		}
-		req.params = params
-		req.headers = headers
-		if !strings.HasPrefix(req.path, "/") {
-			req.path = "/" + req.path
-		}
-		signpath = req.path
-		if req.bucket != "" {
-			req.baseurl = s3.Region.S3BucketEndpoint
-			if req.baseurl == "" {
-				// Use the path method to address the bucket.
-				req.baseurl = s3.Region.S3Endpoint
-				req.path = "/" + req.bucket + req.path
-			} else {
-				// Just in case, prevent injection.
-				if strings.IndexAny(req.bucket, "/:@") >= 0 {
-					return fmt.Errorf("bad S3 bucket: %q", req.bucket)
-				}
-				req.baseurl = strings.Replace(req.baseurl, "${bucket}", req.bucket, -1)
+	if req.prepared {
+		return nil
+	}
+	req.prepared = true
+	if req.method == "" {
+		req.method = "GET"
+	}
+	// Copy so they can be mutated without affecting on retries.
+	params := make(url.Values)
+	headers := make(http.Header)
+	for k, v := range req.params {
+		params[k] = v
+	}
+	for k, v := range req.headers {
+		headers[k] = v
+	}
+	req.params = params
+	req.headers = headers
+	if !strings.HasPrefix(req.path, "/") {
+		req.path = "/" + req.path
+	}
+	req.op = req.operation()
+	req.signpath = req.path
+	if req.bucket != "" {
+		req.baseurl = s3.Region.S3BucketEndpoint
+		if req.baseurl == "" {
+			// Use the path method to address the bucket.
+			ep, err := aws.ResolveEndpoint("s3", s3.Region)
+			if err != nil {
+				return err
+			}
+			req.baseurl = ep.URL
+			req.path = "/" + req.bucket + req.path
+		} else {
+			// Just in case, prevent injection.
+			if strings.IndexAny(req.bucket, "/:@") >= 0 {
+				return fmt.Errorf("bad S3 bucket: %q", req.bucket)
 			}
-			signpath = "/" + req.bucket + signpath
+			req.baseurl = strings.Replace(req.baseurl, "${bucket}", req.bucket, -1)
 		}
+		req.signpath = "/" + req.bucket + req.signpath
 	}
+	if _, err := url.Parse(req.baseurl); err != nil {
+		return fmt.Errorf("bad S3 endpoint URL %q: %v", req.baseurl, err)
+	}
+	return nil
+}
 
-	// Always sign again as it's not clear how far the
-	// server has handled a previous attempt.
-	u, err := url.Parse(req.baseurl)
+// signV2 signs req with the V2 scheme, adding the signature to header, or
+// to the params of req if they set Expires.
+func (s3 *S3) signV2(req *request, header http.Header) error {
+	auth, err := s3.Auth.Resolve()
 	if err != nil {
-		return fmt.Errorf("bad S3 endpoint URL %q: %v", req.baseurl, err)
+		return err
+	}
+	header["Date"] = []string{s3.clock.Now().Format(time.RFC1123)}
+	if token := auth.Token(); token != "" {
+		header["X-Amz-Security-Token"] = []string{token}
+	}
+	signpath := (&url.URL{Path: req.signpath}).String()
+	payload := sign(auth, req.method, signpath, req.params, header)
+	if logger := s3.signingLogger(); logger != nil {
+		logger.Log("aws: s3: string to sign:\n" + aws.RedactSigned(payload))
+	}
+	return nil
+}
+
+// maxPresignExpiry is the longest validity of a V4 presigned URL.
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// presign returns the URL of req presigned with the V4 scheme, valid for
+// expires. The headers of req are signed and must be sent along with the
+// URL.
+func (s3 *S3) presign(req *request, expires time.Duration) (string, error) {
+	if expires <= 0 || expires > maxPresignExpiry {
+		return "", fmt.Errorf("s3: presigned URLs must expire within %v, not %v", maxPresignExpiry, expires)
+	}
+	header := make(http.Header)
+	for k, v := range req.headers {
+		header[k] = v
+	}
+	if err := s3.prepare(req); err != nil {
+		return "", err
+	}
+	u, err := req.url()
+	if err != nil {
+		return "", err
 	}
-	reqSignpathSpaceFix := (&url.URL{Path: signpath}).String()
-	req.headers["Host"] = []string{u.Host}
-	req.headers["Date"] = []string{time.Now().In(time.UTC).Format(time.RFC1123)}
-	if s3.Auth.Token() != "" {
-		req.headers["X-Amz-Security-Token"] = []string{s3.Auth.Token()}
+	auth, err := s3.Auth.Resolve()
+	if err != nil {
+		return "", err
+	}
+	hreq := &http.Request{
+		Method: req.method,
+		URL:    u,
+		Host:   u.Host,
+		Header: header,
+	}
+	signer := aws.NewV4Signer(auth, "s3", s3.Region)
+	signer.Clock = s3.clock
+	signer.Logger = s3.signingLogger()
+	signer.Presign(hreq, expires)
+	return hreq.URL.String(), nil
+}
+
+// streamingChunkSize is the size of the chunks request bodies are signed
+// in when they can't be hashed upfront.
+const streamingChunkSize = 64 * 1024
+
+// signV4 signs hreq, built from req, with the V4 scheme. In-memory
+// payloads that can be rewound are hashed, while other payloads of known
+// length are streamed in signed chunks.
+func (s3 *S3) signV4(req *request, hreq *http.Request) error {
+	auth, err := s3.Auth.Resolve()
+	if err != nil {
+		return err
 	}
-	sign(s3.Auth, req.method, reqSignpathSpaceFix, req.params, req.headers)
+	// Signing adds headers that must not leak into retries of req.
+	header := make(http.Header, len(hreq.Header))
+	for k, v := range hreq.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	hreq.Header = header
+	hreq.Host = hreq.URL.Host
+	if token := auth.Token(); token != "" {
+		hreq.Header.Set("X-Amz-Security-Token", token)
+	}
+	hreq.Header.Del("Date")
+	hreq.Header.Del("X-Amz-Content-Sha256")
+	hreq.Header.Set("X-Amz-Date", s3.clock.Now().Format(aws.ISO8601BasicFormat))
+	signer := aws.NewV4Signer(auth, "s3", s3.Region)
+	signer.Logger = s3.signingLogger()
+
+	if hreq.Body == nil {
+		signer.Sign(hreq)
+		return nil
+	}
+	if s3.UnsignedPayload {
+		hreq.Header.Set("X-Amz-Content-Sha256", aws.UnsignedPayload)
+		signer.Sign(hreq)
+		return nil
+	}
+	if seeker, ok := req.payload.(io.ReadSeeker); ok {
+		start, err := seeker.Seek(0, 1)
+		if err != nil {
+			return err
+		}
+		h := sha256.New()
+		if _, err := io.Copy(h, seeker); err != nil {
+			return err
+		}
+		if _, err := seeker.Seek(start, 0); err != nil {
+			return err
+		}
+		hreq.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(h.Sum(nil)))
+		signer.Sign(hreq)
+		return nil
+	}
+	if hreq.ContentLength > 0 {
+		signer.SignStreaming(hreq, streamingChunkSize)
+		return nil
+	}
+	hreq.Header.Set("X-Amz-Content-Sha256", aws.UnsignedPayload)
+	signer.Sign(hreq)
 	return nil
 }
 
@@ -1003,59 +1342,150 @@ func (s3 *S3) client() *http.Client {
 	return s3.httpClient
 }
 
-// run sends req and returns the http response from the server.
-// If resp is not nil, the XML data contained in the response
-// body will be unmarshalled on it.
+// run sends req through the request pipeline and returns the http
+// response from the server. If resp is not nil, the XML data contained in
+// the response body will be unmarshalled on it, otherwise the body is left
+// for the caller to read and close.
 func (s3 *S3) run(req *request, resp interface{}) (*http.Response, error) {
-	if debug {
-		log.Printf("Running S3 request: %#v", req)
-	}
-
 	u, err := req.url()
 	if err != nil {
 		return nil, err
 	}
-
-	hreq := http.Request{
-		URL:        u,
-		Method:     req.method,
-		ProtoMajor: 1,
-		ProtoMinor: 1,
-		Close:      true,
-		Header:     req.headers,
+	r := &aws.Request{
+		Service:         "s3",
+		Operation:       req.op,
+		Region:          s3.Region,
+		Auth:            s3.Auth,
+		Context:         s3.Context(),
+		Method:          req.method,
+		Endpoint:        u.String(),
+		HTTPClient:      s3.client(),
+		Result:          resp,
+		Stream:          resp == nil,
+		Idempotent:      req.method != "POST" || req.op == "CompleteMultipartUpload",
+		Clock:           s3.clock,
+		Logger:          s3.Logger,
+		LogLevel:        s3.LogLevel,
+		Instrumentation: s3.Instrumentation,
+		Handlers:        aws.ChainHandlers(s3.handlers(req), aws.GlobalHandlers, s3.Handlers),
+	}
+	if _, ok := req.payload.(io.Seeker); req.payload != nil && !ok {
+		// The payload cannot be sent again once read.
+		r.Handlers.Retry.PushBack(aws.NoRetryPolicy.Handler())
+	}
+	if err := r.Send(); err != nil {
+		return nil, err
 	}
+	return r.HTTPResponse, nil
+}
 
-	if v, ok := req.headers["Content-Length"]; ok {
-		hreq.ContentLength, _ = strconv.ParseInt(v[0], 10, 64)
-		delete(req.headers, "Content-Length")
-		if hreq.ContentLength == 0 {
-			req.payload = nil
+// handlers returns the handlers of the request req is sent with, run
+// before the aws.GlobalHandlers and the Handlers of s3.
+func (s3 *S3) handlers(req *request) aws.Handlers {
+	var h aws.Handlers
+	var start int64
+	h.Build.PushBack(aws.NamedHandler{Name: "s3.Build", Fn: func(r *aws.Request) {
+		if seeker, ok := req.payload.(io.Seeker); ok {
+			// Retries send the payload again from where the first
+			// attempt started reading it.
+			var err error
+			if r.RetryCount == 0 {
+				start, err = seeker.Seek(0, io.SeekCurrent)
+			} else {
+				_, err = seeker.Seek(start, io.SeekStart)
+			}
+			if err != nil {
+				r.Error = err
+				return
+			}
 		}
-	}
-	if req.payload != nil {
-		hreq.Body = ioutil.NopCloser(req.payload)
-	}
+		u, err := url.Parse(r.Endpoint)
+		if err != nil {
+			r.Error = err
+			return
+		}
+		hreq := &http.Request{
+			URL:        u,
+			Method:     req.method,
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Close:      true,
+			Header:     make(http.Header, len(req.headers)),
+			Host:       u.Host,
+		}
+		for k, v := range req.headers {
+			hreq.Header[k] = append([]string(nil), v...)
+		}
+		payload := req.payload
+		if v, ok := hreq.Header["Content-Length"]; ok {
+			hreq.ContentLength, _ = strconv.ParseInt(v[0], 10, 64)
+			hreq.Header.Del("Content-Length")
+			if hreq.ContentLength == 0 {
+				payload = nil
+			}
+		}
+		if payload != nil {
+			hreq.Body = ioutil.NopCloser(payload)
+		}
+		r.HTTPRequest = hreq.WithContext(r.Context)
+	}})
+	h.Sign.PushBack(aws.NamedHandler{Name: "s3.Sign", Fn: func(r *aws.Request) {
+		if s3.Signature == aws.V4Signature {
+			r.Error = s3.signV4(req, r.HTTPRequest)
+		} else {
+			r.Error = s3.signV2(req, r.HTTPRequest.Header)
+		}
+	}})
+	h.Send.PushBack(aws.SendHandler)
+	h.Unmarshal.PushBack(aws.UnmarshalXMLHandler)
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("s3.UnmarshalError", func(resp *http.Response) error {
+		err := buildError(resp)
+		if aws.IsClockSkew(err) {
+			// Retries are signed at the time of S3.
+			s3.clock.Correct(resp)
+		}
+		return err
+	}))
+	p := s3.retryPolicy()
+	h.Retry.PushBack(p.Handler())
+	h.Retry.PushBack(aws.NamedHandler{Name: "s3.Retry", Fn: func(r *aws.Request) {
+		// Uploads and buckets just created may not be visible yet.
+		if !r.Retryable && (hasCode(r.Error, "NoSuchUpload") || hasCode(r.Error, "NoSuchBucket")) && r.RetryCount+1 < p.MaxAttempts {
+			r.Retryable, r.RetryDelay = true, p.Delay(r)
+		}
+	}})
+	return h
+}
 
-	hresp, err := s3.client().Do(&hreq)
-	if err != nil {
-		return nil, err
-	}
-	if debug {
-		dump, _ := httputil.DumpResponse(hresp, true)
-		log.Printf("} -> %s\n", dump)
+// retryPolicy returns the policy the requests of s3 are retried with.
+func (s3 *S3) retryPolicy() aws.RetryPolicy {
+	if s3.RetryPolicy != nil {
+		return *s3.RetryPolicy
 	}
-	if hresp.StatusCode != 200 && hresp.StatusCode != 204 && hresp.StatusCode != 206 {
-		defer hresp.Body.Close()
-		return nil, buildError(hresp)
+	a := s3.AttemptStrategy
+	if a == (aws.AttemptStrategy{}) || a == DefaultAttemptStrategy {
+		return aws.DefaultRetryPolicy
 	}
-	if resp != nil {
-		err = xml.NewDecoder(hresp.Body).Decode(resp)
-		hresp.Body.Close()
-		if debug {
-			log.Printf("goamz.s3> decoded xml into %#v", resp)
+	attempts := a.Min
+	if a.Delay > 0 {
+		if n := int(a.Total/a.Delay) + 1; n > attempts {
+			attempts = n
 		}
 	}
-	return hresp, err
+	return aws.RetryPolicy{
+		MaxAttempts:       attempts,
+		BaseDelay:         a.Delay,
+		ThrottleBaseDelay: a.Delay,
+		MaxDelay:          a.Delay,
+	}
+}
+
+// signingLogger returns the Logger signatures are logged to, if any.
+func (s3 *S3) signingLogger() aws.Logger {
+	if s3.LogLevel&aws.LogSigning != 0 {
+		return s3.Logger
+	}
+	return nil
 }
 
 // Error represents an error in an operation with S3.
@@ -1072,18 +1502,14 @@ func (e *Error) Error() string {
 	return e.Message
 }
 
-func buildError(r *http.Response) error {
-	if debug {
-		log.Printf("got error (status code %v)", r.StatusCode)
-		data, err := ioutil.ReadAll(r.Body)
-		if err != nil {
-			log.Printf("\tread error: %v", err)
-		} else {
-			log.Printf("\tdata:\n%s\n\n", data)
-		}
-		r.Body = ioutil.NopCloser(bytes.NewBuffer(data))
-	}
+// HTTPStatusCode, ErrorCode, ErrorMessage and RequestID implement
+// aws.APIError.
+func (e *Error) HTTPStatusCode() int  { return e.StatusCode }
+func (e *Error) ErrorCode() string    { return e.Code }
+func (e *Error) ErrorMessage() string { return e.Message }
+func (e *Error) RequestID() string    { return e.RequestId }
 
+func buildError(r *http.Response) error {
 	err := Error{}
 	// TODO return error if Unmarshal fails?
 	xml.NewDecoder(r.Body).Decode(&err)
@@ -1092,50 +1518,9 @@ func buildError(r *http.Response) error {
 	if err.Message == "" {
 		err.Message = r.Status
 	}
-	if debug {
-		log.Printf("err: %#v\n", err)
-	}
 	return &err
 }
 
-func shouldRetry(err error) bool {
-	if err == nil {
-		return false
-	}
-	if e, ok := err.(*url.Error); ok {
-		// Transport returns this string if it detects a write on a connection which
-		// has already had an error
-		if e.Err.Error() == "http: can't write HTTP request on broken connection" {
-			return true
-		}
-		err = e.Err
-	}
-
-	switch err {
-	case io.ErrUnexpectedEOF, io.EOF:
-		return true
-	}
-	switch e := err.(type) {
-	case *net.DNSError:
-		return true
-	case *net.OpError:
-		switch e.Op {
-		case "read", "write", "WSARecv", "WSASend", "ConnectEx":
-			return true
-		}
-	case *Error:
-		switch e.Code {
-		case "InternalError", "NoSuchUpload", "NoSuchBucket", "RequestTimeout":
-			return true
-		}
-	// let's handle tls handshake timeout issues and similar temporary errors
-	case net.Error:
-		return e.Temporary()
-	}
-
-	return false
-}
-
 func hasCode(err error, code string) bool {
 	s3err, ok := err.(*Error)
 	return ok && s3err.Code == code
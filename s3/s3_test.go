@@ -2,8 +2,13 @@ package s3_test
 
 import (
 	"bytes"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -30,17 +35,14 @@ func (s *S) SetUpSuite(c *C) {
 	testServer.Start()
 	auth := aws.Auth{AccessKey: "abc", SecretKey: "123"}
 	s.s3 = s3.New(auth, aws.Region{Name: "faux-region-1", S3Endpoint: testServer.URL})
-}
-
-func (s *S) TearDownSuite(c *C) {
-	s.s3.AttemptStrategy = s3.DefaultAttemptStrategy
+	// Upload tests send parts of a few bytes.
+	s3.SetMinUploadPartSize(1)
 }
 
 func (s *S) SetUpTest(c *C) {
-	s.s3.AttemptStrategy = aws.AttemptStrategy{
-		Total: 300 * time.Millisecond,
-		Delay: 100 * time.Millisecond,
-	}
+	s.s3.Handlers = aws.Handlers{}
+	s.s3.RetryPolicy = nil
+	s.s3.AttemptStrategy = s3.DefaultAttemptStrategy
 }
 
 func (s *S) TearDownTest(c *C) {
@@ -48,7 +50,7 @@ func (s *S) TearDownTest(c *C) {
 }
 
 func (s *S) DisableRetries() {
-	s.s3.AttemptStrategy = aws.AttemptStrategy{}
+	s.s3.Handlers.Retry.PushBack(aws.NoRetryPolicy.Handler())
 }
 
 // This will trigger an error when tests are run with -race before this commit.
@@ -137,6 +139,68 @@ func (s *S) TestGet(c *C) {
 	c.Assert(string(data), Equals, "content")
 }
 
+func (s *S) TestGetLogged(c *C) {
+	testServer.Response(200, nil, "content")
+
+	var lines []string
+	auth := aws.NewAuth("abc", "123", "session-token", time.Now().Add(time.Hour))
+	logged := s3.New(*auth, aws.Region{Name: "faux-region-1", S3Endpoint: testServer.URL})
+	logged.Logger = aws.LoggerFunc(func(args ...interface{}) { lines = append(lines, fmt.Sprint(args...)) })
+	logged.LogLevel = aws.LogAll
+	data, err := logged.Bucket("bucket").Get("name")
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "content")
+
+	req := testServer.WaitRequest()
+	c.Assert(req.Header.Get("X-Amz-Security-Token"), Equals, "session-token")
+	c.Assert(lines, HasLen, 3)
+	c.Assert(lines[0], Matches, "(?s)aws: canonical request:\nGET\n/bucket/name\n.*\nx-amz-security-token:REDACTED\n.*")
+	c.Assert(lines[1], Matches, "(?s)aws: GetObject: GET http://.*/bucket/name\n.*  Authorization: REDACTED\n.*  X-Amz-Security-Token: REDACTED.*")
+	c.Assert(lines[2], Matches, "(?s)aws: GetObject: 200 OK in .*\ncontent")
+	c.Assert(strings.Contains(strings.Join(lines, "\n"), "session-token"), Equals, false)
+}
+
+func (s *S) TestInstrumentation(c *C) {
+	inst := &aws.MemoryInstrumentation{}
+	s.s3.Instrumentation = inst
+	defer func() { s.s3.Instrumentation = nil }()
+	b := s.s3.Bucket("bucket")
+
+	testServer.Response(500, nil, InternalErrorDump)
+	testServer.Response(200, nil, "content")
+	data, err := b.Get("name")
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "content")
+	testServer.Response(200, nil, "")
+	c.Assert(b.PutBucket(s3.Private), IsNil)
+	testServer.Response(200, nil, "")
+	c.Assert(b.PutBucketSubresource("website", bytes.NewReader(nil), 0), IsNil)
+	testServer.Response(200, nil, GetListResultDump1)
+	_, err = b.List("", "", "", 0)
+	c.Assert(err, IsNil)
+	testServer.Response(200, nil, InitMultiResultDump)
+	_, err = b.InitMulti("multi", "text/plain", s3.Private)
+	c.Assert(err, IsNil)
+
+	attempts := inst.Attempts()
+	c.Assert(attempts, HasLen, 6)
+	var ops []string
+	for _, a := range attempts {
+		c.Assert(a.Service, Equals, "s3")
+		c.Assert(a.Region, Equals, "faux-region-1")
+		ops = append(ops, a.Operation)
+	}
+	c.Assert(ops, DeepEquals, []string{"GetObject", "GetObject", "CreateBucket", "PutBucketWebsite", "ListObjects", "CreateMultipartUpload"})
+	c.Assert(attempts[0].Attempt, Equals, 1)
+	c.Assert(attempts[0].StatusCode, Equals, 500)
+	c.Assert(attempts[0].ErrorCode, Equals, "InternalError")
+	c.Assert(attempts[1].Attempt, Equals, 2)
+	c.Assert(attempts[1].StatusCode, Equals, 200)
+	c.Assert(attempts[1].BytesReceived, Equals, int64(len("content")))
+	c.Assert(attempts[1].Err, IsNil)
+	c.Assert(inst.Stats("s3", "GetObject").Retries, Equals, 1)
+}
+
 func (s *S) TestURL(c *C) {
 	testServer.Response(200, nil, "content")
 
@@ -194,6 +258,18 @@ func (s *S) TestGetNotFound(c *C) {
 	c.Assert(s3err.Message, Equals, "The specified bucket does not exist")
 	c.Assert(s3err.Error(), Equals, "The specified bucket does not exist")
 	c.Assert(data, IsNil)
+	c.Assert(aws.IsNotFound(err), Equals, true)
+}
+
+func (s *S) TestGetSlowDownRetried(c *C) {
+	testServer.Response(503, nil, SlowDownErrorDump)
+	testServer.Response(200, nil, "content")
+
+	b := s.s3.Bucket("bucket")
+	data, err := b.Get("name")
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "content")
+	c.Assert(testServer.WaitRequests(2), HasLen, 2)
 }
 
 // PutObject docs: http://goo.gl/FEBPD
@@ -215,7 +291,194 @@ func (s *S) TestPutObject(c *C) {
 	c.Assert(req.Header["X-Amz-Acl"], DeepEquals, []string{"private"})
 }
 
+func (s *S) TestPutObjectV4Signed(c *C) {
+	testServer.Response(200, nil, "")
+
+	b := s.s3.Bucket("bucket")
+	err := b.Put("name", []byte("content"), "content-type", s3.Private, s3.Options{})
+	c.Assert(err, IsNil)
+
+	req := testServer.WaitRequest()
+	c.Assert(req.Header.Get("Authorization"), Matches, "AWS4-HMAC-SHA256 Credential=abc/[0-9]{8}/faux-region-1/s3/aws4_request, .*")
+	c.Assert(req.Header.Get("X-Amz-Date"), Matches, "[0-9]{8}T[0-9]{6}Z")
+	// In-memory bodies are hashed rather than streamed.
+	c.Assert(req.Header.Get("X-Amz-Content-Sha256"), Equals, "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73")
+	c.Assert(readAll(req.Body), Equals, "content")
+}
+
+func (s *S) TestPutReaderUnsignedPayload(c *C) {
+	testServer.Response(200, nil, "")
+
+	s.s3.UnsignedPayload = true
+	defer func() { s.s3.UnsignedPayload = false }()
+	b := s.s3.Bucket("bucket")
+	buf := bytes.NewBufferString("content")
+	err := b.PutReader("name", buf, int64(buf.Len()), "content-type", s3.Private, s3.Options{})
+	c.Assert(err, IsNil)
+
+	req := testServer.WaitRequest()
+	c.Assert(req.Header.Get("X-Amz-Content-Sha256"), Equals, aws.UnsignedPayload)
+	c.Assert(req.Header["Content-Length"], DeepEquals, []string{"7"})
+	c.Assert(readAll(req.Body), Equals, "content")
+}
+
+func (s *S) TestPutObjectRetried(c *C) {
+	testServer.Response(500, nil, InternalErrorDump)
+	testServer.Response(200, nil, "")
+
+	b := s.s3.Bucket("bucket")
+	err := b.Put("name", []byte("content"), "content-type", s3.Private, s3.Options{})
+	c.Assert(err, IsNil)
+
+	for _, req := range testServer.WaitRequests(2) {
+		c.Assert(readAll(req.Body), Equals, "content")
+	}
+}
+
+func (s *S) TestRetryPolicy(c *C) {
+	s.s3.RetryPolicy = &aws.RetryPolicy{MaxAttempts: 4}
+	for i := 0; i < 3; i++ {
+		testServer.Response(500, nil, InternalErrorDump)
+	}
+	testServer.Response(200, nil, "")
+
+	b := s.s3.Bucket("bucket")
+	err := b.Put("name", []byte("content"), "content-type", s3.Private, s3.Options{})
+	c.Assert(err, IsNil)
+	testServer.WaitRequests(4)
+}
+
+func (s *S) TestAttemptStrategyRetries(c *C) {
+	s.s3.AttemptStrategy = aws.AttemptStrategy{Min: 4}
+	for i := 0; i < 4; i++ {
+		testServer.Response(500, nil, InternalErrorDump)
+	}
+
+	b := s.s3.Bucket("bucket")
+	err := b.Put("name", []byte("content"), "content-type", s3.Private, s3.Options{})
+	c.Assert(err, NotNil)
+	testServer.WaitRequests(4)
+}
+
+func (s *S) TestPutReaderNotRetried(c *C) {
+	testServer.Response(500, nil, InternalErrorDump)
+
+	b := s.s3.Bucket("bucket")
+	r := struct{ io.Reader }{strings.NewReader("content")}
+	err := b.PutReader("name", r, 7, "content-type", s3.Private, s3.Options{})
+	c.Assert(err, NotNil)
+	c.Assert(err.(*s3.Error).Code, Equals, "InternalError")
+
+	req := testServer.WaitRequest()
+	c.Assert(readAll(req.Body), Matches, "(?s).*\r\ncontent\r\n.*")
+}
+
+func (s *S) TestPutObjectV2Signed(c *C) {
+	testServer.Response(200, nil, "")
+
+	s.s3.Signature = aws.V2Signature
+	defer func() { s.s3.Signature = aws.V4Signature }()
+	b := s.s3.Bucket("bucket")
+	err := b.Put("name", []byte("content"), "content-type", s3.Private, s3.Options{})
+	c.Assert(err, IsNil)
+
+	req := testServer.WaitRequest()
+	c.Assert(req.Header.Get("Authorization"), Matches, "AWS abc:.*")
+	c.Assert(req.Header.Get("X-Amz-Content-Sha256"), Equals, "")
+}
+
+func (s *S) TestPresignedURL(c *C) {
+	b := s.s3.Bucket("bucket")
+	u, err := b.PresignedURL("DELETE", "dir/name", time.Hour)
+	c.Assert(err, IsNil)
+
+	parsed, err := url.Parse(u)
+	c.Assert(err, IsNil)
+	c.Assert(parsed.Path, Equals, "/bucket/dir/name")
+	query := parsed.Query()
+	c.Assert(query.Get("X-Amz-Algorithm"), Equals, "AWS4-HMAC-SHA256")
+	c.Assert(query.Get("X-Amz-Credential"), Matches, "abc/[0-9]{8}/faux-region-1/s3/aws4_request")
+	c.Assert(query.Get("X-Amz-Expires"), Equals, "3600")
+	c.Assert(query.Get("X-Amz-SignedHeaders"), Equals, "host")
+	c.Assert(query.Get("X-Amz-Signature"), Matches, "[0-9a-f]{64}")
+
+	// The signed URL works without further credentials.
+	testServer.Response(204, nil, "")
+	hreq, err := http.NewRequest("DELETE", u, nil)
+	c.Assert(err, IsNil)
+	resp, err := http.DefaultClient.Do(hreq)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+
+	req := testServer.WaitRequest()
+	c.Assert(req.Method, Equals, "DELETE")
+	c.Assert(req.Header.Get("Authorization"), Equals, "")
+	c.Assert(req.URL.Query().Get("X-Amz-Signature"), Equals, query.Get("X-Amz-Signature"))
+}
+
+func (s *S) TestSigningErrors(c *C) {
+	failing := s3.New(aws.NewProviderAuth(&aws.StaticProvider{}), s.s3.Region)
+	b := failing.Bucket("bucket")
+
+	_, err := b.SignedUploadURL("name", "PUT", "text/plain", time.Now().Add(time.Hour))
+	c.Assert(err, ErrorMatches, "static credentials are empty")
+	c.Assert(b.UploadSignedURL("name", "PUT", "text/plain", time.Now().Add(time.Hour)), Equals, "")
+
+	for _, expires := range []time.Time{time.Now().Add(time.Hour), time.Now().Add(30 * 24 * time.Hour)} {
+		_, err = b.SignedGetURL("name", expires)
+		c.Assert(err, ErrorMatches, "static credentials are empty")
+		c.Assert(b.SignedURL("name", expires), Equals, "")
+	}
+
+	_, _, err = b.PostForm("name", time.Now().Add(time.Hour), "")
+	c.Assert(err, ErrorMatches, "static credentials are empty")
+	action, fields := b.PostFormArgs("name", time.Now().Add(time.Hour), "")
+	c.Assert(action, Equals, "")
+	c.Assert(fields, IsNil)
+}
+
+func (s *S) TestPresignedURLExpiry(c *C) {
+	b := s.s3.Bucket("bucket")
+	for _, expires := range []time.Duration{0, -time.Minute, 8 * 24 * time.Hour} {
+		_, err := b.PresignedURL("GET", "name", expires)
+		c.Assert(err, ErrorMatches, "s3: presigned URLs must expire within .*")
+	}
+	_, err := b.PresignedURL("GET", "name", 7*24*time.Hour)
+	c.Assert(err, IsNil)
+}
+
+func (s *S) TestSignedURLBeyondV4Expiry(c *C) {
+	b := s.s3.Bucket("bucket")
+	expires := time.Now().Add(30 * 24 * time.Hour)
+	parsed, err := url.Parse(b.SignedURL("name", expires))
+	c.Assert(err, IsNil)
+	c.Assert(parsed.Query().Get("X-Amz-Signature"), Equals, "")
+	c.Assert(parsed.Query().Get("Signature"), Not(Equals), "")
+	c.Assert(parsed.Query().Get("Expires"), Equals, strconv.FormatInt(expires.Unix(), 10))
+}
+
+func (s *S) TestUploadSignedURLBeyondV4Expiry(c *C) {
+	b := s.s3.Bucket("bucket")
+	expires := time.Now().Add(30 * 24 * time.Hour)
+	parsed, err := url.Parse(b.UploadSignedURL("name", "PUT", "text/plain", expires))
+	c.Assert(err, IsNil)
+	c.Assert(parsed.Query().Get("X-Amz-Signature"), Equals, "")
+	c.Assert(parsed.Query().Get("Signature"), Not(Equals), "")
+	c.Assert(parsed.Query().Get("Expires"), Equals, strconv.FormatInt(expires.Unix(), 10))
+}
+
+func (s *S) TestSignedURLV4(c *C) {
+	b := s.s3.Bucket("bucket")
+	u := b.SignedURL("name", time.Now().Add(time.Minute))
+	parsed, err := url.Parse(u)
+	c.Assert(err, IsNil)
+	c.Assert(parsed.Query().Get("X-Amz-Expires"), Matches, "(59|60)")
+	c.Assert(parsed.Query().Get("Signature"), Equals, "")
+}
+
 func (s *S) TestPutObjectReadTimeout(c *C) {
+	// Retries would consume the responses meant for the next request.
+	s.DisableRetries()
 	s.s3.ReadTimeout = 50 * time.Millisecond
 	defer func() {
 		s.s3.ReadTimeout = 0
@@ -275,7 +538,11 @@ func (s *S) TestPutReader(c *C) {
 	c.Assert(req.URL.Path, Equals, "/bucket/name")
 	c.Assert(req.Header["Date"], Not(DeepEquals), []string{""})
 	c.Assert(req.Header["Content-Type"], DeepEquals, []string{"content-type"})
-	c.Assert(req.Header["Content-Length"], DeepEquals, []string{"7"})
+	// Readers are streamed in signed chunks.
+	c.Assert(req.Header["X-Amz-Decoded-Content-Length"], DeepEquals, []string{"7"})
+	c.Assert(req.Header["Content-Encoding"], DeepEquals, []string{"aws-chunked"})
+	c.Assert(req.Header["X-Amz-Content-Sha256"], DeepEquals, []string{aws.StreamingPayload})
+	c.Assert(readAll(req.Body), Matches, "7;chunk-signature=[0-9a-f]{64}\r\ncontent\r\n0;chunk-signature=[0-9a-f]{64}\r\n\r\n")
 	//c.Assert(req.Header["Content-MD5"], Equals, "...")
 	c.Assert(req.Header["X-Amz-Acl"], DeepEquals, []string{"private"})
 }
@@ -299,7 +566,11 @@ func (s *S) TestPutReaderHeader(c *C) {
 	c.Assert(req.URL.Path, Equals, "/bucket/name")
 	c.Assert(req.Header["Date"], Not(DeepEquals), []string{""})
 	c.Assert(req.Header["Content-Type"], DeepEquals, []string{"content-type"})
-	c.Assert(req.Header["Content-Length"], DeepEquals, []string{"7"})
+	// Readers are streamed in signed chunks.
+	c.Assert(req.Header["X-Amz-Decoded-Content-Length"], DeepEquals, []string{"7"})
+	c.Assert(req.Header["Content-Encoding"], DeepEquals, []string{"aws-chunked"})
+	c.Assert(req.Header["X-Amz-Content-Sha256"], DeepEquals, []string{aws.StreamingPayload})
+	c.Assert(readAll(req.Body), Matches, "7;chunk-signature=[0-9a-f]{64}\r\ncontent\r\n0;chunk-signature=[0-9a-f]{64}\r\n\r\n")
 	//c.Assert(req.Header["Content-MD5"], Equals, "...")
 	c.Assert(req.Header["X-Amz-Acl"], DeepEquals, []string{"private"})
 }
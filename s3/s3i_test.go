@@ -389,6 +389,42 @@ func (s *ClientTests) TestBucketList(c *C) {
 	}
 }
 
+func (s *ClientTests) TestBucketListPages(c *C) {
+	b := testBucket(s.s3)
+	err := b.PutBucket(s3.Private)
+	c.Assert(err, IsNil)
+
+	for _, path := range objectNames {
+		err := b.Put(path, nil, "text/plain", s3.Private, s3.Options{})
+		c.Assert(err, IsNil)
+		defer b.Del(path)
+	}
+
+	for _, delim := range []string{"", "/"} {
+		all, err := b.List("", delim, "", 0)
+		c.Assert(err, IsNil)
+		c.Assert(all.IsTruncated, Equals, false)
+
+		var keys, prefixes []string
+		p := b.ListPages("", delim, 2)
+		for p.Next() {
+			c.Assert(len(p.Page().Contents)+len(p.Page().CommonPrefixes) <= 2, Equals, true)
+			for _, k := range p.Page().Contents {
+				keys = append(keys, k.Key)
+			}
+			prefixes = append(prefixes, p.Page().CommonPrefixes...)
+		}
+		c.Assert(p.Err(), IsNil)
+
+		var expected []string
+		for _, k := range all.Contents {
+			expected = append(expected, k.Key)
+		}
+		c.Check(keys, DeepEquals, expected)
+		c.Check(prefixes, DeepEquals, all.CommonPrefixes)
+	}
+}
+
 func etag(data []byte) string {
 	sum := md5.New()
 	sum.Write(data)
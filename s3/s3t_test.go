@@ -78,6 +78,10 @@ func (s *LocalServerSuite) TestBucketList(c *C) {
 	s.clientTests.TestBucketList(c)
 }
 
+func (s *LocalServerSuite) TestBucketListPages(c *C) {
+	s.clientTests.TestBucketListPages(c)
+}
+
 func (s *LocalServerSuite) TestDoublePutBucket(c *C) {
 	s.clientTests.TestDoublePutBucket(c)
 }
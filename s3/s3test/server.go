@@ -578,19 +578,32 @@ func (objr objectResource) put(a *action) interface{} {
 			fatalf(400, "InvalidDigest", "The Content-MD5 you specified was invalid")
 		}
 	}
-	sum := md5.New()
 	// TODO avoid holding lock while reading data.
-	data, err := ioutil.ReadAll(io.TeeReader(a.req.Body, sum))
+	data, err := ioutil.ReadAll(a.req.Body)
 	if err != nil {
 		fatalf(400, "TODO", "read error")
 	}
+	if a.req.ContentLength >= 0 && int64(len(data)) != a.req.ContentLength {
+		fatalf(400, "IncompleteBody", "You did not provide the number of bytes specified by the Content-Length HTTP header")
+	}
+	if enc := a.req.Header.Get("Content-Encoding"); strings.HasPrefix(enc, "aws-chunked") {
+		data = decodeChunked(data)
+		if l := a.req.Header.Get("X-Amz-Decoded-Content-Length"); l != strconv.Itoa(len(data)) {
+			fatalf(400, "IncompleteBody", "You did not provide the number of bytes specified by the X-Amz-Decoded-Content-Length HTTP header")
+		}
+		enc = strings.TrimPrefix(strings.TrimPrefix(enc, "aws-chunked"), ",")
+		if enc == "" {
+			a.req.Header.Del("Content-Encoding")
+		} else {
+			a.req.Header.Set("Content-Encoding", enc)
+		}
+	}
+	sum := md5.New()
+	sum.Write(data)
 	gotHash := sum.Sum(nil)
 	if expectHash != nil && bytes.Compare(gotHash, expectHash) != 0 {
 		fatalf(400, "BadDigest", "The Content-MD5 you specified did not match what we received")
 	}
-	if a.req.ContentLength >= 0 && int64(len(data)) != a.req.ContentLength {
-		fatalf(400, "IncompleteBody", "You did not provide the number of bytes specified by the Content-Length HTTP header")
-	}
 
 	// PUT request has been successful - save data and metadata
 	for key, values := range a.req.Header {
@@ -610,6 +623,28 @@ func (objr objectResource) put(a *action) interface{} {
 	return nil
 }
 
+// decodeChunked returns the payload of a body sent in signed chunks
+// (Content-Encoding: aws-chunked). The chunk signatures aren't checked.
+func decodeChunked(body []byte) []byte {
+	var data []byte
+	for {
+		i := bytes.Index(body, []byte("\r\n"))
+		if i < 0 {
+			fatalf(400, "IncompleteBody", "malformed aws-chunked body")
+		}
+		size, err := strconv.ParseInt(strings.SplitN(string(body[:i]), ";", 2)[0], 16, 64)
+		if err != nil || int64(len(body)) < int64(i)+2+size+2 {
+			fatalf(400, "IncompleteBody", "malformed aws-chunked body")
+		}
+		if size == 0 {
+			return data
+		}
+		body = body[i+2:]
+		data = append(data, body[:size]...)
+		body = body[size+2:]
+	}
+}
+
 func (objr objectResource) delete(a *action) interface{} {
 	delete(objr.bucket.objects, objr.name)
 	return nil
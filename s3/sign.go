@@ -5,7 +5,6 @@ import (
 	"crypto/sha1"
 	"encoding/base64"
 	"github.com/goamz/goamz/aws"
-	"log"
 	"sort"
 	"strings"
 )
@@ -66,7 +65,8 @@ func (l keySortableTupleList) Swap(i, j int) {
 	l[i], l[j] = l[j], l[i]
 }
 
-func sign(auth aws.Auth, method, canonicalPath string, params, headers map[string][]string) {
+// sign signs a request with the V2 scheme, and returns the string signed.
+func sign(auth aws.Auth, method, canonicalPath string, params, headers map[string][]string) string {
 	var md5, ctype, date, xamz string
 	var xamzDate bool
 	var sarray keySortableTupleList
@@ -134,8 +134,5 @@ func sign(auth aws.Auth, method, canonicalPath string, params, headers map[strin
 	} else {
 		headers["Authorization"] = []string{"AWS " + auth.AccessKey + ":" + string(signature)}
 	}
-	if debug {
-		log.Printf("Signature payload: %q", payload)
-		log.Printf("Signature: %q", signature)
-	}
+	return payload
 }
@@ -0,0 +1,222 @@
+package s3
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+)
+
+const (
+	// MinUploadPartSize is the smallest size S3 accepts for the parts
+	// of a multipart upload other than the last one.
+	MinUploadPartSize = 5 << 20
+
+	// MaxUploadPartSize is the largest size S3 accepts for a part.
+	MaxUploadPartSize = 5 << 30
+
+	// MaxUploadParts is the largest number of parts of a multipart
+	// upload.
+	MaxUploadParts = 10000
+
+	// DefaultUploadPartSize is the part size used by an Uploader whose
+	// PartSize is zero.
+	DefaultUploadPartSize = 8 << 20
+
+	// DefaultUploadConcurrency is the number of parts sent at once by an
+	// Uploader whose Concurrency is zero.
+	DefaultUploadConcurrency = 5
+)
+
+// minUploadPartSize is the smallest part size an Uploader uses. Tests
+// lower it.
+var minUploadPartSize int64 = MinUploadPartSize
+
+// partSizeDoubling is the number of parts after which the part size of an
+// Uploader is doubled.
+const partSizeDoubling = 1000
+
+var ErrUploadTooLarge = errors.New("s3: object too large for a multipart upload")
+
+// Uploader uploads objects from readers of unknown size.
+//
+// Objects smaller than a part are sent with a single PUT. Larger ones are
+// sent with a multipart upload, reading parts from the reader while
+// Concurrency of them are sent at once, so that no more than
+// Concurrency+1 parts are held in memory. The part size starts at
+// PartSize, and is doubled every 1000 parts so that, with the default
+// part size, objects up to the 5TB S3 limit fit in 10000 parts.
+//
+// Parts are retried like the other requests of the bucket's S3. If the
+// upload fails, the multipart upload is aborted.
+type Uploader struct {
+	Bucket *Bucket
+
+	// PartSize is the size of the first parts. Zero means
+	// DefaultUploadPartSize, and sizes below MinUploadPartSize, which S3
+	// would reject when completing the upload, mean MinUploadPartSize.
+	PartSize int64
+
+	// Concurrency is the number of parts sent at once. Zero means
+	// DefaultUploadConcurrency.
+	Concurrency int
+}
+
+// NewUploader returns an Uploader of b with the default part size and
+// concurrency.
+func NewUploader(b *Bucket) *Uploader {
+	return &Uploader{Bucket: b}
+}
+
+// Upload inserts an object into the bucket by consuming data from r until
+// EOF.
+func (u *Uploader) Upload(key string, r io.Reader, contType string, perm ACL, options Options) error {
+	data, last, err := readPart(r, u.partSize(1))
+	if err != nil {
+		return err
+	}
+	if last {
+		return u.Bucket.putBytes(key, data, contType, perm, options)
+	}
+
+	// ContentMD5 is that of the whole object, which parts don't have.
+	options.ContentMD5 = ""
+	m, err := u.Bucket.initMulti(key, contType, perm, options)
+	if err != nil {
+		return err
+	}
+	parts, err := u.putParts(m, r, data)
+	if err == nil {
+		err = m.Complete(parts)
+	}
+	if err != nil {
+		m.Abort()
+		return err
+	}
+	return nil
+}
+
+// putParts sends data as the first part of m, followed by the rest of r.
+func (u *Uploader) putParts(m *Multi, r io.Reader, data []byte) ([]Part, error) {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		parts []Part
+		err   error
+	)
+	setErr := func(e error) {
+		mu.Lock()
+		if err == nil {
+			err = e
+		}
+		mu.Unlock()
+	}
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return err != nil
+	}
+	sem := make(chan struct{}, u.concurrency())
+	last := false
+	for n := 1; ; n++ {
+		if n > MaxUploadParts {
+			setErr(ErrUploadTooLarge)
+			break
+		}
+		sem <- struct{}{}
+		if failed() {
+			break
+		}
+		wg.Add(1)
+		go func(n int, data []byte) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			part, perr := m.PutPart(n, bytes.NewReader(data))
+			if perr != nil {
+				setErr(perr)
+				return
+			}
+			mu.Lock()
+			parts = append(parts, part)
+			mu.Unlock()
+		}(n, data)
+		if last {
+			break
+		}
+
+		var rerr error
+		data, last, rerr = readPart(r, u.partSize(n+1))
+		if rerr != nil {
+			setErr(rerr)
+			break
+		}
+		if last && len(data) == 0 {
+			break
+		}
+	}
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+// partSize returns the size of part n.
+func (u *Uploader) partSize(n int) int64 {
+	size := u.PartSize
+	if size <= 0 {
+		size = DefaultUploadPartSize
+	}
+	if size < minUploadPartSize {
+		size = minUploadPartSize
+	}
+	for i := partSizeDoubling; i < n && size < MaxUploadPartSize; i += partSizeDoubling {
+		size *= 2
+	}
+	if size > MaxUploadPartSize {
+		size = MaxUploadPartSize
+	}
+	return size
+}
+
+func (u *Uploader) concurrency() int {
+	if u.Concurrency <= 0 {
+		return DefaultUploadConcurrency
+	}
+	return u.Concurrency
+}
+
+// readPart reads a part of up to size bytes from r. last tells whether r
+// ended before it was filled.
+func readPart(r io.Reader, size int64) (data []byte, last bool, err error) {
+	data = make([]byte, size)
+	n, err := io.ReadFull(r, data)
+	switch err {
+	case nil:
+		return data, false, nil
+	case io.EOF, io.ErrUnexpectedEOF:
+		return data[:n], true, nil
+	}
+	return nil, false, err
+}
+
+// putBytes inserts data into the bucket with a single PUT.
+func (b *Bucket) putBytes(key string, data []byte, contType string, perm ACL, options Options) error {
+	headers := map[string][]string{
+		"Content-Length": {strconv.Itoa(len(data))},
+		"Content-Type":   {contType},
+		"x-amz-acl":      {string(perm)},
+	}
+	options.addHeaders(headers)
+	req := &request{
+		method:  "PUT",
+		bucket:  b.Name,
+		path:    key,
+		headers: headers,
+		payload: bytes.NewReader(data),
+	}
+	return b.S3.query(req, nil)
+}
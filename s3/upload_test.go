@@ -0,0 +1,130 @@
+package s3_test
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"strings"
+
+	"github.com/goamz/goamz/s3"
+	. "gopkg.in/check.v1"
+)
+
+func (s *S) TestUploadSmall(c *C) {
+	testServer.Response(200, nil, "")
+
+	u := &s3.Uploader{Bucket: s.s3.Bucket("sample"), PartSize: 10}
+	err := u.Upload("name", strings.NewReader("content"), "text/plain", s3.Private, s3.Options{})
+	c.Assert(err, IsNil)
+
+	req := testServer.WaitRequest()
+	c.Assert(req.Method, Equals, "PUT")
+	c.Assert(req.URL.Path, Equals, "/sample/name")
+	c.Assert(req.Header["Content-Type"], DeepEquals, []string{"text/plain"})
+	c.Assert(req.Header["Content-Length"], DeepEquals, []string{"7"})
+	body, _ := ioutil.ReadAll(req.Body)
+	c.Assert(string(body), Equals, "content")
+}
+
+func (s *S) TestUploadMultipart(c *C) {
+	testServer.Response(200, nil, InitMultiResultDump)
+	testServer.Responses(5, 200, map[string]string{"ETag": `"etag"`}, "")
+	testServer.Response(200, nil, CompleteMultiResultDump)
+
+	u := &s3.Uploader{Bucket: s.s3.Bucket("sample"), PartSize: 5, Concurrency: 3}
+	data := "partApartBpartCpartDend"
+	err := u.Upload("multi", strings.NewReader(data), "text/plain", s3.Private, s3.Options{SSE: true})
+	c.Assert(err, IsNil)
+
+	req := testServer.WaitRequest()
+	c.Assert(req.Method, Equals, "POST")
+	c.Assert(req.Form["uploads"], DeepEquals, []string{""})
+	c.Assert(req.Header["Content-Type"], DeepEquals, []string{"text/plain"})
+	c.Assert(req.Header["X-Amz-Server-Side-Encryption"], DeepEquals, []string{"AES256"})
+
+	// Parts are sent concurrently, so they may arrive in any order.
+	parts := make(map[string]string)
+	for _, req := range testServer.WaitRequests(5) {
+		c.Assert(req.Method, Equals, "PUT")
+		c.Assert(req.Form.Get("uploadId"), Matches, "JNbR_[A-Za-z0-9.]+QQ--")
+		body, _ := ioutil.ReadAll(req.Body)
+		parts[req.Form.Get("partNumber")] = string(body)
+	}
+	c.Assert(parts, DeepEquals, map[string]string{
+		"1": "partA", "2": "partB", "3": "partC", "4": "partD", "5": "end",
+	})
+
+	req = testServer.WaitRequest()
+	c.Assert(req.Method, Equals, "POST")
+	c.Assert(req.Form.Get("uploadId"), Matches, "JNbR_[A-Za-z0-9.]+QQ--")
+	var payload struct {
+		Part []struct {
+			PartNumber int
+		}
+	}
+	err = xml.NewDecoder(req.Body).Decode(&payload)
+	c.Assert(err, IsNil)
+	c.Assert(payload.Part, HasLen, 5)
+	for i, p := range payload.Part {
+		c.Assert(p.PartNumber, Equals, i+1)
+	}
+}
+
+func (s *S) TestUploadRetriesPart(c *C) {
+	testServer.Response(200, nil, InitMultiResultDump)
+	testServer.Response(500, nil, InternalErrorDump)
+	testServer.Responses(2, 200, map[string]string{"ETag": `"etag"`}, "")
+	testServer.Response(200, nil, CompleteMultiResultDump)
+
+	u := &s3.Uploader{Bucket: s.s3.Bucket("sample"), PartSize: 5, Concurrency: 1}
+	err := u.Upload("multi", strings.NewReader("partAend"), "text/plain", s3.Private, s3.Options{})
+	c.Assert(err, IsNil)
+
+	reqs := testServer.WaitRequests(5)
+	c.Assert(reqs[1].Form.Get("partNumber"), Equals, "1")
+	c.Assert(reqs[2].Form.Get("partNumber"), Equals, "1")
+	c.Assert(reqs[3].Form.Get("partNumber"), Equals, "2")
+	c.Assert(reqs[4].Method, Equals, "POST")
+}
+
+func (s *S) TestUploadAbortsOnError(c *C) {
+	s.DisableRetries()
+
+	testServer.Response(200, nil, InitMultiResultDump)
+	testServer.Response(200, map[string]string{"ETag": `"etag"`}, "")
+	testServer.Response(403, nil, GetObjectErrorDump)
+	testServer.Response(204, nil, "")
+
+	u := &s3.Uploader{Bucket: s.s3.Bucket("sample"), PartSize: 5, Concurrency: 1}
+	err := u.Upload("multi", strings.NewReader("partApartBend"), "text/plain", s3.Private, s3.Options{})
+	c.Assert(err, NotNil)
+	c.Assert(err.(*s3.Error).StatusCode, Equals, 403)
+
+	reqs := testServer.WaitRequests(4)
+	c.Assert(reqs[1].Form.Get("partNumber"), Equals, "1")
+	c.Assert(reqs[2].Form.Get("partNumber"), Equals, "2")
+	c.Assert(reqs[3].Method, Equals, "DELETE")
+	c.Assert(reqs[3].Form.Get("uploadId"), Matches, "JNbR_[A-Za-z0-9.]+QQ--")
+}
+
+func (s *S) TestUploadPartSize(c *C) {
+	u := s3.NewUploader(s.s3.Bucket("sample"))
+	c.Assert(s3.UploadPartSize(u, 1), Equals, int64(s3.DefaultUploadPartSize))
+	c.Assert(s3.UploadPartSize(u, 1000), Equals, int64(s3.DefaultUploadPartSize))
+	c.Assert(s3.UploadPartSize(u, 1001), Equals, int64(2*s3.DefaultUploadPartSize))
+	c.Assert(s3.UploadPartSize(u, s3.MaxUploadParts), Equals, int64(512*s3.DefaultUploadPartSize))
+
+	// The largest objects fit in the parts of the default size.
+	var total int64
+	for n := 1; n <= s3.MaxUploadParts; n++ {
+		total += s3.UploadPartSize(u, n)
+	}
+	c.Assert(total >= 5<<40, Equals, true)
+
+	u.PartSize = 1 << 30
+	c.Assert(s3.UploadPartSize(u, 9001), Equals, int64(s3.MaxUploadPartSize))
+
+	s3.SetMinUploadPartSize(s3.MinUploadPartSize)
+	defer s3.SetMinUploadPartSize(1)
+	u.PartSize = 1 << 20
+	c.Assert(s3.UploadPartSize(u, 1), Equals, int64(s3.MinUploadPartSize))
+}
@@ -10,32 +10,44 @@
 package sqs
 
 import (
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"log"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/goamz/goamz/arn"
 	"github.com/goamz/goamz/aws"
 )
 
 const API_VERSION = "2012-11-05"
 
-const debug = false
-
 // The SQS type encapsulates operation with an SQS region.
 type SQS struct {
 	aws.Auth
 	aws.Region
-	private   byte // Reserve the right of using private data.
-	transport *http.Transport
+	private    byte // Reserve the right of using private data.
+	httpClient *http.Client
+	ctx        context.Context
+	clock      *aws.Clock
+
+	// Logger, if set, is given the lines selected by LogLevel about
+	// requests, with credentials redacted.
+	Logger   aws.Logger
+	LogLevel aws.LogLevel
+
+	// Instrumentation, if set, is told about every attempt of the
+	// calls of sqs.
+	Instrumentation aws.Instrumentation
+
+	// Handlers are run on every request of the client, after the
+	// aws.GlobalHandlers.
+	Handlers aws.Handlers
 }
 
 // NewFrom Create A new SQS Client given an access and secret Key
@@ -78,12 +90,37 @@ func NewFrom(accessKey, secretKey, region string) (*SQS, error) {
 
 // NewFrom Create A new SQS Client from an exisisting aws.Auth
 func New(auth aws.Auth, region aws.Region) *SQS {
-	return &SQS{auth, region, 0, nil}
+	return NewWithClient(auth, region, http.DefaultClient)
+}
+
+// NewWithClient creates a new SQS Client sending its requests with client.
+func NewWithClient(auth aws.Auth, region aws.Region, client *http.Client) *SQS {
+	return &SQS{Auth: auth, Region: region, httpClient: client, clock: &aws.Clock{}}
 }
 
 // NewFromTransport Create A new SQS Client that uses a given &http.Transport
 func NewFromTransport(auth aws.Auth, region aws.Region, transport *http.Transport) *SQS {
-	return &SQS{auth, region, 0, transport}
+	return NewWithClient(auth, region, &http.Client{Transport: transport})
+}
+
+// WithContext returns a shallow copy of s whose requests are bound to
+// ctx, so that they are abandoned when ctx is cancelled or its deadline
+// expires. The provided ctx must be non-nil.
+func (s *SQS) WithContext(ctx context.Context) *SQS {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
+// Context returns the context requests made by s are bound to.
+func (s *SQS) Context() context.Context {
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return context.Background()
 }
 
 // Queue Reference to a Queue
@@ -92,6 +129,12 @@ type Queue struct {
 	Url string
 }
 
+// WithContext returns a copy of q whose requests are bound to ctx.
+// A long polling ReceiveMessage returns as soon as ctx is cancelled.
+func (q *Queue) WithContext(ctx context.Context) *Queue {
+	return &Queue{q.SQS.WithContext(ctx), q.Url}
+}
+
 type CreateQueueResponse struct {
 	QueueUrl         string `xml:"CreateQueueResult>QueueUrl"`
 	ResponseMetadata ResponseMetadata
@@ -189,6 +232,13 @@ func (err *Error) Error() string {
 	return fmt.Sprintf("%s (%s)", err.Message, err.Code)
 }
 
+// HTTPStatusCode, ErrorCode, ErrorMessage and RequestID implement
+// aws.APIError.
+func (err *Error) HTTPStatusCode() int  { return err.StatusCode }
+func (err *Error) ErrorCode() string    { return err.Code }
+func (err *Error) ErrorMessage() string { return err.Message }
+func (err *Error) RequestID() string    { return err.RequestId }
+
 func (err *Error) String() string {
 	return err.Message
 }
@@ -232,7 +282,24 @@ func (s *SQS) GetQueue(queueName string) (*Queue, error) {
 	return q, nil
 }
 
+// QueueFromArn returns the queue named by an ARN, such as
+// arn:aws:sqs:us-east-1:123456789012:queue, or by a URL. The requests of
+// a queue in another region than the one of s are sent to and signed for
+// the region of its ARN, unless s has an unnamed region, such as one with
+// only a custom endpoint.
 func (s *SQS) QueueFromArn(queueUrl string) (q *Queue) {
+	if a, err := arn.Parse(queueUrl); err == nil && a.Service == "sqs" {
+		queueUrl = "/" + a.AccountID + "/" + a.ResourceID()
+		if a.Region != "" && s.Region.Name != "" && a.Region != s.Region.Name {
+			region, ok := aws.Regions[a.Region]
+			if !ok {
+				region = aws.Region{Name: a.Region}
+			}
+			c := *s
+			c.Region = region
+			s = &c
+		}
+	}
 	q = &Queue{s, queueUrl}
 	return
 }
@@ -483,74 +550,62 @@ func (q *Queue) DeleteMessageBatch(msgList []Message) (resp *DeleteMessageBatchR
 	return
 }
 
+// handlers are the handlers of SQS requests, run before the
+// aws.GlobalHandlers and the Handlers of the client.
+var handlers = func() aws.Handlers {
+	h := aws.QueryHandlers()
+	h.Build.PushBack(aws.NamedHandler{Name: "sqs.Build", Fn: func(r *aws.Request) {
+		// Message bodies are encoded the way SQS expects them.
+		var sarray []string
+		for k, v := range r.Params {
+			sarray = append(sarray, aws.Encode(k)+"="+aws.Encode(v))
+		}
+		r.HTTPRequest.URL.RawQuery = strings.Join(sarray, "&")
+	}})
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("sqs.UnmarshalError", buildError))
+	return h
+}()
+
 func (s *SQS) query(queueUrl string, params map[string]string, resp interface{}) (err error) {
 	params["Version"] = API_VERSION
 	params["Timestamp"] = time.Now().In(time.UTC).Format(time.RFC3339)
-	var url_ *url.URL
 
+	ep, err := aws.ResolveEndpoint("sqs", s.Region)
+	if err != nil {
+		return err
+	}
+	var endpoint string
 	switch {
 	// fully qualified queueUrl
 	case strings.HasPrefix(queueUrl, "http"):
-		url_, err = url.Parse(queueUrl)
+		endpoint = queueUrl
 		// relative queueUrl
 	case strings.HasPrefix(queueUrl, "/"):
-		url_, err = url.Parse(s.Region.SQSEndpoint + queueUrl)
+		endpoint = ep.URL + queueUrl
 		// zero-value for queueUrl
 	default:
-		url_, err = url.Parse(s.Region.SQSEndpoint)
-	}
-
-	if err != nil {
-		return err
-	}
-
-	if s.Auth.Token() != "" {
-		params["SecurityToken"] = s.Auth.Token()
-	}
-
-	var r *http.Response
-
-	var sarray []string
-	for k, v := range params {
-		sarray = append(sarray, aws.Encode(k)+"="+aws.Encode(v))
-	}
-
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s?%s", url_, strings.Join(sarray, "&")), nil)
-	if err != nil {
-		return err
+		endpoint = ep.URL
 	}
-	signer := aws.NewV4Signer(s.Auth, "sqs", s.Region)
-	signer.Sign(req)
-	var client http.Client
-	if s.transport == nil {
-		client = http.Client{}
-	} else {
-		client = http.Client{Transport: s.transport}
-	}
-	r, err = client.Do(req)
-
-	if debug {
-		log.Printf("GET %s\n", url_.String())
-	}
-
-	if err != nil {
-		return err
-	}
-
-	defer r.Body.Close()
 
-	if debug {
-		dump, _ := httputil.DumpResponse(r, true)
-		log.Printf("DUMP:%s\n", string(dump))
+	r := &aws.Request{
+		Service:         ep.SigningName,
+		Operation:       params["Action"],
+		Region:          s.Region,
+		Auth:            s.Auth,
+		Context:         s.Context(),
+		Method:          "GET",
+		SigningRegion:   ep.SigningRegion,
+		Endpoint:        endpoint,
+		Params:          params,
+		Result:          resp,
+		HTTPClient:      s.httpClient,
+		Clock:           s.clock,
+		Logger:          s.Logger,
+		LogLevel:        s.LogLevel,
+		Instrumentation: s.Instrumentation,
+		Handlers:        aws.ChainHandlers(handlers, aws.GlobalHandlers, s.Handlers),
 	}
-
-	if r.StatusCode != 200 {
-		return buildError(r)
-	}
-	err = xml.NewDecoder(r.Body).Decode(resp)
-	io.Copy(ioutil.Discard, r.Body)
-
-	return err
+	return r.Send()
 }
 
 func buildError(r *http.Response) error {
@@ -113,6 +113,36 @@ func (s *S) TestDeleteQueue(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (s *S) TestQueueFromArn(c *C) {
+	testServer.PrepareResponse(200, nil, TestDeleteQueueXmlOK)
+
+	q := s.sqs.QueueFromArn("arn:aws:sqs:us-east-1:123456789012:testQueue")
+	c.Assert(q.Url, Equals, "/123456789012/testQueue")
+	_, err := q.Delete()
+	c.Assert(err, IsNil)
+	req := testServer.WaitRequest()
+	c.Assert(req.URL.Path, Equals, "/123456789012/testQueue")
+
+	q = s.sqs.QueueFromArn("http://sqs.us-east-1.amazonaws.com/123456789012/testQueue")
+	c.Assert(q.Url, Equals, "http://sqs.us-east-1.amazonaws.com/123456789012/testQueue")
+}
+
+func (s *S) TestQueueFromArnRegion(c *C) {
+	client := New(s.sqs.Auth, aws.Region{Name: "faux-region-1", SQSEndpoint: testServer.URL})
+
+	q := client.QueueFromArn("arn:aws:sqs:faux-region-1:123456789012:testQueue")
+	c.Assert(q.SQS, Equals, client)
+
+	q = client.QueueFromArn("arn:aws:sqs:eu-west-1:123456789012:testQueue")
+	c.Assert(q.Url, Equals, "/123456789012/testQueue")
+	c.Assert(q.Region.Name, Equals, "eu-west-1")
+	c.Assert(client.Region.Name, Equals, "faux-region-1")
+	ep, err := aws.ResolveEndpoint("sqs", q.Region)
+	c.Assert(err, IsNil)
+	c.Assert(ep.URL, Equals, "https://sqs.eu-west-1.amazonaws.com")
+	c.Assert(ep.SigningRegion, Equals, "eu-west-1")
+}
+
 func (s *S) TestPurgeQueue(c *C) {
 	testServer.PrepareResponse(200, nil, TestPurgeQueueXmlOK)
 
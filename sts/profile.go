@@ -0,0 +1,115 @@
+package sts
+
+import (
+	"fmt"
+
+	"github.com/goamz/goamz/aws"
+)
+
+// ProfileLoader resolves named profiles of the shared config files into
+// credentials and a region, assuming roles through STS where a profile
+// sets role_arn.
+type ProfileLoader struct {
+	// ConfigFile and CredentialsFile are passed to aws.LoadProfile.
+	// Empty values select the default locations.
+	ConfigFile      string
+	CredentialsFile string
+
+	// TokenProvider returns MFA token codes for profiles that set
+	// mfa_serial.
+	TokenProvider func() (string, error)
+}
+
+// LoadProfile resolves the named profile using the default config file
+// locations. See ProfileLoader.Load.
+func LoadProfile(name string) (aws.Auth, aws.Region, error) {
+	return (&ProfileLoader{}).Load(name)
+}
+
+// Load resolves the named profile, or the AWS_PROFILE/default profile if
+// name is empty. The returned Auth refreshes temporary credentials, such
+// as those of an assumed role or of a credential_process, before they
+// expire. The returned Region is the zero Region if none is configured.
+func (l *ProfileLoader) Load(name string) (aws.Auth, aws.Region, error) {
+	profile, err := aws.LoadProfile(l.ConfigFile, l.CredentialsFile, name)
+	if err != nil {
+		return aws.Auth{}, aws.Region{}, err
+	}
+	region, err := profile.GetRegion()
+	if err != nil {
+		return aws.Auth{}, aws.Region{}, err
+	}
+	provider, err := l.provider(profile, map[string]bool{})
+	if err != nil {
+		return aws.Auth{}, aws.Region{}, err
+	}
+	if p, ok := provider.(*aws.StaticProvider); ok {
+		return p.Auth, region, nil
+	}
+	return aws.NewProviderAuth(provider), region, nil
+}
+
+func (l *ProfileLoader) provider(profile *aws.Profile, visited map[string]bool) (aws.CredentialsProvider, error) {
+	if profile.RoleArn == "" {
+		return profile.Provider()
+	}
+	if profile.WebIdentityTokenFile != "" {
+		region, err := stsRegion(profile)
+		if err != nil {
+			return nil, err
+		}
+		p := NewWebIdentityRoleProvider(New(aws.Auth{}, region), profile.RoleArn, profile.WebIdentityTokenFile)
+		p.RoleSessionName = profile.RoleSessionName
+		p.DurationSeconds = profile.DurationSeconds
+		return p, nil
+	}
+	if visited[profile.Name] {
+		return nil, fmt.Errorf("Circular source_profile reference in profile %q", profile.Name)
+	}
+	visited[profile.Name] = true
+
+	var source aws.CredentialsProvider
+	var err error
+	switch {
+	case profile.SourceProfile == profile.Name:
+		// A role profile may source its own static keys.
+		source, err = profile.Provider()
+	case profile.SourceProfile != "":
+		var sourceProfile *aws.Profile
+		sourceProfile, err = aws.LoadProfile(l.ConfigFile, l.CredentialsFile, profile.SourceProfile)
+		if err == nil {
+			source, err = l.provider(sourceProfile, visited)
+		}
+	case profile.CredentialSource != "":
+		source, err = profile.Provider()
+	default:
+		err = fmt.Errorf("Profile %q sets role_arn without source_profile or credential_source", profile.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	region, err := stsRegion(profile)
+	if err != nil {
+		return nil, err
+	}
+	p := NewAssumeRoleProvider(New(aws.NewProviderAuth(source), region), profile.RoleArn)
+	p.ExternalId = profile.ExternalId
+	p.RoleSessionName = profile.RoleSessionName
+	p.DurationSeconds = profile.DurationSeconds
+	p.SerialNumber = profile.MFASerial
+	p.TokenProvider = l.TokenProvider
+	return p, nil
+}
+
+// stsRegion returns the region the role of profile is assumed in.
+func stsRegion(profile *aws.Profile) (aws.Region, error) {
+	region, err := profile.GetRegion()
+	if err != nil {
+		return aws.Region{}, err
+	}
+	if region.STSEndpoint == "" {
+		region = aws.Regions["us-east-1"]
+	}
+	return region, nil
+}
@@ -0,0 +1,174 @@
+package sts
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/goamz/goamz/aws"
+)
+
+// Auth returns the temporary credentials as an aws.Auth.
+func (c *Credentials) Auth() aws.Auth {
+	return *aws.NewAuth(c.AccessKeyId, c.SecretAccessKey, c.SessionToken, c.Expiration)
+}
+
+// AssumeRoleProvider is an aws.CredentialsProvider that obtains temporary
+// credentials by assuming a role through STS.
+//
+// Wrap it with aws.NewCredentials or aws.NewProviderAuth to have the role
+// assumed again before the credentials expire:
+//
+//	p := sts.NewAssumeRoleProvider(sts.New(auth, aws.USEast), roleArn)
+//	ec2 := ec2.New(aws.NewProviderAuth(p), aws.USEast)
+//
+// Roles may be chained across accounts by building the STS client of one
+// provider with the provider-backed Auth of another.
+type AssumeRoleProvider struct {
+	// STS is the client the role is assumed with.
+	STS *STS
+
+	// RoleArn is the ARN of the role to assume.
+	RoleArn string
+
+	// RoleSessionName identifies the session. If empty, a name based
+	// on the current time is used.
+	RoleSessionName string
+
+	// ExternalId, DurationSeconds and Policy are passed along to
+	// AssumeRole when set.
+	ExternalId      string
+	DurationSeconds int
+	Policy          string
+
+	// SerialNumber is the identification number of the MFA device
+	// required by the role, if any. TokenProvider must then be set.
+	SerialNumber string
+
+	// TokenProvider returns the current MFA token code. It is called
+	// every time the role is assumed.
+	TokenProvider func() (string, error)
+}
+
+// NewAssumeRoleProvider returns an AssumeRoleProvider that assumes roleArn
+// using sts.
+func NewAssumeRoleProvider(sts *STS, roleArn string) *AssumeRoleProvider {
+	return &AssumeRoleProvider{STS: sts, RoleArn: roleArn}
+}
+
+// Retrieve assumes the role and returns its temporary credentials.
+func (p *AssumeRoleProvider) Retrieve() (aws.Auth, error) {
+	options := &AssumeRoleParams{
+		DurationSeconds: p.DurationSeconds,
+		ExternalId:      p.ExternalId,
+		Policy:          p.Policy,
+		RoleArn:         p.RoleArn,
+		RoleSessionName: p.RoleSessionName,
+		SerialNumber:    p.SerialNumber,
+	}
+	if options.RoleSessionName == "" {
+		options.RoleSessionName = fmt.Sprintf("goamz-%d", time.Now().UnixNano())
+	}
+	if p.SerialNumber != "" {
+		if p.TokenProvider == nil {
+			return aws.Auth{}, errors.New("sts: role requires an MFA token but no TokenProvider is set")
+		}
+		code, err := p.TokenProvider()
+		if err != nil {
+			return aws.Auth{}, err
+		}
+		options.TokenCode = code
+	}
+	resp, err := p.STS.AssumeRole(options)
+	if err != nil {
+		return aws.Auth{}, err
+	}
+	return resp.Credentials.Auth(), nil
+}
+
+// WebIdentityRoleProvider is an aws.CredentialsProvider that obtains
+// temporary credentials by assuming a role with a web identity token read
+// from a file, as done by workloads federated through OpenID Connect such
+// as EKS pods with an IAM role for their service account.
+//
+// The token file is read again every time the role is assumed, so that
+// tokens rotated by their issuer are picked up:
+//
+//	p := sts.NewWebIdentityRoleProvider(nil, "", "")
+//	ec2 := ec2.New(aws.NewProviderAuth(p), aws.USEast)
+type WebIdentityRoleProvider struct {
+	// STS is the client the role is assumed with. Its credentials are
+	// not used. If nil, a client for us-east-1 is used.
+	STS *STS
+
+	// RoleArn is the ARN of the role to assume. If empty, it is taken
+	// from the AWS_ROLE_ARN environment variable.
+	RoleArn string
+
+	// TokenFile is the path of the file holding the web identity
+	// token. If empty, it is taken from the AWS_WEB_IDENTITY_TOKEN_FILE
+	// environment variable.
+	TokenFile string
+
+	// RoleSessionName identifies the session. If empty, it is taken from
+	// the AWS_ROLE_SESSION_NAME environment variable, or else based on
+	// the current time.
+	RoleSessionName string
+
+	// DurationSeconds and Policy are passed along to
+	// AssumeRoleWithWebIdentity when set.
+	DurationSeconds int
+	Policy          string
+}
+
+// NewWebIdentityRoleProvider returns a WebIdentityRoleProvider that
+// assumes roleArn using sts with the token in tokenFile. Empty arguments
+// are taken from the environment when the role is assumed.
+func NewWebIdentityRoleProvider(sts *STS, roleArn, tokenFile string) *WebIdentityRoleProvider {
+	return &WebIdentityRoleProvider{STS: sts, RoleArn: roleArn, TokenFile: tokenFile}
+}
+
+// Retrieve reads the token and assumes the role, returning its temporary
+// credentials.
+func (p *WebIdentityRoleProvider) Retrieve() (aws.Auth, error) {
+	roleArn := p.RoleArn
+	if roleArn == "" {
+		roleArn = os.Getenv("AWS_ROLE_ARN")
+	}
+	tokenFile := p.TokenFile
+	if tokenFile == "" {
+		tokenFile = os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	}
+	if roleArn == "" || tokenFile == "" {
+		return aws.Auth{}, errors.New("AWS_ROLE_ARN or AWS_WEB_IDENTITY_TOKEN_FILE not found in environment")
+	}
+	token, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return aws.Auth{}, fmt.Errorf("sts: reading web identity token: %v", err)
+	}
+	options := &AssumeRoleWithWebIdentityParams{
+		DurationSeconds:  p.DurationSeconds,
+		Policy:           p.Policy,
+		RoleArn:          roleArn,
+		RoleSessionName:  p.RoleSessionName,
+		WebIdentityToken: strings.TrimSpace(string(token)),
+	}
+	if options.RoleSessionName == "" {
+		options.RoleSessionName = os.Getenv("AWS_ROLE_SESSION_NAME")
+	}
+	if options.RoleSessionName == "" {
+		options.RoleSessionName = fmt.Sprintf("goamz-%d", time.Now().UnixNano())
+	}
+	client := p.STS
+	if client == nil {
+		client = New(aws.Auth{}, aws.USEast)
+	}
+	resp, err := client.AssumeRoleWithWebIdentity(options)
+	if err != nil {
+		return aws.Auth{}, err
+	}
+	return resp.Credentials.Auth(), nil
+}
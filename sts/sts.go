@@ -7,14 +7,13 @@
 package sts
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/xml"
 	"fmt"
-	"log"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/goamz/goamz/aws"
@@ -24,20 +23,60 @@ import (
 type STS struct {
 	aws.Auth
 	aws.Region
+	httpClient *http.Client
+	ctx        context.Context
+	clock      *aws.Clock
+
+	// Logger, if set, is given the lines selected by LogLevel about
+	// requests, with credentials redacted.
+	Logger   aws.Logger
+	LogLevel aws.LogLevel
+
+	// Instrumentation, if set, is told about every attempt of the
+	// calls of sts.
+	Instrumentation aws.Instrumentation
+
+	// Handlers are run on every request of the client, after the
+	// aws.GlobalHandlers.
+	Handlers aws.Handlers
+
 	private byte // Reserve the right of using private data.
 }
 
 // New creates a new STS Client.
 // We can only use us-east for region because AWS..
 func New(auth aws.Auth, region aws.Region) *STS {
+	return NewWithClient(auth, region, http.DefaultClient)
+}
+
+// NewWithClient creates a new STS sending its requests with client.
+func NewWithClient(auth aws.Auth, region aws.Region, client *http.Client) *STS {
 	// Make sure we can run the package tests
-	if region.Name == "" {
-		return &STS{auth, region, 0}
+	if region.Name != "" {
+		region = aws.Regions["us-east-1"]
 	}
-	return &STS{auth, aws.Regions["us-east-1"], 0}
+	return &STS{Auth: auth, Region: region, httpClient: client, clock: &aws.Clock{}}
 }
 
-const debug = false
+// WithContext returns a shallow copy of sts whose requests are bound to
+// ctx, so that they are abandoned when ctx is cancelled or its deadline
+// expires. The provided ctx must be non-nil.
+func (sts *STS) WithContext(ctx context.Context) *STS {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c := *sts
+	c.ctx = ctx
+	return &c
+}
+
+// Context returns the context requests made by sts are bound to.
+func (sts *STS) Context() context.Context {
+	if sts.ctx != nil {
+		return sts.ctx
+	}
+	return context.Background()
+}
 
 // ----------------------------------------------------------------------------
 // Request dispatching logic.
@@ -63,53 +102,63 @@ func (err *Error) Error() string {
 	return fmt.Sprintf("%s (%s)", err.Message, err.Code)
 }
 
+// HTTPStatusCode, ErrorCode, ErrorMessage and RequestID implement
+// aws.APIError.
+func (err *Error) HTTPStatusCode() int  { return err.StatusCode }
+func (err *Error) ErrorCode() string    { return err.Code }
+func (err *Error) ErrorMessage() string { return err.Message }
+func (err *Error) RequestID() string    { return err.RequestId }
+
 type xmlErrors struct {
 	RequestId string  `xml:"RequestId"`
 	Errors    []Error `xml:"Error"`
 }
 
-func (sts *STS) query(params map[string]string, resp interface{}) error {
-	params["Version"] = "2011-06-15"
-
-	data := strings.NewReader(multimap(params).Encode())
-
-	hreq, err := http.NewRequest("POST", sts.Region.STSEndpoint+"/", data)
-	if err != nil {
-		return err
-	}
-
-	hreq.Header.Set("Content-Type", "application/x-www-form-urlencoded; param=value")
+// handlers are the handlers of STS requests, run before the
+// aws.GlobalHandlers and the Handlers of the client.
+var handlers = func() aws.Handlers {
+	h := aws.QueryHandlers()
+	h.UnmarshalError.PushBack(aws.UnmarshalErrorHandler("sts.UnmarshalError", buildError))
+	return h
+}()
+
+// unsignedHandlers are the handlers of STS requests that are not signed,
+// such as AssumeRoleWithWebIdentity, which is authenticated by its token.
+var unsignedHandlers = func() aws.Handlers {
+	h := handlers
+	h.Sign.Remove(aws.V4SignHandler.Name)
+	return h
+}()
 
-	token := sts.Auth.Token()
-	if token != "" {
-		hreq.Header.Set("X-Amz-Security-Token", token)
-	}
-
-	signer := aws.NewV4Signer(sts.Auth, "sts", sts.Region)
-	signer.Sign(hreq)
-
-	if debug {
-		log.Printf("%v -> {\n", hreq)
-	}
-	r, err := http.DefaultClient.Do(hreq)
+func (sts *STS) query(params map[string]string, resp interface{}) error {
+	return sts.send(handlers, params, resp)
+}
 
+func (sts *STS) send(h aws.Handlers, params map[string]string, resp interface{}) error {
+	params["Version"] = "2011-06-15"
+	ep, err := aws.ResolveEndpoint("sts", sts.Region)
 	if err != nil {
-		log.Printf("Error calling Amazon")
 		return err
 	}
-
-	defer r.Body.Close()
-
-	if debug {
-		dump, _ := httputil.DumpResponse(r, true)
-		log.Printf("response:\n")
-		log.Printf("%v\n}\n", string(dump))
-	}
-	if r.StatusCode != 200 {
-		return buildError(r)
+	r := &aws.Request{
+		Service:         ep.SigningName,
+		Operation:       params["Action"],
+		Region:          sts.Region,
+		Auth:            sts.Auth,
+		Context:         sts.Context(),
+		Method:          "POST",
+		SigningRegion:   ep.SigningRegion,
+		Endpoint:        ep.URL,
+		Params:          params,
+		Result:          resp,
+		HTTPClient:      sts.httpClient,
+		Clock:           sts.clock,
+		Logger:          sts.Logger,
+		LogLevel:        sts.LogLevel,
+		Instrumentation: sts.Instrumentation,
+		Handlers:        aws.ChainHandlers(h, aws.GlobalHandlers, sts.Handlers),
 	}
-	err = xml.NewDecoder(r.Body).Decode(resp)
-	return err
+	return r.Send()
 }
 
 func buildError(r *http.Response) error {
@@ -153,6 +202,8 @@ type AssumeRoleParams struct {
 	Policy          string
 	RoleArn         string
 	RoleSessionName string
+	SerialNumber    string
+	TokenCode       string
 }
 
 type AssumedRoleUser struct {
@@ -192,6 +243,12 @@ func (sts *STS) AssumeRole(options *AssumeRoleParams) (resp *AssumeRoleResult, e
 	if options.Policy != "" {
 		params["Policy"] = options.Policy
 	}
+	if options.SerialNumber != "" {
+		params["SerialNumber"] = options.SerialNumber
+	}
+	if options.TokenCode != "" {
+		params["TokenCode"] = options.TokenCode
+	}
 
 	resp = new(AssumeRoleResult)
 	if err := sts.query(params, resp); err != nil {
@@ -200,6 +257,60 @@ func (sts *STS) AssumeRole(options *AssumeRoleParams) (resp *AssumeRoleResult, e
 	return resp, nil
 }
 
+// AssumeRoleWithWebIdentityParams are the options of
+// AssumeRoleWithWebIdentity.
+//
+// See https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRoleWithWebIdentity.html for details
+type AssumeRoleWithWebIdentityParams struct {
+	DurationSeconds  int
+	Policy           string
+	ProviderId       string
+	RoleArn          string
+	RoleSessionName  string
+	WebIdentityToken string
+}
+
+type AssumeRoleWithWebIdentityResult struct {
+	AssumedRoleUser             AssumedRoleUser `xml:"AssumeRoleWithWebIdentityResult>AssumedRoleUser"`
+	Audience                    string          `xml:"AssumeRoleWithWebIdentityResult>Audience"`
+	Credentials                 Credentials     `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	PackedPolicySize            int             `xml:"AssumeRoleWithWebIdentityResult>PackedPolicySize"`
+	Provider                    string          `xml:"AssumeRoleWithWebIdentityResult>Provider"`
+	SubjectFromWebIdentityToken string          `xml:"AssumeRoleWithWebIdentityResult>SubjectFromWebIdentityToken"`
+	RequestId                   string          `xml:"ResponseMetadata>RequestId"`
+}
+
+// AssumeRoleWithWebIdentity assumes the specified role on behalf of the
+// user authenticated by an OpenID Connect or OAuth 2.0 identity provider,
+// such as the service account of a Kubernetes pod. The request is
+// authenticated by the web identity token rather than signed, so the
+// credentials of sts are not used.
+//
+// See https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRoleWithWebIdentity.html for more details.
+func (sts *STS) AssumeRoleWithWebIdentity(options *AssumeRoleWithWebIdentityParams) (resp *AssumeRoleWithWebIdentityResult, err error) {
+	params := makeParams("AssumeRoleWithWebIdentity")
+
+	params["RoleArn"] = options.RoleArn
+	params["RoleSessionName"] = options.RoleSessionName
+	params["WebIdentityToken"] = options.WebIdentityToken
+
+	if options.DurationSeconds != 0 {
+		params["DurationSeconds"] = strconv.Itoa(options.DurationSeconds)
+	}
+	if options.Policy != "" {
+		params["Policy"] = options.Policy
+	}
+	if options.ProviderId != "" {
+		params["ProviderId"] = options.ProviderId
+	}
+
+	resp = new(AssumeRoleWithWebIdentityResult)
+	if err := sts.send(unsignedHandlers, params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 // FederatedUser presents dentifiers for the federated user that is associated with the credentials.
 //
 // See http://goo.gl/uPtr7V for more details
@@ -271,3 +382,64 @@ func (sts *STS) GetSessionToken(durationSeconds int, serialnNumber, tokenCode st
 	}
 	return resp, nil
 }
+
+type GetCallerIdentityResult struct {
+	Account   string `xml:"GetCallerIdentityResult>Account"`
+	Arn       string `xml:"GetCallerIdentityResult>Arn"`
+	UserId    string `xml:"GetCallerIdentityResult>UserId"`
+	RequestId string `xml:"ResponseMetadata>RequestId"`
+}
+
+// GetCallerIdentity returns details about the IAM identity whose credentials
+// are used to call the API.
+//
+// See http://goo.gl/ZLMwUy for more details
+func (sts *STS) GetCallerIdentity() (resp *GetCallerIdentityResult, err error) {
+	params := makeParams("GetCallerIdentity")
+
+	resp = new(GetCallerIdentityResult)
+	if err := sts.query(params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// PresignGetCallerIdentity returns a URL for the GetCallerIdentity action,
+// presigned with the credentials of sts and valid for the duration of
+// expires. Whoever is handed the URL can call it to learn, and trust, the
+// identity of the signer. The given headers are signed and must be sent
+// along with the URL.
+func (sts *STS) PresignGetCallerIdentity(header http.Header, expires time.Duration) (string, error) {
+	auth, err := sts.Auth.Resolve()
+	if err != nil {
+		return "", err
+	}
+	ep, err := aws.ResolveEndpoint("sts", sts.Region)
+	if err != nil {
+		return "", err
+	}
+	params := makeParams("GetCallerIdentity")
+	params["Version"] = "2011-06-15"
+	hreq, err := http.NewRequest("GET", ep.URL+"/?"+multimap(params).Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range header {
+		hreq.Header[k] = v
+	}
+	region := sts.Region
+	region.Name = ep.SigningRegion
+	aws.NewV4Signer(auth, ep.SigningName, region).Presign(hreq, expires)
+	return hreq.URL.String(), nil
+}
+
+// EKSToken returns a bearer token that authenticates the caller to the
+// Kubernetes API server of the EKS cluster named clusterName, as the
+// "aws eks get-token" command does. The token is valid for a minute.
+func (sts *STS) EKSToken(clusterName string) (string, error) {
+	u, err := sts.PresignGetCallerIdentity(http.Header{"X-K8s-Aws-Id": {clusterName}}, time.Minute)
+	if err != nil {
+		return "", err
+	}
+	return "k8s-aws-v1." + base64.RawURLEncoding.EncodeToString([]byte(u)), nil
+}
@@ -0,0 +1,309 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Record must be used by tests using a Recorder to determine whether to
+// record new cassettes against the real AWS servers rather than replay
+// the existing ones.
+var Record bool
+
+func init() {
+	flag.BoolVar(&Record, "record", false, "Record HTTP interactions into cassettes")
+}
+
+// Cassette holds the HTTP interactions recorded by a Recorder.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a request and the response it got.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request as recorded in a cassette, without its
+// credentials, signature and timestamps.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Params url.Values  `json:"params,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	// Body is the body of requests not made of form parameters, such as
+	// JSON or XML documents.
+	Body string `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response as recorded in a cassette.
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// Recorder is an http.RoundTripper that either records the interactions of
+// a client with AWS into a cassette file, or replays them from it, so that
+// client tests can run without an AWS account.
+//
+// A test typically creates the recorder with the Record flag, uses it as
+// the transport of the client under test, and saves it once done:
+//
+//	rec, err := testutil.NewRecorder("testdata/describe_instances.json", testutil.Record)
+//	c.Assert(err, IsNil)
+//	defer rec.Save()
+//	e := ec2.NewWithClient(auth, aws.USEast, rec.Client())
+//
+// Replayed requests are matched against the recorded ones by method, path,
+// Action and parameters, leaving out credentials, signatures and
+// timestamps. Requests with a body other than form parameters are also
+// matched by body. Each recorded interaction is replayed once, in order.
+type Recorder struct {
+	// Transport sends the requests being recorded. If nil,
+	// http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	// Scrub, if set, is called on every interaction before it is
+	// recorded, to remove data the default scrubbing does not know about.
+	Scrub func(*Interaction)
+
+	filename  string
+	recording bool
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder returns a Recorder of the cassette file filename. If record
+// is set, it records interactions to be written to the file by Save.
+// Otherwise it replays those read from the file.
+func NewRecorder(filename string, record bool) (*Recorder, error) {
+	r := &Recorder{filename: filename, recording: record}
+	if record {
+		return r, nil
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("testutil: cannot parse cassette %s: %v", filename, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Client returns an http.Client using r as its transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Save writes the recorded interactions to the cassette file. It does
+// nothing when replaying.
+func (r *Recorder) Save() error {
+	if !r.recording {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(&r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.filename), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.filename, append(data, '\n'), 0644)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.recording {
+		return r.record(req, recorded)
+	}
+	return r.replay(req, recorded)
+}
+
+func (r *Recorder) record(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	i := &Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: scrubHeader(resp.Header),
+			Body:   scrubBody(string(body)),
+		},
+	}
+	if r.Scrub != nil {
+		r.Scrub(i)
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for n, i := range r.cassette.Interactions {
+		if r.used[n] || !i.Request.matches(&recorded) {
+			continue
+		}
+		r.used[n] = true
+		body := i.Response.Body
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", i.Response.Status, http.StatusText(i.Response.Status)),
+			StatusCode:    i.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        cloneHeader(i.Response.Header),
+			Body:          ioutil.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("testutil: no interaction of %s matches %s %s?%s", r.filename, recorded.Method, recorded.Path, recorded.Params.Encode())
+}
+
+func (req *RecordedRequest) matches(other *RecordedRequest) bool {
+	return req.Method == other.Method &&
+		req.Path == other.Path &&
+		req.Params.Encode() == other.Params.Encode() &&
+		req.Header.Get("X-Amz-Target") == other.Header.Get("X-Amz-Target") &&
+		req.Body == other.Body
+}
+
+// Parameters and headers left out of cassettes: credentials, signatures
+// and values changing with every request.
+var scrubbedParams = map[string]bool{
+	"AWSAccessKeyId":       true,
+	"Signature":            true,
+	"SignatureMethod":      true,
+	"SignatureVersion":     true,
+	"SecurityToken":        true,
+	"Timestamp":            true,
+	"Expires":              true,
+	"WebIdentityToken":     true,
+	"X-Amz-Algorithm":      true,
+	"X-Amz-Credential":     true,
+	"X-Amz-Date":           true,
+	"X-Amz-Expires":        true,
+	"X-Amz-Security-Token": true,
+	"X-Amz-Signature":      true,
+	"X-Amz-SignedHeaders":  true,
+}
+
+var scrubbedHeaders = []string{
+	"Authorization",
+	"Date",
+	"Set-Cookie",
+	"User-Agent",
+	"X-Amz-Content-Sha256",
+	"X-Amz-Date",
+	"X-Amz-Security-Token",
+	"X-Amzn-Authorization",
+}
+
+// Credentials returned by AWS in response bodies, such as by STS.
+var scrubbedBody = regexp.MustCompile(`(<(SecretAccessKey|SessionToken|Token)>)[^<]*(</[A-Za-z]+>)|("(SecretAccessKey|SessionToken|Token)"\s*:\s*")[^"]*(")`)
+
+func scrubBody(body string) string {
+	return scrubbedBody.ReplaceAllString(body, "${1}${4}REDACTED${3}${6}")
+}
+
+func scrubHeader(h http.Header) http.Header {
+	h = cloneHeader(h)
+	for _, k := range scrubbedHeaders {
+		h.Del(k)
+	}
+	if len(h) == 0 {
+		return nil
+	}
+	return h
+}
+
+func cloneHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, v := range h {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
+
+// recordRequest returns req as recorded in cassettes, leaving its body
+// ready to be sent.
+func recordRequest(req *http.Request) (RecordedRequest, error) {
+	recorded := RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Params: make(url.Values),
+	}
+	if recorded.Path == "" {
+		recorded.Path = "/"
+	}
+	for k, v := range req.URL.Query() {
+		recorded.Params[k] = v
+	}
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return recorded, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+			form, err := url.ParseQuery(string(body))
+			if err != nil {
+				return recorded, err
+			}
+			for k, v := range form {
+				recorded.Params[k] = append(recorded.Params[k], v...)
+			}
+		} else {
+			recorded.Body = string(body)
+		}
+	}
+	for k := range recorded.Params {
+		if scrubbedParams[k] {
+			delete(recorded.Params, k)
+		}
+	}
+	if len(recorded.Params) == 0 {
+		recorded.Params = nil
+	}
+	recorded.Header = scrubHeader(req.Header)
+	return recorded, nil
+}
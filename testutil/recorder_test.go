@@ -0,0 +1,105 @@
+package testutil_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goamz/goamz/testutil"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type S struct{}
+
+var _ = Suite(&S{})
+
+func post(c *C, client *http.Client, u string, params url.Values) (int, string) {
+	resp, err := client.Post(u, "application/x-www-form-urlencoded", strings.NewReader(params.Encode()))
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, IsNil)
+	return resp.StatusCode, string(body)
+}
+
+func (s *S) TestRecordReplay(c *C) {
+	var n int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n++
+		w.Header().Set("X-Amzn-Requestid", "req-id")
+		fmt.Fprintf(w, "<Response><Action>%s</Action><N>%d</N><SecretAccessKey>secret</SecretAccessKey></Response>", req.FormValue("Action"), n)
+	}))
+	defer ts.Close()
+
+	filename := filepath.Join(c.MkDir(), "testdata", "cassette.json")
+	rec, err := testutil.NewRecorder(filename, true)
+	c.Assert(err, IsNil)
+	client := rec.Client()
+	for _, action := range []string{"List", "List", "Get"} {
+		params := url.Values{"Action": {action}, "Signature": {"sig-" + action}, "Timestamp": {"now"}}
+		status, body := post(c, client, ts.URL+"/path", params)
+		c.Assert(status, Equals, 200)
+		c.Assert(body, Matches, ".*<SecretAccessKey>secret</SecretAccessKey>.*")
+	}
+	c.Assert(rec.Save(), IsNil)
+
+	data, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Not(Matches), "(?s).*(secret|sig-|now).*")
+
+	rec, err = testutil.NewRecorder(filename, false)
+	c.Assert(err, IsNil)
+	client = rec.Client()
+	// Credentials, signatures and timestamps are ignored, and requests
+	// get the responses recorded for them in order.
+	params := url.Values{"Action": {"Get"}, "Signature": {"other"}, "Timestamp": {"later"}}
+	status, body := post(c, client, "http://example.com/path", params)
+	c.Assert(status, Equals, 200)
+	c.Assert(body, Equals, "<Response><Action>Get</Action><N>3</N><SecretAccessKey>REDACTED</SecretAccessKey></Response>")
+	params.Set("Action", "List")
+	_, body = post(c, client, "http://example.com/path", params)
+	c.Assert(body, Matches, ".*<N>1</N>.*")
+	_, body = post(c, client, "http://example.com/path", params)
+	c.Assert(body, Matches, ".*<N>2</N>.*")
+
+	_, err = client.Post("http://example.com/path", "application/x-www-form-urlencoded", strings.NewReader(params.Encode()))
+	c.Assert(err, ErrorMatches, `.*testutil: no interaction of .*cassette.json matches POST /path\?Action=List`)
+	_, err = client.Get("http://example.com/other?Action=Get")
+	c.Assert(err, ErrorMatches, `.*testutil: no interaction of .*cassette.json matches GET /other\?Action=Get`)
+	c.Assert(n, Equals, 3)
+}
+
+func (s *S) TestReplayBody(c *C) {
+	filename := filepath.Join(c.MkDir(), "cassette.json")
+	cassette := `{"interactions": [{
+		"request": {"method": "POST", "path": "/", "header": {"X-Amz-Target": ["DynamoDB_20120810.GetItem"]}, "body": "{\"TableName\":\"t\"}"},
+		"response": {"status": 400, "header": {"Content-Type": ["application/x-amz-json-1.0"]}, "body": "{}"}
+	}]}`
+	c.Assert(ioutil.WriteFile(filename, []byte(cassette), 0644), IsNil)
+	rec, err := testutil.NewRecorder(filename, false)
+	c.Assert(err, IsNil)
+
+	req, err := http.NewRequest("POST", "http://localhost/", strings.NewReader(`{"TableName":"other"}`))
+	c.Assert(err, IsNil)
+	req.Header.Set("X-Amz-Target", "DynamoDB_20120810.GetItem")
+	_, err = rec.Client().Do(req)
+	c.Assert(err, ErrorMatches, ".*no interaction.*")
+
+	req, err = http.NewRequest("POST", "http://localhost/", strings.NewReader(`{"TableName":"t"}`))
+	c.Assert(err, IsNil)
+	req.Header.Set("X-Amz-Target", "DynamoDB_20120810.GetItem")
+	resp, err := rec.Client().Do(req)
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, 400)
+	c.Assert(resp.Status, Equals, "400 Bad Request")
+	c.Assert(resp.Header.Get("Content-Type"), Equals, "application/x-amz-json-1.0")
+}